@@ -0,0 +1,278 @@
+package arbiter
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// Admin exposes operator controls over the locks managed by a Client
+type Admin struct {
+	client *Client
+}
+
+// Admin returns the operator API of the client
+func (c *Client) Admin() *Admin {
+	return &Admin{client: c}
+}
+
+// Freeze rejects new acquisitions of locks matching pattern until Unfreeze is called.
+// The pattern is either an exact lock name or a name prefix followed by "*".
+// A pattern of just "*" freezes every lock of every client sharing the key prefix.
+// Clients with WithHashTag only freeze exact names and return ErrHashTagged for prefixes.
+// Current holders keep their locks and may still refresh and release them,
+// which lets operators drain activity around a troubled resource.
+func (a *Admin) Freeze(ctx context.Context, pattern string) error {
+	if err := a.client.authorize(ctx, OpFreeze, pattern); err != nil {
+		return err
+	}
+
+	for _, c := range a.client.backends() {
+		set, err := c.frozenSet(pattern)
+		if err == nil {
+			_, err = c.do(ctx, "SADD", set, pattern)
+		}
+		if err != nil {
+			a.client.logger.Error(ctx, "Failed to freeze locks: %s, error: %v", pattern, err)
+			return err
+		}
+	}
+
+	a.client.logger.Warn(ctx, "Froze locks: %s", pattern)
+	return nil
+}
+
+// Unfreeze allows new acquisitions of locks matching a pattern previously passed to Freeze
+func (a *Admin) Unfreeze(ctx context.Context, pattern string) error {
+	if err := a.client.authorize(ctx, OpUnfreeze, pattern); err != nil {
+		return err
+	}
+
+	for _, c := range a.client.backends() {
+		set, err := c.frozenSet(pattern)
+		if err == nil {
+			_, err = c.do(ctx, "SREM", set, pattern)
+		}
+		if err != nil {
+			a.client.logger.Error(ctx, "Failed to unfreeze locks: %s, error: %v", pattern, err)
+			return err
+		}
+	}
+
+	a.client.logger.Info(ctx, "Unfroze locks: %s", pattern)
+	return nil
+}
+
+// Frozen returns the currently frozen patterns in lexical order
+func (a *Admin) Frozen(ctx context.Context) ([]string, error) {
+	c := a.client
+	var keys []string
+	for _, kind := range []string{"frozen", "frozen-prefixes"} {
+		shards, err := c.shardKeys(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, shards...)
+	}
+
+	// Shards of different slots cannot be read in one SUNION on a cluster
+	seen := make(map[string]bool)
+	patterns := []string{}
+	for _, key := range keys {
+		members, err := replyStrings(c.do(ctx, "SMEMBERS", key))
+		if err != nil {
+			return nil, err
+		}
+		for _, pattern := range members {
+			if !seen[pattern] {
+				seen[pattern] = true
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+
+	sort.Strings(patterns)
+	return patterns, nil
+}
+
+// ForceUnlock releases a lock regardless of its owner, for recovering stuck resources.
+// The previous holder finds out on its next refresh. It returns ErrLockNotHeld if the
+// lock was not held.
+func (a *Admin) ForceUnlock(ctx context.Context, name string) error {
+	if err := a.client.authorize(ctx, OpForceUnlock, name); err != nil {
+		return err
+	}
+
+	return a.forceUnlock(ctx, name)
+}
+
+// forceUnlock releases a lock regardless of its owner. With a field and value
+// in cond, it only releases the lock while the field holds the value and
+// returns ErrLockIDMismatch otherwise.
+func (a *Admin) forceUnlock(ctx context.Context, name string, cond ...string) error {
+	c := a.client.route(name)
+	key := c.lockKey(name)
+
+	args := []interface{}{c.eventsChannel(), c.releasesChannel()}
+	for _, arg := range cond {
+		args = append(args, arg)
+	}
+	res, err := c.runScript(ctx, lua.ForceUnlock, []string{key, c.heartbeatKey(key), c.permanentKey(key), c.heldKey(key)}, args...)
+	if err != nil {
+		c.logger.Error(ctx, "Failed to force unlock: %s, error: %v", name, err)
+		return err
+	}
+	if res == nil {
+		return ErrLockNotHeld
+	}
+	owner, ok := res.(string)
+	if !ok {
+		return ErrLockIDMismatch
+	}
+
+	c.buryTombstone(ctx, key, owner, 0, ReasonForceUnlocked)
+	c.logger.Warn(ctx, "Force unlocked: %s, previous owner: %s", name, owner)
+	event := Event{Type: EventForceUnlock, Name: name, Owner: owner}
+	if identity, ok := IdentityFromContext(ctx); ok {
+		event.Detail = "by " + identity
+	}
+	c.emit(ctx, event)
+	return nil
+}
+
+// Steal takes a lock over regardless of its current owner, for recovering stuck
+// resources without a window for a third party to acquire it, and returns the lock
+// held by the caller. Unlike ForceUnlock it also bypasses freezes and quotas. The
+// previous holder finds out on its next refresh, so its watchdog reports the lock
+// lost and the Done channels of its leases fire.
+func (a *Admin) Steal(ctx context.Context, name string, opts ...Option) (Lock, error) {
+	if err := a.client.authorize(ctx, OpSteal, name); err != nil {
+		return nil, err
+	}
+
+	lock := a.client.NewLock(name, opts...)
+	previous, err := lock.(*lockImpl).steal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := a.client.route(name)
+	if previous != "" {
+		c.buryTombstone(ctx, c.lockKey(name), previous, 0, ReasonStolen)
+	}
+	c.logger.Warn(ctx, "Stole lock: %s, previous owner: %s", name, previous)
+	event := Event{Type: EventSteal, Name: name, Owner: previous}
+	if identity, ok := IdentityFromContext(ctx); ok {
+		event.Detail = "by " + identity
+	}
+	c.emit(ctx, event)
+	return lock, nil
+}
+
+// Annotate attaches a free-form annotation to a held lock without affecting its ownership,
+// e.g. "incident" = "INC-123, contact @alice". Annotations are listed in LockInfo and
+// disappear with the lock. It returns ErrLockNotHeld if the lock is not held.
+func (a *Admin) Annotate(ctx context.Context, name, key, value string) error {
+	if err := a.client.authorize(ctx, OpAnnotate, name); err != nil {
+		return err
+	}
+
+	c := a.client.route(name)
+	ok, err := c.runScriptBool(ctx, lua.Annotate, []string{c.lockKey(name)}, annotationFieldPrefix+key, c.encodeValue(ctx, value))
+	if err != nil {
+		a.client.logger.Error(ctx, "Failed to annotate lock: %s, error: %v", name, err)
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+
+	a.client.logger.Info(ctx, "Annotated lock: %s, %s=%s", name, key, value)
+	return nil
+}
+
+// RemoveAnnotation removes an annotation previously attached with Annotate
+func (a *Admin) RemoveAnnotation(ctx context.Context, name, key string) error {
+	if err := a.client.authorize(ctx, OpRemoveAnnotation, name); err != nil {
+		return err
+	}
+
+	c := a.client.route(name)
+	_, err := c.do(ctx, "HDEL", c.lockKey(name), annotationFieldPrefix+key)
+	return err
+}
+
+// ListLocks returns the locks currently held under the client key prefix.
+// It walks the keyspace with SCAN, so prefer InspectLocks when the names are known.
+// Coalesced locks are listed under their bucket name.
+func (a *Admin) ListLocks(ctx context.Context) ([]LockInfo, error) {
+	var held []LockInfo
+	for _, c := range a.client.backends() {
+		infos, err := c.listLocks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		held = append(held, infos...)
+	}
+
+	sort.Slice(held, func(i, j int) bool { return held[i].Name < held[j].Name })
+	return held, nil
+}
+
+// listLocks returns the locks held in the Redis of c
+func (c *Client) listLocks(ctx context.Context) ([]LockInfo, error) {
+	match := escapeGlob(c.prefix) + "*"
+	internal := c.key(reservedPrefix)
+
+	var names, keys []string
+	err := c.scan(ctx, match, func(key string) error {
+		if !strings.HasPrefix(key, internal) {
+			names = append(names, c.lockName(key))
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := c.inspectKeys(ctx, names, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	held := infos[:0]
+	for _, info := range infos {
+		if info.Held {
+			held = append(held, info)
+		}
+	}
+	return held, nil
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as patterns
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// frozenSet returns the set a frozen pattern is stored in. With hash tags only exact
+// names can be frozen, in the shard of their lock.
+func (c *Client) frozenSet(pattern string) (string, error) {
+	if strings.HasSuffix(pattern, "*") {
+		if c.hashTag {
+			return "", ErrHashTagged
+		}
+		return c.frozenPrefixKey(""), nil
+	}
+	return c.frozenKey(c.lockKey(pattern)), nil
+}
@@ -0,0 +1,274 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAdminFreeze(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-admin:"))
+	admin := client.Admin()
+	ctx := context.Background()
+
+	t.Run("frozen lock rejects new owners", func(t *testing.T) {
+		holder := client.NewLock("test-freeze")
+		other := client.NewLock("test-freeze")
+
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		if err := admin.Freeze(ctx, "test-freeze"); err != nil {
+			t.Fatalf("Failed to freeze lock: %v", err)
+		}
+		defer admin.Unfreeze(ctx, "test-freeze")
+
+		// The current holder may re-enter and refresh its lock
+		acquired, err := holder.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Holder should re-enter frozen lock, got: %v, %v", acquired, err)
+		}
+		if err := holder.Refresh(ctx); err != nil {
+			t.Fatalf("Holder should refresh frozen lock: %v", err)
+		}
+		if err := holder.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+
+		if _, err := other.TryLock(ctx); err != ErrLockFrozen {
+			t.Fatalf("Expected frozen error, got: %v", err)
+		}
+		if err := other.Lock(ctx); err != ErrLockFrozen {
+			t.Fatalf("Expected frozen error, got: %v", err)
+		}
+	})
+
+	t.Run("prefix pattern", func(t *testing.T) {
+		if err := admin.Freeze(ctx, "orders:*"); err != nil {
+			t.Fatalf("Failed to freeze locks: %v", err)
+		}
+
+		patterns, err := admin.Frozen(ctx)
+		if err != nil {
+			t.Fatalf("Failed to list frozen patterns: %v", err)
+		}
+		if len(patterns) != 1 || patterns[0] != "orders:*" {
+			t.Fatalf("Unexpected frozen patterns: %v", patterns)
+		}
+
+		if _, err := client.NewLock("orders:42").TryLock(ctx); err != ErrLockFrozen {
+			t.Fatalf("Expected frozen error, got: %v", err)
+		}
+
+		if err := admin.Unfreeze(ctx, "orders:*"); err != nil {
+			t.Fatalf("Failed to unfreeze locks: %v", err)
+		}
+
+		lock := client.NewLock("orders:42")
+		acquired, err := lock.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Should acquire unfrozen lock, got: %v, %v", acquired, err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+	})
+
+	t.Run("patterns are matched against unprefixed names", func(t *testing.T) {
+		for _, prefix := range []string{defaultKeyPrefix, "test-admin-other:"} {
+			other := NewClient(redisClient, WithKeyPrefix(prefix))
+			if err := other.Admin().Freeze(ctx, "test-freeze-prefix"); err != nil {
+				t.Fatalf("Failed to freeze lock: %v", err)
+			}
+
+			if _, err := other.NewLock("test-freeze-prefix").TryLock(ctx); err != ErrLockFrozen {
+				t.Errorf("Expected frozen error with prefix %q, got: %v", prefix, err)
+			}
+
+			// Freezing is scoped to the key prefix of the client
+			lock := client.NewLock("test-freeze-prefix")
+			acquired, err := lock.TryLock(ctx)
+			if err != nil || !acquired {
+				t.Errorf("Should acquire lock under another prefix, got: %v, %v", acquired, err)
+			}
+			lock.Unlock(ctx)
+
+			if err := other.Admin().Unfreeze(ctx, "test-freeze-prefix"); err != nil {
+				t.Fatalf("Failed to unfreeze lock: %v", err)
+			}
+		}
+	})
+
+	t.Run("wildcard freezes every lock", func(t *testing.T) {
+		if err := admin.Freeze(ctx, "*"); err != nil {
+			t.Fatalf("Failed to freeze locks: %v", err)
+		}
+		defer admin.Unfreeze(ctx, "*")
+
+		if _, err := client.NewLock("anything").TryLock(ctx); err != ErrLockFrozen {
+			t.Fatalf("Expected frozen error, got: %v", err)
+		}
+	})
+
+	t.Run("reserved names are rejected", func(t *testing.T) {
+		lock := client.NewLock(reservedPrefix + "frozen")
+
+		if _, err := lock.TryLock(ctx); err != ErrReservedLockName {
+			t.Fatalf("Expected reserved name error, got: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != ErrReservedLockName {
+			t.Fatalf("Expected reserved name error, got: %v", err)
+		}
+	})
+}
+
+func TestAdminAnnotations(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-annotate:"))
+	admin := client.Admin()
+	ctx := context.Background()
+
+	if err := admin.Annotate(ctx, "test-free", "incident", "INC-1"); err != ErrLockNotHeld {
+		t.Fatalf("Expected not held error, got: %v", err)
+	}
+	if exists, _ := redisClient.Exists(ctx, client.lockKey("test-free")).Result(); exists != 0 {
+		t.Fatal("Annotating a free lock should not create its key")
+	}
+
+	lock := client.NewLock("test-annotated")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer lock.Unlock(ctx)
+
+	if err := admin.Annotate(ctx, "test-annotated", "incident", "INC-123, contact @alice"); err != nil {
+		t.Fatalf("Failed to annotate lock: %v", err)
+	}
+
+	infos, err := admin.ListLocks(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list locks: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "test-annotated" {
+		t.Fatalf("Unexpected locks: %+v", infos)
+	}
+	if got := infos[0].Annotations["incident"]; got != "INC-123, contact @alice" {
+		t.Errorf("Unexpected annotation: %q", got)
+	}
+	if len(infos[0].Metadata) != 0 {
+		t.Errorf("Annotations should not be reported as metadata: %v", infos[0].Metadata)
+	}
+
+	// Annotations never affect ownership
+	if err := lock.Refresh(ctx); err != nil {
+		t.Fatalf("Failed to refresh annotated lock: %v", err)
+	}
+
+	if err := admin.RemoveAnnotation(ctx, "test-annotated", "incident"); err != nil {
+		t.Fatalf("Failed to remove annotation: %v", err)
+	}
+	infos, err = client.InspectLocks(ctx, []string{"test-annotated"})
+	if err != nil {
+		t.Fatalf("Failed to inspect lock: %v", err)
+	}
+	if len(infos[0].Annotations) != 0 {
+		t.Errorf("Annotation should be removed: %v", infos[0].Annotations)
+	}
+}
+
+func TestAdminForceUnlock(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	sink := &recordingSink{}
+	client := NewClient(redisClient, WithKeyPrefix("test-force:"), WithEventSink(sink))
+	ctx := context.Background()
+
+	if err := client.Admin().ForceUnlock(ctx, "test-free"); err != ErrLockNotHeld {
+		t.Fatalf("Expected not held error, got: %v", err)
+	}
+
+	lock := client.NewLock("test-stuck", WithWatchDog(true), WithWatchDogTimeout(300*time.Millisecond))
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	if err := client.Admin().ForceUnlock(ctx, "test-stuck"); err != nil {
+		t.Fatalf("Failed to force unlock: %v", err)
+	}
+	if locked, _ := client.IsLocked(ctx, "test-stuck"); locked {
+		t.Fatal("Lock should be released")
+	}
+
+	// The watchdog of the previous holder notices on its next refresh
+	waitFor(t, func() bool { return len(sink.types()) == 2 })
+	if types := sink.types(); types[0] != EventForceUnlock || types[1] != EventLockLost {
+		t.Errorf("Unexpected events: %v", types)
+	}
+
+	if err := lock.Unlock(ctx); err != ErrLockNotHeld {
+		t.Fatalf("Expected not held error, got: %v", err)
+	}
+}
+
+func TestAdminSteal(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	sink := &recordingSink{}
+	client := NewClient(redisClient, WithKeyPrefix("test-steal:"), WithEventSink(sink))
+	ctx := ContextWithIdentity(context.Background(), "alice")
+
+	stuck := client.NewLock("test-stuck", WithWatchDog(true), WithWatchDogTimeout(300*time.Millisecond))
+	lease, err := stuck.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	fence := lease.Fence()
+
+	if err := client.Admin().Freeze(ctx, "test-stuck"); err != nil {
+		t.Fatalf("Failed to freeze lock: %v", err)
+	}
+	defer client.Admin().Unfreeze(ctx, "test-stuck")
+
+	lock, err := client.Admin().Steal(ctx, "test-stuck")
+	if err != nil {
+		t.Fatalf("Failed to steal lock: %v", err)
+	}
+	defer lock.Unlock(ctx)
+	if lock.Fence() <= fence {
+		t.Errorf("Stolen lock should take a new fencing token, got %d after %d", lock.Fence(), fence)
+	}
+	if acquired, _ := stuck.TryLock(ctx); acquired {
+		t.Fatal("Stolen lock should be held by the new owner")
+	}
+
+	// The lease of the previous holder ends on its next refresh
+	select {
+	case <-lease.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Lease of the previous holder should end")
+	}
+	if err := lease.Release(ctx); err != ErrLockNotHeld {
+		t.Errorf("Expected not held error, got: %v", err)
+	}
+
+	waitFor(t, func() bool { return len(sink.types()) == 2 })
+	if types := sink.types(); types[0] != EventSteal || types[1] != EventLockLost {
+		t.Errorf("Unexpected events: %v", types)
+	}
+
+	// Stealing a free lock acquires it
+	free, err := client.Admin().Steal(ctx, "test-free")
+	if err != nil {
+		t.Fatalf("Failed to steal free lock: %v", err)
+	}
+	if err := free.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release stolen lock: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+// Package arbiterasynq provides hibiken/asynq middleware that runs task handlers under
+// arbiter locks, so that at most one worker of a fleet processes a given task at a time.
+package arbiterasynq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/huimingz/arbiter"
+)
+
+// ErrTaskLocked is returned by the handler of a task whose lock is held by another
+// worker. asynq treats it as a failure and retries the task later.
+var ErrTaskLocked = errors.New("task locked by another worker")
+
+// KeyFunc returns the name of the lock a task runs under
+type KeyFunc func(ctx context.Context, task *asynq.Task) string
+
+// ByType serializes every task of a type
+func ByType(_ context.Context, task *asynq.Task) string {
+	return "asynq:type:" + task.Type()
+}
+
+// ByTaskID serializes the executions of each task, e.g. a retry overlapping with a
+// still running attempt whose lease on the queue lapsed
+func ByTaskID(ctx context.Context, task *asynq.Task) string {
+	id, _ := asynq.GetTaskID(ctx)
+	return "asynq:task:" + task.Type() + ":" + id
+}
+
+// Middleware returns asynq middleware that runs each handler under the lock named by
+// key. Without a wait timeout in opts a held lock fails the task with ErrTaskLocked
+// right away, with one the handler waits that long for the lock. The lock is kept by
+// the watchdog while the handler runs unless opts say otherwise, and the handler
+// context is cancelled with cause arbiter.ErrLockLost if the lock is lost.
+func Middleware(client *arbiter.Client, key KeyFunc, opts ...arbiter.Option) asynq.MiddlewareFunc {
+	opts = append([]arbiter.Option{arbiter.WithWatchDog(true)}, opts...)
+
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+			name := key(ctx, task)
+			lock := client.NewLock(name, opts...)
+
+			if err := acquire(ctx, lock, opts); err != nil {
+				return err
+			}
+			defer lock.Unlock(context.WithoutCancel(ctx))
+
+			_, ctx = lock.Group(ctx)
+			return next.ProcessTask(ctx, task)
+		})
+	}
+}
+
+// acquire takes the lock, waiting only if opts set a wait timeout
+func acquire(ctx context.Context, lock arbiter.Lock, opts []arbiter.Option) error {
+	options := &arbiter.LockOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.WaitTimeout > 0 {
+		if err := lock.Lock(ctx); err != nil {
+			if err == arbiter.ErrLockTimeout {
+				return ErrTaskLocked
+			}
+			return fmt.Errorf("acquire task lock: %w", err)
+		}
+		return nil
+	}
+
+	acquired, err := lock.TryLock(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire task lock: %w", err)
+	}
+	if !acquired {
+		return ErrTaskLocked
+	}
+	return nil
+}
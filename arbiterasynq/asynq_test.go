@@ -0,0 +1,112 @@
+package arbiterasynq
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+
+	return client
+}
+
+func TestMiddleware(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := arbiter.NewClient(redisClient, arbiter.WithKeyPrefix("test-asynq:"))
+	ctx := context.Background()
+
+	// processConcurrently hands the task to three workers at once and returns their errors
+	processConcurrently := func(middleware asynq.MiddlewareFunc, task *asynq.Task, fn asynq.HandlerFunc) []error {
+		handler := middleware(fn)
+		errs := make([]error, 3)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = handler.ProcessTask(ctx, task)
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	t.Run("held lock fails the task", func(t *testing.T) {
+		var runs atomic.Int32
+		errs := processConcurrently(Middleware(client, ByType), asynq.NewTask("report", nil),
+			func(ctx context.Context, task *asynq.Task) error {
+				runs.Add(1)
+				time.Sleep(200 * time.Millisecond)
+				return nil
+			})
+
+		if n := runs.Load(); n != 1 {
+			t.Fatalf("Expected a single execution, got: %d", n)
+		}
+		locked := 0
+		for _, err := range errs {
+			if err == ErrTaskLocked {
+				locked++
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+		if locked != 2 {
+			t.Errorf("Expected two locked tasks, got: %d", locked)
+		}
+		if held, _ := client.IsLocked(ctx, ByType(ctx, asynq.NewTask("report", nil))); held {
+			t.Fatal("Lock should be released after the handler")
+		}
+	})
+
+	t.Run("wait timeout serializes handlers", func(t *testing.T) {
+		var runs, running atomic.Int32
+		errs := processConcurrently(Middleware(client, ByType, arbiter.WithWaitTimeout(5*time.Second)), asynq.NewTask("sync", nil),
+			func(ctx context.Context, task *asynq.Task) error {
+				if running.Add(1) > 1 {
+					t.Error("Handlers should not overlap")
+				}
+				time.Sleep(50 * time.Millisecond)
+				running.Add(-1)
+				runs.Add(1)
+				return nil
+			})
+
+		for _, err := range errs {
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+		if n := runs.Load(); n != 3 {
+			t.Fatalf("Expected every task to run in turn, got: %d", n)
+		}
+	})
+
+	t.Run("handler error is returned", func(t *testing.T) {
+		failure := asynq.SkipRetry
+		handler := Middleware(client, ByTaskID)(asynq.HandlerFunc(func(context.Context, *asynq.Task) error {
+			return failure
+		}))
+		if err := handler.ProcessTask(ctx, asynq.NewTask("failing", nil)); err != failure {
+			t.Fatalf("Expected handler error, got: %v", err)
+		}
+	})
+}
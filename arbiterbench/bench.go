@@ -0,0 +1,243 @@
+// Package arbiterbench drives contention scenarios against an arbiter backend and
+// reports throughput, fairness and lost locks, so performance regressions and backend
+// choices can be compared with numbers instead of impressions.
+package arbiterbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/huimingz/arbiter"
+)
+
+// errorBackoff is how long a worker backs off after a failed acquisition, so an
+// unreachable backend does not turn the run into a busy loop
+const errorBackoff = 100 * time.Millisecond
+
+// Scenario describes the contention driven against a backend. Workers of every
+// process running the same scenario compete for the same Locks lock names.
+type Scenario struct {
+	// Workers is the number of goroutines acquiring locks, default 8
+	Workers int `json:"workers"`
+
+	// Locks is the number of lock names the workers compete for, default 1
+	Locks int `json:"locks"`
+
+	// Hold is how long a worker holds a lock once acquired
+	Hold time.Duration `json:"hold"`
+
+	// Pause is how long a worker waits between releasing and acquiring again
+	Pause time.Duration `json:"pause"`
+
+	// Duration is how long the scenario runs, default 10s
+	Duration time.Duration `json:"duration"`
+
+	// Prefix is prepended to the lock names, default "arbiter-bench:"
+	Prefix string `json:"prefix"`
+
+	// LockOptions are passed to every lock, e.g. wait timeout or lease time
+	LockOptions []arbiter.Option `json:"-"`
+}
+
+// Report is the outcome of a scenario run
+type Report struct {
+	Scenario Scenario      `json:"scenario"`
+	Elapsed  time.Duration `json:"elapsed"`
+
+	// Acquisitions counts successful acquisitions, Timeouts the ones given up on
+	// with ErrLockTimeout and Errors every other failure
+	Acquisitions int64 `json:"acquisitions"`
+	Timeouts     int64 `json:"timeouts"`
+	Errors       int64 `json:"errors"`
+
+	// Lost counts locks found lost while held
+	Lost int64 `json:"lost"`
+
+	// Overlaps counts acquisitions while a worker of this process still held the
+	// same lock, a mutual exclusion violation
+	Overlaps int64 `json:"overlaps"`
+
+	// Throughput is the number of acquisitions per second
+	Throughput float64 `json:"throughput"`
+
+	// Fairness is Jain's fairness index over the acquisitions per worker, 1 if every
+	// worker got the same share and 1/Workers if a single worker got all of them
+	Fairness float64 `json:"fairness"`
+
+	// WaitP50, WaitP99 and WaitMax are percentiles of the time taken to acquire
+	WaitP50 time.Duration `json:"wait_p50"`
+	WaitP99 time.Duration `json:"wait_p99"`
+	WaitMax time.Duration `json:"wait_max"`
+}
+
+// Run drives scenario against locker until its duration elapsed or ctx is done and
+// returns the report. Errors of single acquisitions are counted, not returned.
+func Run(ctx context.Context, locker arbiter.Locker, scenario Scenario) (*Report, error) {
+	scenario = withDefaults(scenario)
+	if scenario.Hold < 0 || scenario.Pause < 0 {
+		return nil, errors.New("arbiterbench: hold and pause must not be negative")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, scenario.Duration)
+	defer cancel()
+
+	var (
+		report   = &Report{Scenario: scenario}
+		holders  = make([]atomic.Int32, scenario.Locks)
+		counts   = make([]int64, scenario.Workers)
+		mu       sync.Mutex
+		waits    []time.Duration
+		wg       sync.WaitGroup
+		lostOpts = append(scenario.LockOptions[:len(scenario.LockOptions):len(scenario.LockOptions)],
+			arbiter.WithLostCallback(func(ctx context.Context, err error) {
+				// Locks still held when the run ends are not lost
+				if ctx.Err() == nil {
+					atomic.AddInt64(&report.Lost, 1)
+				}
+			}))
+	)
+
+	start := time.Now()
+	for w := 0; w < scenario.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			var local []time.Duration
+			for i := w; ctx.Err() == nil; i++ {
+				n := i % scenario.Locks
+				lock := locker.NewLock(scenario.Prefix+strconv.Itoa(n), lostOpts...)
+
+				begin := time.Now()
+				err := lock.Lock(ctx)
+				switch {
+				case err == nil:
+				case ctx.Err() != nil:
+					// The run ended while waiting, not a failed acquisition
+					continue
+				case errors.Is(err, arbiter.ErrLockTimeout):
+					atomic.AddInt64(&report.Timeouts, 1)
+					continue
+				default:
+					atomic.AddInt64(&report.Errors, 1)
+					sleep(ctx, errorBackoff)
+					continue
+				}
+				local = append(local, time.Since(begin))
+				counts[w]++
+				atomic.AddInt64(&report.Acquisitions, 1)
+
+				if holders[n].Add(1) > 1 {
+					atomic.AddInt64(&report.Overlaps, 1)
+				}
+				sleep(ctx, scenario.Hold)
+				holders[n].Add(-1)
+
+				if err := lock.Unlock(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, arbiter.ErrLockNotHeld) {
+					atomic.AddInt64(&report.Errors, 1)
+				}
+				sleep(ctx, scenario.Pause)
+			}
+
+			mu.Lock()
+			waits = append(waits, local...)
+			mu.Unlock()
+		}(w)
+	}
+	wg.Wait()
+
+	report.Elapsed = time.Since(start)
+	report.Throughput = float64(report.Acquisitions) / report.Elapsed.Seconds()
+	report.Fairness = fairness(counts)
+
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	report.WaitP50 = percentile(waits, 0.50)
+	report.WaitP99 = percentile(waits, 0.99)
+	report.WaitMax = percentile(waits, 1)
+
+	return report, nil
+}
+
+// Print writes the report in a human readable form to w
+func (r *Report) Print(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `workers:      %d
+locks:        %d
+hold:         %s
+elapsed:      %s
+acquisitions: %d (%.1f/s)
+timeouts:     %d
+errors:       %d
+lost:         %d
+overlaps:     %d
+fairness:     %.3f
+wait p50:     %s
+wait p99:     %s
+wait max:     %s
+`,
+		r.Scenario.Workers, r.Scenario.Locks, r.Scenario.Hold, r.Elapsed.Round(time.Millisecond),
+		r.Acquisitions, r.Throughput, r.Timeouts, r.Errors, r.Lost, r.Overlaps, r.Fairness,
+		r.WaitP50, r.WaitP99, r.WaitMax)
+	return err
+}
+
+func withDefaults(s Scenario) Scenario {
+	if s.Workers <= 0 {
+		s.Workers = 8
+	}
+	if s.Locks <= 0 {
+		s.Locks = 1
+	}
+	if s.Duration <= 0 {
+		s.Duration = 10 * time.Second
+	}
+	if s.Prefix == "" {
+		s.Prefix = "arbiter-bench:"
+	}
+	return s
+}
+
+// fairness returns Jain's fairness index of counts, 1 if nothing was counted
+func fairness(counts []int64) float64 {
+	var sum, squares float64
+	for _, c := range counts {
+		sum += float64(c)
+		squares += float64(c) * float64(c)
+	}
+	if squares == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(counts)) * squares)
+}
+
+// percentile returns the p-th percentile of the sorted durations, 0 if there are none
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
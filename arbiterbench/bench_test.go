@@ -0,0 +1,82 @@
+package arbiterbench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+
+	return client
+}
+
+func TestRun(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := arbiter.NewClient(redisClient, arbiter.WithKeyPrefix("test-bench:"))
+	report, err := Run(context.Background(), client, Scenario{
+		Workers:  4,
+		Locks:    2,
+		Hold:     5 * time.Millisecond,
+		Duration: 500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.Acquisitions == 0 {
+		t.Error("Workers should acquire locks")
+	}
+	if report.Overlaps != 0 || report.Lost != 0 || report.Errors != 0 {
+		t.Errorf("Expected no overlaps, lost locks or errors, got: %+v", report)
+	}
+	if report.Fairness <= 0 || report.Fairness > 1 {
+		t.Errorf("Fairness should be in (0, 1], got: %v", report.Fairness)
+	}
+	if report.WaitP50 > report.WaitP99 || report.WaitP99 > report.WaitMax {
+		t.Errorf("Wait percentiles should be ordered, got: %v, %v, %v", report.WaitP50, report.WaitP99, report.WaitMax)
+	}
+}
+
+func TestFairness(t *testing.T) {
+	tests := []struct {
+		counts []int64
+		want   float64
+	}{
+		{[]int64{5, 5, 5, 5}, 1},
+		{[]int64{8, 0, 0, 0}, 0.25},
+		{[]int64{0, 0}, 1},
+	}
+	for _, tt := range tests {
+		if got := fairness(tt.counts); got != tt.want {
+			t.Errorf("fairness(%v) = %v, want %v", tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if got := percentile(sorted, 0.5); got != 5 {
+		t.Errorf("p50 = %v, want 5", got)
+	}
+	if got := percentile(sorted, 0.99); got != 10 {
+		t.Errorf("p99 = %v, want 10", got)
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("p50 of nothing = %v, want 0", got)
+	}
+}
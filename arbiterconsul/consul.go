@@ -0,0 +1,293 @@
+// Package arbiterconsul runs arbiter locks on Consul sessions, for deployments
+// standardized on Consul for service coordination. Importing it registers the
+// "consul" driver for arbiter.Open.
+package arbiterconsul
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/huimingz/arbiter"
+)
+
+const (
+	// defaultKVPrefix is the KV path locks are stored below
+	defaultKVPrefix = "arbiter/"
+
+	// minSessionTTL and maxSessionTTL are the session TTLs Consul accepts
+	minSessionTTL = 10 * time.Second
+	maxSessionTTL = 24 * time.Hour
+
+	// watchRetry is how long Watch waits after a failed blocking query
+	watchRetry = time.Second
+)
+
+// Store is an arbiter.Store on Consul. Every owner of a lock holds it through a session
+// of its own whose TTL is the lease, renewed by Extend. Sessions release their locks
+// when they expire, keeping the KV entry, so its lock index serves as fencing token
+// and keeps increasing across owners.
+type Store struct {
+	client    *api.Client
+	prefix    string
+	lockDelay time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]string
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithKVPrefix sets the KV path locks are stored below, "arbiter/" by default
+func WithKVPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithLockDelay sets how long Consul refuses a lock released by an expired session,
+// 15s by default. It protects against a holder that still works after losing its
+// session, at the price of a gap after every lost lock.
+func WithLockDelay(d time.Duration) Option {
+	return func(s *Store) {
+		s.lockDelay = d
+	}
+}
+
+// NewStore returns a Store on the Consul agent of client
+func NewStore(client *api.Client, opts ...Option) *Store {
+	s := &Store{
+		client:   client,
+		prefix:   defaultKVPrefix,
+		sessions: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewClient returns an arbiter client whose locks run on the Consul agent of client.
+// Operations that need Redis return arbiter.ErrStoreUnsupported.
+func NewClient(client *api.Client, opts ...Option) *arbiter.Client {
+	return arbiter.NewClient(nil, arbiter.WithStore(NewStore(client, opts...)))
+}
+
+func (s *Store) TryAcquire(ctx context.Context, req arbiter.LeaseRequest) (arbiter.AcquireResult, error) {
+	acquired, err := s.acquire(ctx, req)
+	if errors.Is(err, errSessionGone) {
+		// The session of a previous acquisition expired, the owner starts over
+		s.forget(req.Key, req.Owner)
+		acquired, err = s.acquire(ctx, req)
+	}
+	if err != nil {
+		return arbiter.AcquireResult{}, err
+	}
+	if !acquired {
+		// Waiters retry with a new session instead of keeping one per attempt around
+		s.destroy(ctx, req.Key, req.Owner)
+	}
+
+	pair, _, err := s.client.KV().Get(s.kvKey(req.Key), s.query(ctx))
+	if err != nil {
+		return arbiter.AcquireResult{}, err
+	}
+	if pair == nil {
+		return arbiter.AcquireResult{}, nil
+	}
+	if acquired {
+		return arbiter.AcquireResult{Acquired: true, Fence: int64(pair.LockIndex)}, nil
+	}
+
+	var res arbiter.AcquireResult
+	if req.ReadHolder && pair.Session != "" {
+		res.Holder = arbiter.LockInfo{Held: true, Owner: string(pair.Value), Fence: int64(pair.LockIndex)}
+	}
+	return res, nil
+}
+
+// errSessionGone is returned by acquire when the cached session of the owner expired
+var errSessionGone = errors.New("consul session expired")
+
+// acquire takes the lock for the session of the owner, creating it on first use
+func (s *Store) acquire(ctx context.Context, req arbiter.LeaseRequest) (bool, error) {
+	session, err := s.session(ctx, req)
+	if err != nil {
+		return false, err
+	}
+
+	pair := &api.KVPair{Key: s.kvKey(req.Key), Value: []byte(req.Owner), Session: session}
+	acquired, _, err := s.client.KV().Acquire(pair, s.write(ctx))
+	if err != nil && strings.Contains(err.Error(), "invalid session") {
+		return false, errSessionGone
+	}
+	return acquired, err
+}
+
+func (s *Store) Release(ctx context.Context, key, owner string) (bool, error) {
+	session, ok := s.cached(key, owner)
+	if !ok {
+		return false, nil
+	}
+
+	pair := &api.KVPair{Key: s.kvKey(key), Session: session}
+	released, _, err := s.client.KV().Release(pair, s.write(ctx))
+	if err != nil {
+		return false, err
+	}
+	return released, s.destroy(ctx, key, owner)
+}
+
+// Extend renews the session of the owner. Consul keeps the TTL the session was created
+// with, so the lease is renewed to its original length rather than set to req.Lease.
+func (s *Store) Extend(ctx context.Context, req arbiter.LeaseRequest) (bool, error) {
+	session, ok := s.cached(req.Key, req.Owner)
+	if !ok {
+		return false, nil
+	}
+
+	entry, _, err := s.client.Session().Renew(session, s.write(ctx))
+	if err != nil {
+		return false, err
+	}
+	if entry == nil {
+		s.forget(req.Key, req.Owner)
+		return false, nil
+	}
+
+	pair, _, err := s.client.KV().Get(s.kvKey(req.Key), s.query(ctx))
+	if err != nil {
+		return false, err
+	}
+	return pair != nil && pair.Session == session, nil
+}
+
+// Watch calls fn with the key of every lock whose session changed, found by blocking
+// queries on the KV prefix, until the returned function is called
+func (s *Store) Watch(ctx context.Context, fn func(key string)) (func(), error) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	// The first query only records the current holders
+	pairs, meta, err := s.client.KV().List(s.prefix, s.query(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	holders := sessionsOf(pairs)
+
+	go func() {
+		index := meta.LastIndex
+		for ctx.Err() == nil {
+			opts := s.query(ctx)
+			opts.WaitIndex = index
+			pairs, meta, err := s.client.KV().List(s.prefix, opts)
+			if err != nil {
+				select {
+				case <-ctx.Done():
+				case <-time.After(watchRetry):
+				}
+				continue
+			}
+
+			index = meta.LastIndex
+			current := sessionsOf(pairs)
+			for key, session := range current {
+				if holders[key] != session {
+					fn(strings.TrimPrefix(key, s.prefix))
+				}
+			}
+			for key := range holders {
+				if _, ok := current[key]; !ok {
+					fn(strings.TrimPrefix(key, s.prefix))
+				}
+			}
+			holders = current
+		}
+	}()
+	return cancel, nil
+}
+
+// session returns the session of the owner of req, creating it on first use
+func (s *Store) session(ctx context.Context, req arbiter.LeaseRequest) (string, error) {
+	if session, ok := s.cached(req.Key, req.Owner); ok {
+		return session, nil
+	}
+
+	// A lock without expiry lives as long as its heartbeat is renewed
+	ttl := req.Lease
+	if ttl <= 0 {
+		ttl = req.Heartbeat
+	}
+	ttl = min(max(ttl, minSessionTTL), maxSessionTTL)
+
+	entry := &api.SessionEntry{
+		Name:      "arbiter: " + req.Name,
+		TTL:       ttl.String(),
+		Behavior:  api.SessionBehaviorRelease,
+		LockDelay: s.lockDelay,
+	}
+	session, _, err := s.client.Session().Create(entry, s.write(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.sessions[req.Key+"\x00"+req.Owner] = session
+	s.mu.Unlock()
+	return session, nil
+}
+
+func (s *Store) cached(key, owner string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[key+"\x00"+owner]
+	return session, ok
+}
+
+// destroy ends the session of owner on key, releasing the lock if it still held it
+func (s *Store) destroy(ctx context.Context, key, owner string) error {
+	session, ok := s.cached(key, owner)
+	if !ok {
+		return nil
+	}
+	s.forget(key, owner)
+
+	_, err := s.client.Session().Destroy(session, s.write(ctx))
+	return err
+}
+
+func (s *Store) forget(key, owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, key+"\x00"+owner)
+}
+
+func (s *Store) kvKey(key string) string {
+	return s.prefix + key
+}
+
+func (s *Store) query(ctx context.Context) *api.QueryOptions {
+	return (&api.QueryOptions{}).WithContext(ctx)
+}
+
+func (s *Store) write(ctx context.Context) *api.WriteOptions {
+	return (&api.WriteOptions{}).WithContext(ctx)
+}
+
+// sessionsOf returns the session holding each key of pairs that is locked
+func sessionsOf(pairs api.KVPairs) map[string]string {
+	sessions := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if pair.Session != "" {
+			sessions[pair.Key] = pair.Session
+		}
+	}
+	return sessions
+}
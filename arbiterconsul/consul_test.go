@@ -0,0 +1,78 @@
+package arbiterconsul
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupConsul(t *testing.T) *api.Client {
+	client, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create Consul client: %v", err)
+	}
+	if _, err := client.Status().Leader(); err != nil {
+		t.Skipf("Consul is not available: %v", err)
+	}
+	return client
+}
+
+func TestStore(t *testing.T) {
+	consul := setupConsul(t)
+	client := NewClient(consul, WithKVPrefix("test-arbiter/"), WithLockDelay(time.Millisecond))
+	ctx := context.Background()
+
+	holder := client.NewLock("test-lock")
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	fence := holder.Fence()
+
+	acquired, info, err := client.NewLock("test-lock").TryLockInfo(ctx)
+	if err != nil || acquired {
+		t.Fatalf("Expected lock to be held, got: %v, %v", acquired, err)
+	}
+	if !info.Held || info.Fence != fence {
+		t.Errorf("Expected holder with fence %d, got: %+v", fence, info)
+	}
+
+	if err := holder.Refresh(ctx); err != nil {
+		t.Errorf("Failed to refresh lock: %v", err)
+	}
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	if err := holder.Unlock(ctx); !errors.Is(err, arbiter.ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld, got: %v", err)
+	}
+
+	next := client.NewLock("test-lock")
+	if err := next.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire released lock: %v", err)
+	}
+	defer next.Unlock(ctx)
+	if next.Fence() <= fence {
+		t.Errorf("Expected fence above %d, got: %d", fence, next.Fence())
+	}
+}
+
+func TestDriver(t *testing.T) {
+	setupConsul(t)
+
+	locker, err := arbiter.Open("consul://127.0.0.1:8500?kv=test-arbiter/")
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer locker.Close()
+
+	lock := locker.NewLock("test-driver")
+	if acquired, err := lock.TryLock(context.Background()); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	lock.Unlock(context.Background())
+}
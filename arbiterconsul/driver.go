@@ -0,0 +1,43 @@
+package arbiterconsul
+
+import (
+	"net/url"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/huimingz/arbiter"
+)
+
+func init() {
+	arbiter.Register("consul", driver{})
+}
+
+// driver opens clients from consul://host:port URLs, with optional "kv" and "token"
+// query parameters for the KV prefix and the ACL token
+type driver struct{}
+
+func (driver) Open(dsn string) (arbiter.Locker, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	config := api.DefaultConfig()
+	if u.Host != "" {
+		config.Address = u.Host
+	}
+	query := u.Query()
+	if query.Has("token") {
+		config.Token = query.Get("token")
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []Option
+	if query.Has("kv") {
+		opts = append(opts, WithKVPrefix(query.Get("kv")))
+	}
+	return NewClient(client, opts...), nil
+}
@@ -0,0 +1,60 @@
+// Package arbitercron runs robfig/cron jobs under arbiter locks, so that a schedule
+// shared by a fleet of processes executes each run only once.
+package arbitercron
+
+import (
+	"context"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/huimingz/arbiter"
+)
+
+// SkipIfLocked returns a JobWrapper that runs the job only if it acquires the named
+// lock, and skips the run if another process holds it. The lock is kept by the
+// watchdog while the job runs unless opts say otherwise.
+func SkipIfLocked(client *arbiter.Client, name string, logger cron.Logger, opts ...arbiter.Option) cron.JobWrapper {
+	return wrap(client, name, logger, false, opts)
+}
+
+// WaitForLock returns a JobWrapper that waits for the named lock before running the
+// job, bounded by the wait timeout of opts. Runs are serialized across the fleet
+// instead of being skipped.
+func WaitForLock(client *arbiter.Client, name string, logger cron.Logger, opts ...arbiter.Option) cron.JobWrapper {
+	return wrap(client, name, logger, true, opts)
+}
+
+func wrap(client *arbiter.Client, name string, logger cron.Logger, wait bool, opts []arbiter.Option) cron.JobWrapper {
+	opts = append([]arbiter.Option{arbiter.WithWatchDog(true)}, opts...)
+
+	return func(job cron.Job) cron.Job {
+		return cron.FuncJob(func() {
+			ctx := context.Background()
+			lock := client.NewLock(name, opts...)
+
+			if wait {
+				if err := lock.Lock(ctx); err != nil {
+					logger.Error(err, "failed to acquire lock", "lock", name)
+					return
+				}
+			} else {
+				acquired, err := lock.TryLock(ctx)
+				if err != nil {
+					logger.Error(err, "failed to acquire lock", "lock", name)
+					return
+				}
+				if !acquired {
+					logger.Info("skip", "lock", name)
+					return
+				}
+			}
+			defer func() {
+				if err := lock.Unlock(ctx); err != nil {
+					logger.Error(err, "failed to release lock", "lock", name)
+				}
+			}()
+
+			job.Run()
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package arbitercron
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+
+	return client
+}
+
+func TestJobWrappers(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := arbiter.NewClient(redisClient, arbiter.WithKeyPrefix("test-cron:"))
+	logger := cron.DiscardLogger
+
+	// runConcurrently runs the wrapped job as if it were scheduled on three processes at once
+	runConcurrently := func(wrapper cron.JobWrapper, job func()) {
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cron.NewChain(wrapper).Then(cron.FuncJob(job)).Run()
+			}()
+		}
+		wg.Wait()
+	}
+
+	t.Run("skip if locked", func(t *testing.T) {
+		var runs atomic.Int32
+		runConcurrently(SkipIfLocked(client, "test-skip", logger), func() {
+			runs.Add(1)
+			time.Sleep(200 * time.Millisecond)
+		})
+
+		if n := runs.Load(); n != 1 {
+			t.Fatalf("Expected a single run, got: %d", n)
+		}
+		if locked, _ := client.IsLocked(context.Background(), "test-skip"); locked {
+			t.Fatal("Lock should be released after the run")
+		}
+	})
+
+	t.Run("wait for lock", func(t *testing.T) {
+		var runs, running atomic.Int32
+		runConcurrently(WaitForLock(client, "test-wait", logger, arbiter.WithWaitTimeout(5*time.Second)), func() {
+			if running.Add(1) > 1 {
+				t.Error("Runs should not overlap")
+			}
+			time.Sleep(50 * time.Millisecond)
+			running.Add(-1)
+			runs.Add(1)
+		})
+
+		if n := runs.Load(); n != 3 {
+			t.Fatalf("Expected every run to execute in turn, got: %d", n)
+		}
+	})
+}
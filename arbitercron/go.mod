@@ -0,0 +1,16 @@
+module github.com/huimingz/arbiter/arbitercron
+
+go 1.21
+
+require (
+	github.com/huimingz/arbiter v0.0.0
+	github.com/redis/go-redis/v9 v9.4.0
+	github.com/robfig/cron/v3 v3.0.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+)
+
+replace github.com/huimingz/arbiter => ../
@@ -0,0 +1,170 @@
+// Package arbiterdynamodb runs arbiter locks on DynamoDB conditional writes, in the way
+// of dynamodb-lock-client, for serverless deployments on AWS without Redis.
+package arbiterdynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/huimingz/arbiter"
+)
+
+// Attributes of a lock item. The table has the string partition key "key".
+const (
+	attrKey     = "key"
+	attrOwner   = "owner"
+	attrName    = "name"
+	attrFence   = "fence"
+	attrExpires = "expires"
+)
+
+// API is the part of the DynamoDB client the store uses, satisfied by *dynamodb.Client
+type API interface {
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// Store is an arbiter.Store on a DynamoDB table. Every lock is an item whose owner and
+// lease expiry are only written under a condition that the lease of the previous owner
+// lapsed, so the lease is a TTL compared against the clocks of the clients, which must
+// be kept in sync. Items are kept after release, their fence attribute serves as
+// fencing token and keeps increasing across owners.
+type Store struct {
+	client API
+	table  string
+}
+
+// NewStore returns a Store on table, whose partition key is the string attribute "key"
+func NewStore(client API, table string) *Store {
+	return &Store{client: client, table: table}
+}
+
+// NewClient returns an arbiter client whose locks run on table. Operations that need
+// Redis return arbiter.ErrStoreUnsupported.
+func NewClient(client API, table string) *arbiter.Client {
+	return arbiter.NewClient(nil, arbiter.WithStore(NewStore(client, table)))
+}
+
+func (s *Store) TryAcquire(ctx context.Context, req arbiter.LeaseRequest) (arbiter.AcquireResult, error) {
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.table),
+		Key:                 s.key(req.Key),
+		ConditionExpression: aws.String("attribute_not_exists(#owner) OR #expires <= :now"),
+		UpdateExpression:    aws.String("SET #owner = :owner, #name = :name, #expires = :expires ADD #fence :one"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner": attrOwner, "#name": attrName, "#expires": attrExpires, "#fence": attrFence,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner":   &types.AttributeValueMemberS{Value: req.Owner},
+			":name":    &types.AttributeValueMemberS{Value: req.Name},
+			":now":     number(req.Now.UnixMilli()),
+			":expires": number(req.Expires.UnixMilli()),
+			":one":     number(1),
+		},
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err == nil {
+		return arbiter.AcquireResult{Acquired: true, Fence: numberOf(out.Attributes[attrFence])}, nil
+	}
+
+	var failed *types.ConditionalCheckFailedException
+	if !errors.As(err, &failed) {
+		return arbiter.AcquireResult{}, err
+	}
+	holder := arbiter.LockInfo{
+		Held:  true,
+		Name:  stringOf(failed.Item[attrName]),
+		Owner: stringOf(failed.Item[attrOwner]),
+		Fence: numberOf(failed.Item[attrFence]),
+		TTL:   time.UnixMilli(numberOf(failed.Item[attrExpires])).Sub(req.Now),
+	}
+
+	// The owner re-enters the lock it holds and keeps its fencing token
+	if holder.Owner == req.Owner {
+		extended, err := s.Extend(ctx, req)
+		if err != nil || !extended {
+			return arbiter.AcquireResult{}, err
+		}
+		return arbiter.AcquireResult{Acquired: true, Fence: holder.Fence}, nil
+	}
+
+	var res arbiter.AcquireResult
+	if req.ReadHolder {
+		res.Holder = holder
+	}
+	return res, nil
+}
+
+func (s *Store) Release(ctx context.Context, key, owner string) (bool, error) {
+	return s.conditional(s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      s.key(key),
+		ConditionExpression:      aws.String("#owner = :owner AND #expires > :now"),
+		UpdateExpression:         aws.String("REMOVE #owner, #expires"),
+		ExpressionAttributeNames: map[string]string{"#owner": attrOwner, "#expires": attrExpires},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: owner},
+			":now":   number(time.Now().UnixMilli()),
+		},
+	}))
+}
+
+func (s *Store) Extend(ctx context.Context, req arbiter.LeaseRequest) (bool, error) {
+	return s.conditional(s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      s.key(req.Key),
+		ConditionExpression:      aws.String("#owner = :owner AND #expires > :now"),
+		UpdateExpression:         aws.String("SET #expires = :expires"),
+		ExpressionAttributeNames: map[string]string{"#owner": attrOwner, "#expires": attrExpires},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner":   &types.AttributeValueMemberS{Value: req.Owner},
+			":now":     number(req.Now.UnixMilli()),
+			":expires": number(req.Expires.UnixMilli()),
+		},
+	}))
+}
+
+// Watch is not supported, DynamoDB has no notifications short of streams
+func (s *Store) Watch(ctx context.Context, fn func(key string)) (func(), error) {
+	return nil, arbiter.ErrStoreUnsupported
+}
+
+// conditional reports whether a conditional update was applied
+func (s *Store) conditional(_ *dynamodb.UpdateItemOutput, err error) (bool, error) {
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Store) key(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{attrKey: &types.AttributeValueMemberS{Value: key}}
+}
+
+func number(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func numberOf(v types.AttributeValue) int64 {
+	n, ok := v.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+	i, _ := strconv.ParseInt(n.Value, 10, 64)
+	return i
+}
+
+func stringOf(v types.AttributeValue) string {
+	s, ok := v.(*types.AttributeValueMemberS)
+	if !ok {
+		return ""
+	}
+	return s.Value
+}
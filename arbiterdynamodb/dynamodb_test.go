@@ -0,0 +1,90 @@
+package arbiterdynamodb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/huimingz/arbiter"
+)
+
+// setupDynamoDB creates a lock table on DynamoDB Local
+func setupDynamoDB(t *testing.T) (*dynamodb.Client, string) {
+	endpoint := os.Getenv("ARBITER_DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:8000"
+	}
+	client := dynamodb.New(dynamodb.Options{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("test", "test", ""),
+		BaseEndpoint: aws.String(endpoint),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	table := "arbiter-test-locks"
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String(attrKey), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String(attrKey), KeyType: types.KeyTypeHash}},
+		BillingMode:          types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		t.Skipf("DynamoDB is not available: %v", err)
+	}
+	t.Cleanup(func() {
+		client.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+	return client, table
+}
+
+func TestStore(t *testing.T) {
+	db, table := setupDynamoDB(t)
+	ctx := context.Background()
+	client := NewClient(db, table)
+	other := NewClient(db, table)
+
+	lock := client.NewLock("test-lock", arbiter.WithLeaseTime(time.Second))
+	if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	fence := lock.Fence()
+	if fence == 0 {
+		t.Error("Expected a fencing token")
+	}
+
+	if acquired, err := other.NewLock("test-lock").TryLock(ctx); err != nil || acquired {
+		t.Errorf("Expected lock to be held, got: %v, %v", acquired, err)
+	}
+	if err := lock.Refresh(ctx); err != nil {
+		t.Errorf("Failed to refresh lock: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+
+	next := other.NewLock("test-lock", arbiter.WithLeaseTime(100*time.Millisecond))
+	if acquired, err := next.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire released lock, got: %v, %v", acquired, err)
+	}
+	if next.Fence() <= fence {
+		t.Errorf("Expected fencing token above %d, got: %d", fence, next.Fence())
+	}
+
+	// A lapsed lease lets the next owner in
+	time.Sleep(200 * time.Millisecond)
+	if acquired, err := client.NewLock("test-lock").TryLock(ctx); err != nil || !acquired {
+		t.Errorf("Expected to acquire expired lock, got: %v, %v", acquired, err)
+	}
+
+	if _, err := client.IsLocked(ctx, "test-lock"); !errors.Is(err, arbiter.ErrStoreUnsupported) {
+		t.Errorf("Expected ErrStoreUnsupported, got: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/huimingz/arbiter/arbitergrpc
+
+go 1.21
+
+require (
+	github.com/huimingz/arbiter v0.0.0
+	github.com/redis/go-redis/v9 v9.4.0
+	google.golang.org/grpc v1.60.1
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.16.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20231002182017-d307bd883b97 // indirect
+)
+
+replace github.com/huimingz/arbiter => ../
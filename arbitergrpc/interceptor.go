@@ -0,0 +1,165 @@
+// Package arbitergrpc provides gRPC server interceptors that run handlers in per-key
+// critical sections guarded by arbiter locks, for services that must serialize
+// mutations per entity across instances.
+package arbitergrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/huimingz/arbiter"
+)
+
+// KeyFunc returns the name of the lock a call runs under, or "" to run it without one
+type KeyFunc func(ctx context.Context, fullMethod string) string
+
+// FromMetadata returns a KeyFunc naming the lock after the first value of the metadata
+// header, e.g. "x-entity-id". Calls without the header run without a lock.
+func FromMetadata(header string) KeyFunc {
+	return func(ctx context.Context, _ string) string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(header); len(values) > 0 && values[0] != "" {
+			return "grpc:" + header + ":" + values[0]
+		}
+		return ""
+	}
+}
+
+// Option is a function type for configuring the interceptors
+type Option func(*config)
+
+type config struct {
+	maxWait  time.Duration
+	budget   float64
+	lockOpts []arbiter.Option
+}
+
+// WithMaxWait lets calls wait up to d for a busy key. Without it busy calls fail at once.
+func WithMaxWait(d time.Duration) Option {
+	return func(c *config) {
+		c.maxWait = d
+	}
+}
+
+// WithWaitBudget sets the fraction of the time left until the call deadline that may be
+// spent waiting for the lock, leaving the rest to the handler. The default is 0.5.
+func WithWaitBudget(fraction float64) Option {
+	return func(c *config) {
+		c.budget = fraction
+	}
+}
+
+// WithLockOptions sets the options of the locks calls run under
+func WithLockOptions(opts ...arbiter.Option) Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, opts...)
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor running unary handlers under the lock
+// named by key. Calls whose key stays busy fail with codes.Aborted. The lock is kept
+// by the watchdog while the handler runs and the handler context is cancelled with
+// cause arbiter.ErrLockLost if the lock is lost.
+func UnaryServerInterceptor(client *arbiter.Client, key KeyFunc, opts ...Option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		name := key(ctx, info.FullMethod)
+		if name == "" {
+			return handler(ctx, req)
+		}
+
+		lock, err := cfg.acquire(ctx, client, name)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Unlock(context.WithoutCancel(ctx))
+
+		_, ctx = lock.Group(ctx)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor,
+// holding the lock for the lifetime of the stream
+func StreamServerInterceptor(client *arbiter.Client, key KeyFunc, opts ...Option) grpc.StreamServerInterceptor {
+	cfg := newConfig(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		name := key(ctx, info.FullMethod)
+		if name == "" {
+			return handler(srv, ss)
+		}
+
+		lock, err := cfg.acquire(ctx, client, name)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock(context.WithoutCancel(ctx))
+
+		_, ctx = lock.Group(ctx)
+		return handler(srv, &lockedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// lockedStream replaces the context of a stream with the one tied to its lock
+type lockedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *lockedStream) Context() context.Context {
+	return s.ctx
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{budget: 0.5}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// wait returns how long a call may wait for its lock, bounded by the wait budget of
+// its deadline
+func (c *config) wait(ctx context.Context) time.Duration {
+	wait := c.maxWait
+	if deadline, ok := ctx.Deadline(); ok && wait > 0 {
+		wait = min(wait, time.Duration(float64(time.Until(deadline))*c.budget))
+	}
+	return wait
+}
+
+// acquire takes the named lock for a call and translates failures into status errors
+func (c *config) acquire(ctx context.Context, client *arbiter.Client, name string) (arbiter.Lock, error) {
+	opts := append([]arbiter.Option{arbiter.WithWatchDog(true)}, c.lockOpts...)
+
+	wait := c.wait(ctx)
+	if wait <= 0 {
+		lock := client.NewLock(name, opts...)
+		acquired, err := lock.TryLock(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "acquire lock %s: %v", name, err)
+		}
+		if !acquired {
+			return nil, status.Errorf(codes.Aborted, "lock %s is held by another call", name)
+		}
+		return lock, nil
+	}
+
+	lock := client.NewLock(name, append(opts, arbiter.WithWaitTimeout(wait))...)
+	switch err := lock.Lock(ctx); {
+	case err == nil:
+		return lock, nil
+	case err == arbiter.ErrLockTimeout:
+		return nil, status.Errorf(codes.Aborted, "lock %s is held by another call", name)
+	case ctx.Err() != nil:
+		return nil, status.FromContextError(ctx.Err()).Err()
+	default:
+		return nil, status.Errorf(codes.Unavailable, "acquire lock %s: %v", name, err)
+	}
+}
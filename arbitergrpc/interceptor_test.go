@@ -0,0 +1,149 @@
+package arbitergrpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+
+	return client
+}
+
+// fakeStream is a server stream that only carries a context
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptors(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := arbiter.NewClient(redisClient, arbiter.WithKeyPrefix("test-grpc:"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Update"}
+	key := FromMetadata("x-order-id")
+
+	withOrder := func(id string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-order-id", id))
+	}
+	hold := func(t *testing.T, id string) (release func()) {
+		lock := client.NewLock("grpc:x-order-id:" + id)
+		if err := lock.Lock(context.Background()); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		return func() { lock.Unlock(context.Background()) }
+	}
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "done", nil }
+
+	t.Run("busy key aborts the call", func(t *testing.T) {
+		release := hold(t, "1")
+		defer release()
+
+		_, err := UnaryServerInterceptor(client, key)(withOrder("1"), nil, info, ok)
+		if status.Code(err) != codes.Aborted {
+			t.Fatalf("Expected aborted call, got: %v", err)
+		}
+
+		resp, err := UnaryServerInterceptor(client, key)(withOrder("2"), nil, info, ok)
+		if err != nil || resp != "done" {
+			t.Fatalf("Call on another key should run, got: %v, %v", resp, err)
+		}
+	})
+
+	t.Run("calls wait in turn", func(t *testing.T) {
+		interceptor := UnaryServerInterceptor(client, key, WithMaxWait(5*time.Second))
+		var mu sync.Mutex
+		running := 0
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			mu.Lock()
+			running++
+			if running > 1 {
+				t.Error("Calls should not overlap")
+			}
+			mu.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := interceptor(withOrder("3"), nil, info, handler); err != nil {
+					t.Errorf("Call should be served, got: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("wait is bounded by the deadline budget", func(t *testing.T) {
+		release := hold(t, "4")
+		defer release()
+
+		ctx, cancel := context.WithTimeout(withOrder("4"), time.Second)
+		defer cancel()
+		start := time.Now()
+		_, err := UnaryServerInterceptor(client, key, WithMaxWait(time.Minute))(ctx, nil, info, ok)
+		if status.Code(err) != codes.Aborted {
+			t.Fatalf("Expected aborted call, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+			t.Fatalf("Wait should leave half of the deadline to the handler, waited: %v", elapsed)
+		}
+	})
+
+	t.Run("calls without key skip the lock", func(t *testing.T) {
+		if _, err := UnaryServerInterceptor(client, key)(context.Background(), nil, info, ok); err != nil {
+			t.Fatalf("Call without key should run, got: %v", err)
+		}
+	})
+
+	t.Run("stream holds the lock", func(t *testing.T) {
+		interceptor := StreamServerInterceptor(client, key)
+		streamInfo := &grpc.StreamServerInfo{FullMethod: "/orders.Orders/Watch"}
+
+		err := interceptor(nil, &fakeStream{ctx: withOrder("5")}, streamInfo, func(srv interface{}, ss grpc.ServerStream) error {
+			if locked, _ := client.IsLocked(ss.Context(), "grpc:x-order-id:5"); !locked {
+				t.Error("Lock should be held while the stream runs")
+			}
+			return interceptor(nil, &fakeStream{ctx: withOrder("5")}, streamInfo, func(interface{}, grpc.ServerStream) error {
+				t.Error("Concurrent stream should be rejected")
+				return nil
+			})
+		})
+		if status.Code(err) != codes.Aborted {
+			t.Fatalf("Expected aborted stream, got: %v", err)
+		}
+		if locked, _ := client.IsLocked(context.Background(), "grpc:x-order-id:5"); locked {
+			t.Fatal("Lock should be released with the stream")
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package arbitergrpc
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/huimingz/arbiter"
+)
+
+// WatchServiceName is the full name of the lock watch service
+const WatchServiceName = "arbiter.v1.LockWatch"
+
+// RegisterWatchService registers the lock watch service on s, streaming the state
+// transitions of the locks of client to clients in any language. Its messages are the
+// well-known google.protobuf.Struct, so clients need no generated code of arbiter:
+//
+//	service LockWatch {
+//	  rpc Watch(google.protobuf.Struct) returns (stream google.protobuf.Struct);
+//	}
+//
+// The request names the locks as {"names": ["leader"]}. The stream sends the current
+// state of every lock first and then each transition, as {"name": "leader",
+// "held": true, "owner": "...", "time": "<RFC 3339>"}, until the call is cancelled.
+func RegisterWatchService(s grpc.ServiceRegistrar, client *arbiter.Client) {
+	s.RegisterService(&watchServiceDesc, &watchServer{client: client})
+}
+
+// watchService is the handler type of the lock watch service
+type watchService interface {
+	watch(req *structpb.Struct, stream grpc.ServerStream) error
+}
+
+var watchServiceDesc = grpc.ServiceDesc{
+	ServiceName: WatchServiceName,
+	HandlerType: (*watchService)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Watch",
+		Handler:       watchHandler,
+		ServerStreams: true,
+	}},
+}
+
+func watchHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(structpb.Struct)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(watchService).watch(req, stream)
+}
+
+type watchServer struct {
+	client *arbiter.Client
+}
+
+func (s *watchServer) watch(req *structpb.Struct, stream grpc.ServerStream) error {
+	var names []string
+	for _, value := range req.GetFields()["names"].GetListValue().GetValues() {
+		if name := value.GetStringValue(); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return status.Error(codes.InvalidArgument, "no lock names to watch")
+	}
+
+	ctx := stream.Context()
+	changes, err := s.client.Watch(ctx, names...)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "watch locks: %v", err)
+	}
+	for change := range changes {
+		msg, err := structpb.NewStruct(map[string]interface{}{
+			"name":  change.Name,
+			"held":  change.Held,
+			"owner": change.Owner,
+			"time":  change.Time.Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := stream.SendMsg(msg); err != nil {
+			return err
+		}
+	}
+	return status.FromContextError(ctx.Err()).Err()
+}
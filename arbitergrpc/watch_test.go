@@ -0,0 +1,134 @@
+package arbitergrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/huimingz/arbiter"
+)
+
+// stateExecutor replies to HGETALL with the hash of a lock and publishes changes to
+// the subscriber, without Redis
+type stateExecutor struct {
+	mu         sync.Mutex
+	owner      string
+	subscriber func(payload string)
+}
+
+func (e *stateExecutor) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return nil, errors.New("unexpected script")
+}
+
+func (e *stateExecutor) EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) (interface{}, error) {
+	return nil, errors.New("unexpected script")
+}
+
+func (e *stateExecutor) ScriptLoad(ctx context.Context, script string) (string, error) {
+	return "", errors.New("unexpected script")
+}
+
+func (e *stateExecutor) Publish(ctx context.Context, channel, message string) error {
+	return nil
+}
+
+func (e *stateExecutor) Subscribe(ctx context.Context, channel string, fn func(payload string)) (func(), error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.subscriber = fn
+	return func() {}, nil
+}
+
+func (e *stateExecutor) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fields := map[interface{}]interface{}{}
+	if e.owner != "" {
+		fields["owner"] = e.owner
+	}
+	return fields, nil
+}
+
+func (e *stateExecutor) DoMulti(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error) {
+	return nil, errors.New("unexpected pipeline")
+}
+
+func (e *stateExecutor) Scan(ctx context.Context, match string, fn func(key string) error) error {
+	return nil
+}
+
+// acquire records owner as holder and publishes the change of key
+func (e *stateExecutor) acquire(key, owner string) {
+	e.mu.Lock()
+	e.owner = owner
+	subscriber := e.subscriber
+	e.mu.Unlock()
+	subscriber(key)
+}
+
+func TestWatchService(t *testing.T) {
+	executor := &stateExecutor{}
+	client := arbiter.NewClient(nil, arbiter.WithKeyPrefix("test-grpc:"), arbiter.WithExecutor(executor))
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterWatchService(server, client)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := func(names ...interface{}) grpc.ClientStream {
+		t.Helper()
+		stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/"+WatchServiceName+"/Watch")
+		if err != nil {
+			t.Fatalf("Failed to open stream: %v", err)
+		}
+		req, _ := structpb.NewStruct(map[string]interface{}{"names": names})
+		if err := stream.SendMsg(req); err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		stream.CloseSend()
+		return stream
+	}
+	next := func(stream grpc.ClientStream) map[string]interface{} {
+		t.Helper()
+		msg := new(structpb.Struct)
+		if err := stream.RecvMsg(msg); err != nil {
+			t.Fatalf("Failed to receive state: %v", err)
+		}
+		return msg.AsMap()
+	}
+
+	stream := watch("leader")
+	if state := next(stream); state["name"] != "leader" || state["held"] != false {
+		t.Fatalf("Unexpected initial state: %v", state)
+	}
+	executor.acquire("test-grpc:leader", "node-1")
+	if state := next(stream); state["held"] != true || state["owner"] != "node-1" {
+		t.Errorf("Expected acquisition, got: %v", state)
+	}
+
+	err = watch().RecvMsg(new(structpb.Struct))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without names, got: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package arbiterhttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/huimingz/arbiter"
+)
+
+// SetRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers of a limiter decision, and Retry-After if the request was not admitted.
+// Durations are rounded up to whole seconds.
+func SetRateLimitHeaders(h http.Header, res arbiter.RateLimitResult) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", seconds(res.ResetAfter))
+	if !res.Allowed {
+		h.Set("Retry-After", seconds(res.RetryAfter))
+	}
+}
+
+// seconds formats d in whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
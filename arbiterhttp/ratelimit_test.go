@@ -0,0 +1,37 @@
+package arbiterhttp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/huimingz/arbiter"
+)
+
+func TestSetRateLimitHeaders(t *testing.T) {
+	h := http.Header{}
+	SetRateLimitHeaders(h, arbiter.RateLimitResult{
+		Limit:      10,
+		Remaining:  0,
+		RetryAfter: 1500 * time.Millisecond,
+		ResetAfter: 9 * time.Second,
+	})
+
+	expected := map[string]string{
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "9",
+		"Retry-After":         "2",
+	}
+	for name, value := range expected {
+		if got := h.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	h = http.Header{}
+	SetRateLimitHeaders(h, arbiter.RateLimitResult{Allowed: true, Limit: 10, Remaining: 9})
+	if h.Get("Retry-After") != "" {
+		t.Error("Admitted requests should not get Retry-After")
+	}
+}
@@ -0,0 +1,125 @@
+// Package arbiterhttp provides net/http middleware built on arbiter locks.
+package arbiterhttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/huimingz/arbiter"
+)
+
+// KeyFunc returns the name of the lock a request runs under, or "" to run it without one
+type KeyFunc func(r *http.Request) string
+
+// Option is a function type for configuring Serialize
+type Option func(*config)
+
+type config struct {
+	wait     time.Duration
+	lockOpts []arbiter.Option
+	shed     http.Handler
+	failed   http.Handler
+}
+
+// WithWait makes requests wait up to timeout for a busy key before they are shed
+func WithWait(timeout time.Duration) Option {
+	return func(c *config) {
+		c.wait = timeout
+	}
+}
+
+// WithLockOptions sets the options of the locks requests run under
+func WithLockOptions(opts ...arbiter.Option) Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, opts...)
+	}
+}
+
+// WithShedHandler sets the handler responding to shed requests. By default they are
+// answered with 429 Too Many Requests and a Retry-After header.
+func WithShedHandler(h http.Handler) Option {
+	return func(c *config) {
+		c.shed = h
+	}
+}
+
+// WithErrorHandler sets the handler responding when the lock cannot be acquired because
+// of an error, such as an unreachable Redis. By default it answers 503 Service Unavailable.
+func WithErrorHandler(h http.Handler) Option {
+	return func(c *config) {
+		c.failed = h
+	}
+}
+
+// Serialize returns middleware that runs requests with the same key one at a time
+// across every instance sharing the Redis of client, e.g. keyed by order ID. Requests
+// finding their key busy are shed right away unless WithWait lets them wait. The lock
+// is kept by the watchdog while the handler runs and the request context is cancelled
+// with cause arbiter.ErrLockLost if the lock is lost.
+func Serialize(client *arbiter.Client, keyFn KeyFunc, opts ...Option) func(http.Handler) http.Handler {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	lockOpts := append([]arbiter.Option{arbiter.WithWatchDog(true)}, cfg.lockOpts...)
+	if cfg.wait > 0 {
+		lockOpts = append(lockOpts, arbiter.WithWaitTimeout(cfg.wait))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFn(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			lock := client.NewLock(key, lockOpts...)
+			acquired, err := acquire(ctx, lock, cfg.wait > 0)
+			if err != nil {
+				if cfg.failed != nil {
+					cfg.failed.ServeHTTP(w, r)
+					return
+				}
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			if !acquired {
+				if cfg.shed != nil {
+					cfg.shed.ServeHTTP(w, r)
+					return
+				}
+				shed(client, key, w, r)
+				return
+			}
+			defer lock.Unlock(context.WithoutCancel(ctx))
+
+			_, ctx = lock.Group(ctx)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// acquire takes the lock, waiting for it if wait is set. A timed out wait is not an error.
+func acquire(ctx context.Context, lock arbiter.Lock, wait bool) (bool, error) {
+	if !wait {
+		return lock.TryLock(ctx)
+	}
+
+	err := lock.Lock(ctx)
+	if err == arbiter.ErrLockTimeout {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// shed answers 429 Too Many Requests with a Retry-After hint in whole seconds
+func shed(client *arbiter.Client, key string, w http.ResponseWriter, r *http.Request) {
+	if delay, err := client.RetryAfter(r.Context(), key); err == nil && delay > 0 {
+		w.Header().Set("Retry-After", seconds(delay))
+	}
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
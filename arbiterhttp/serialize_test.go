@@ -0,0 +1,145 @@
+package arbiterhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+
+	return client
+}
+
+func TestSerialize(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := arbiter.NewClient(redisClient, arbiter.WithKeyPrefix("test-http:"))
+	byOrder := func(r *http.Request) string { return r.URL.Query().Get("order") }
+
+	// serveConcurrently sends three requests at once and returns their status codes
+	serveConcurrently := func(handler http.Handler, target string) []int {
+		codes := make([]int, 3)
+		var wg sync.WaitGroup
+		for i := range codes {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+				codes[i] = rec.Code
+			}(i)
+		}
+		wg.Wait()
+		return codes
+	}
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	t.Run("busy key is shed", func(t *testing.T) {
+		codes := serveConcurrently(Serialize(client, byOrder)(slow), "/pay?order=1")
+
+		ok, shed := 0, 0
+		for _, code := range codes {
+			switch code {
+			case http.StatusOK:
+				ok++
+			case http.StatusTooManyRequests:
+				shed++
+			}
+		}
+		if ok != 1 || shed != 2 {
+			t.Fatalf("Expected one served and two shed requests, got: %v", codes)
+		}
+	})
+
+	t.Run("shed response carries retry hint", func(t *testing.T) {
+		lock := client.NewLock("2")
+		if err := lock.Lock(context.Background()); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer lock.Unlock(context.Background())
+
+		rec := httptest.NewRecorder()
+		Serialize(client, byOrder)(slow).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pay?order=2", nil))
+		if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+			t.Fatalf("Unexpected shed response: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("waiting serializes requests", func(t *testing.T) {
+		var running atomic.Int32
+		handler := Serialize(client, byOrder, WithWait(5*time.Second))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if running.Add(1) > 1 {
+				t.Error("Requests should not overlap")
+			}
+			time.Sleep(50 * time.Millisecond)
+			running.Add(-1)
+		}))
+
+		for _, code := range serveConcurrently(handler, "/pay?order=3") {
+			if code != http.StatusOK {
+				t.Fatalf("Every request should be served in turn, got: %d", code)
+			}
+		}
+	})
+
+	t.Run("distinct keys run in parallel", func(t *testing.T) {
+		handler := Serialize(client, byOrder)(slow)
+		var wg sync.WaitGroup
+		for _, order := range []string{"4", "5"} {
+			wg.Add(1)
+			go func(order string) {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pay?order="+order, nil))
+				if rec.Code != http.StatusOK {
+					t.Errorf("Request for order %s should be served, got: %d", order, rec.Code)
+				}
+			}(order)
+		}
+		wg.Wait()
+	})
+
+	t.Run("empty key skips the lock", func(t *testing.T) {
+		for _, code := range serveConcurrently(Serialize(client, byOrder)(slow), "/pay") {
+			if code != http.StatusOK {
+				t.Fatalf("Unkeyed requests should be served, got: %d", code)
+			}
+		}
+	})
+
+	t.Run("custom shed handler", func(t *testing.T) {
+		lock := client.NewLock("6")
+		lock.Lock(context.Background())
+		defer lock.Unlock(context.Background())
+
+		conflict := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		})
+		rec := httptest.NewRecorder()
+		Serialize(client, byOrder, WithShedHandler(conflict))(slow).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pay?order=6", nil))
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("Expected custom shed response, got: %d", rec.Code)
+		}
+	})
+}
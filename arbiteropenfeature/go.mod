@@ -0,0 +1,18 @@
+module github.com/huimingz/arbiter/arbiteropenfeature
+
+go 1.21
+
+require (
+	github.com/huimingz/arbiter v0.0.0
+	github.com/open-feature/go-sdk v1.10.0
+	github.com/redis/go-redis/v9 v9.4.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	golang.org/x/exp v0.0.0-20240205201215-2c58cdc269a3 // indirect
+)
+
+replace github.com/huimingz/arbiter => ../
@@ -0,0 +1,100 @@
+// Package arbiteropenfeature exposes arbiter lock states as OpenFeature boolean flags,
+// so application behaviour switches automatically while coordination locks are held,
+// e.g. a read-only mode during a schema migration.
+package arbiteropenfeature
+
+import (
+	"context"
+
+	"github.com/open-feature/go-sdk/openfeature"
+
+	"github.com/huimingz/arbiter"
+)
+
+// Variants of the boolean flags
+const (
+	VariantHeld = "held"
+	VariantFree = "free"
+)
+
+// Provider is an OpenFeature provider whose boolean flags are true while a lock is held.
+// Combine it with arbiter.WithStateCache on the client for hot evaluation paths.
+type Provider struct {
+	client *arbiter.Client
+	flags  map[string]string
+}
+
+// NewProvider creates a provider evaluating each flag key of flags to whether the lock
+// it maps to is held, e.g. {"migration-running": "migrations:orders"}
+func NewProvider(client *arbiter.Client, flags map[string]string) *Provider {
+	return &Provider{client: client, flags: flags}
+}
+
+func (p *Provider) Metadata() openfeature.Metadata {
+	return openfeature.Metadata{Name: "arbiter"}
+}
+
+func (p *Provider) Hooks() []openfeature.Hook {
+	return nil
+}
+
+func (p *Provider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, _ openfeature.FlattenedContext) openfeature.BoolResolutionDetail {
+	name, ok := p.flags[flag]
+	if !ok {
+		return openfeature.BoolResolutionDetail{
+			Value: defaultValue,
+			ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+				ResolutionError: openfeature.NewFlagNotFoundResolutionError("no lock for flag " + flag),
+				Reason:          openfeature.ErrorReason,
+			},
+		}
+	}
+
+	held, err := p.client.IsLocked(ctx, name)
+	if err != nil {
+		return openfeature.BoolResolutionDetail{
+			Value: defaultValue,
+			ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+				ResolutionError: openfeature.NewGeneralResolutionError(err.Error()),
+				Reason:          openfeature.ErrorReason,
+			},
+		}
+	}
+
+	variant := VariantFree
+	if held {
+		variant = VariantHeld
+	}
+	return openfeature.BoolResolutionDetail{
+		Value: held,
+		ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+			Reason:       openfeature.TargetingMatchReason,
+			Variant:      variant,
+			FlagMetadata: openfeature.FlagMetadata{"lock": name},
+		},
+	}
+}
+
+// Lock states are booleans, flags of other types resolve to their defaults
+func (p *Provider) StringEvaluation(_ context.Context, _ string, defaultValue string, _ openfeature.FlattenedContext) openfeature.StringResolutionDetail {
+	return openfeature.StringResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func (p *Provider) FloatEvaluation(_ context.Context, _ string, defaultValue float64, _ openfeature.FlattenedContext) openfeature.FloatResolutionDetail {
+	return openfeature.FloatResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func (p *Provider) IntEvaluation(_ context.Context, _ string, defaultValue int64, _ openfeature.FlattenedContext) openfeature.IntResolutionDetail {
+	return openfeature.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func (p *Provider) ObjectEvaluation(_ context.Context, _ string, defaultValue interface{}, _ openfeature.FlattenedContext) openfeature.InterfaceResolutionDetail {
+	return openfeature.InterfaceResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch()}
+}
+
+func typeMismatch() openfeature.ProviderResolutionDetail {
+	return openfeature.ProviderResolutionDetail{
+		ResolutionError: openfeature.NewTypeMismatchResolutionError("arbiter flags are boolean"),
+		Reason:          openfeature.ErrorReason,
+	}
+}
@@ -0,0 +1,67 @@
+package arbiteropenfeature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+
+	return client
+}
+
+func TestProvider(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := arbiter.NewClient(redisClient, arbiter.WithKeyPrefix("test-openfeature:"))
+	ctx := context.Background()
+
+	if err := openfeature.SetProviderAndWait(NewProvider(client, map[string]string{"read-only": "migrations:orders"})); err != nil {
+		t.Fatalf("Failed to set provider: %v", err)
+	}
+	flags := openfeature.NewClient("test")
+
+	if readOnly, err := flags.BooleanValue(ctx, "read-only", true, openfeature.EvaluationContext{}); err != nil || readOnly {
+		t.Fatalf("Flag should be off while the lock is free, got: %v, %v", readOnly, err)
+	}
+
+	lock := client.NewLock("migrations:orders")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	details, err := flags.BooleanValueDetails(ctx, "read-only", false, openfeature.EvaluationContext{})
+	if err != nil || !details.Value || details.Variant != VariantHeld {
+		t.Fatalf("Flag should be on while the lock is held, got: %+v, %v", details, err)
+	}
+
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	if readOnly, _ := flags.BooleanValue(ctx, "read-only", true, openfeature.EvaluationContext{}); readOnly {
+		t.Fatal("Flag should be off again once the lock is released")
+	}
+
+	details, err = flags.BooleanValueDetails(ctx, "unknown", true, openfeature.EvaluationContext{})
+	if err == nil || !details.Value || details.ErrorCode != openfeature.FlagNotFoundCode {
+		t.Fatalf("Unknown flag should resolve to its default, got: %+v, %v", details, err)
+	}
+
+	if value, err := flags.StringValue(ctx, "read-only", "default", openfeature.EvaluationContext{}); err == nil || value != "default" {
+		t.Fatalf("Non-boolean evaluation should fail with the default, got: %v, %v", value, err)
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/huimingz/arbiter/arbiterpostgres
+
+go 1.21
+
+require (
+	github.com/huimingz/arbiter v0.0.0
+	github.com/jackc/pgx/v5 v5.5.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/puddle/v2 v2.2.1 // indirect
+	github.com/redis/go-redis/v9 v9.4.0 // indirect
+	golang.org/x/crypto v0.9.0 // indirect
+	golang.org/x/sync v0.1.0 // indirect
+	golang.org/x/text v0.9.0 // indirect
+)
+
+replace github.com/huimingz/arbiter => ../
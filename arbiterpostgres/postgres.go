@@ -0,0 +1,201 @@
+// Package arbiterpostgres runs arbiter locks on PostgreSQL advisory locks, for
+// distributed locking without introducing Redis. It works with any database/sql
+// driver for PostgreSQL, such as pgx or lib/pq.
+package arbiterpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/huimingz/arbiter"
+)
+
+// defaultTable is the table fencing tokens and holders are recorded in
+const defaultTable = "arbiter_locks"
+
+// Store is an arbiter.Store on PostgreSQL advisory locks. A lock is held by a database
+// connection taken from the pool for as long as the lock is held, and PostgreSQL
+// releases it when that connection ends, so locks do not expire with a lease: a
+// holder that dies loses its lock once the server notices the connection is gone.
+// Lock keys are hashed to the 64-bit advisory lock IDs. Fencing tokens and holders
+// are recorded in a table created by Init.
+type Store struct {
+	db    *sql.DB
+	table string
+
+	mu   sync.Mutex
+	held map[string]*holding
+}
+
+// holding is a lock held on a dedicated connection
+type holding struct {
+	conn  *sql.Conn
+	fence int64
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithTable sets the table fencing tokens and holders are recorded in, "arbiter_locks" by default
+func WithTable(table string) Option {
+	return func(s *Store) {
+		s.table = table
+	}
+}
+
+// NewStore returns a Store on db
+func NewStore(db *sql.DB, opts ...Option) *Store {
+	s := &Store{db: db, table: defaultTable, held: make(map[string]*holding)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewClient returns an arbiter client whose locks run on the PostgreSQL of db. Init
+// must have created the table of the store. Operations that need Redis return
+// arbiter.ErrStoreUnsupported.
+func NewClient(db *sql.DB, opts ...Option) *arbiter.Client {
+	return arbiter.NewClient(nil, arbiter.WithStore(NewStore(db, opts...)))
+}
+
+// Init creates the table of the store unless it exists
+func (s *Store) Init(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key text PRIMARY KEY,
+		owner text NOT NULL,
+		fence bigint NOT NULL,
+		acquired_at timestamptz NOT NULL
+	)`, s.ident()))
+	return err
+}
+
+func (s *Store) TryAcquire(ctx context.Context, req arbiter.LeaseRequest) (arbiter.AcquireResult, error) {
+	// Advisory locks are re-entrant per connection, the owner keeps its acquisition
+	if h, ok := s.cached(req.Key, req.Owner); ok {
+		return arbiter.AcquireResult{Acquired: true, Fence: h.fence}, nil
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return arbiter.AcquireResult{}, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID(req.Key)).Scan(&acquired); err != nil {
+		conn.Close()
+		return arbiter.AcquireResult{}, err
+	}
+	if !acquired {
+		conn.Close()
+		var res arbiter.AcquireResult
+		if req.ReadHolder {
+			res.Holder, err = s.holder(ctx, req.Key)
+		}
+		return res, err
+	}
+
+	var fence int64
+	err = conn.QueryRowContext(ctx, fmt.Sprintf(`INSERT INTO %[1]s (key, owner, fence, acquired_at) VALUES ($1, $2, 1, now())
+		ON CONFLICT (key) DO UPDATE SET owner = $2, fence = %[1]s.fence + 1, acquired_at = now()
+		RETURNING fence`, s.ident()), req.Key, req.Owner).Scan(&fence)
+	if err != nil {
+		// Ending the connection releases the advisory lock
+		conn.Close()
+		return arbiter.AcquireResult{}, err
+	}
+
+	s.mu.Lock()
+	s.held[req.Key+"\x00"+req.Owner] = &holding{conn: conn, fence: fence}
+	s.mu.Unlock()
+	return arbiter.AcquireResult{Acquired: true, Fence: fence}, nil
+}
+
+func (s *Store) Release(ctx context.Context, key, owner string) (bool, error) {
+	h, ok := s.cached(key, owner)
+	if !ok {
+		return false, nil
+	}
+	s.forget(key, owner)
+	defer h.conn.Close()
+
+	if _, err := h.conn.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET owner = '' WHERE key = $1 AND owner = $2", s.ident()), key, owner); err != nil {
+		return false, err
+	}
+	var released bool
+	err := h.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", lockID(key)).Scan(&released)
+	return released, err
+}
+
+// Extend checks that the connection holding the lock is still alive. Advisory locks
+// have no lease, so req.Lease is not applied.
+func (s *Store) Extend(ctx context.Context, req arbiter.LeaseRequest) (bool, error) {
+	h, ok := s.cached(req.Key, req.Owner)
+	if !ok {
+		return false, nil
+	}
+
+	var held bool
+	err := h.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_locks
+		WHERE locktype = 'advisory' AND pid = pg_backend_pid() AND granted
+		AND ((classid::bigint << 32) | objid::bigint) = $1)`, lockID(req.Key)).Scan(&held)
+	if ctx.Err() != nil {
+		return false, err
+	}
+	if err != nil || !held {
+		// The connection broke and took the lock with it
+		s.forget(req.Key, req.Owner)
+		h.conn.Close()
+		return false, err
+	}
+	return true, nil
+}
+
+// Watch is not supported, database/sql cannot listen for notifications
+func (s *Store) Watch(ctx context.Context, fn func(key string)) (func(), error) {
+	return nil, arbiter.ErrStoreUnsupported
+}
+
+// holder reads the recorded holder of key, who still holds the advisory lock
+func (s *Store) holder(ctx context.Context, key string) (arbiter.LockInfo, error) {
+	var info arbiter.LockInfo
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT owner, fence FROM %s WHERE key = $1", s.ident()), key).
+		Scan(&info.Owner, &info.Fence)
+	if errors.Is(err, sql.ErrNoRows) {
+		return info, nil
+	}
+	info.Held = err == nil && info.Owner != ""
+	return info, err
+}
+
+func (s *Store) cached(key, owner string) (*holding, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.held[key+"\x00"+owner]
+	return h, ok
+}
+
+func (s *Store) forget(key, owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.held, key+"\x00"+owner)
+}
+
+// ident returns the quoted table name
+func (s *Store) ident() string {
+	return `"` + strings.ReplaceAll(s.table, `"`, `""`) + `"`
+}
+
+// lockID hashes a lock key to the ID of its advisory lock
+func lockID(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,76 @@
+package arbiterpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupPostgres(t *testing.T) *sql.DB {
+	dsn := os.Getenv("ARBITER_POSTGRES_DSN")
+	if dsn == "" {
+		dsn = "postgres://postgres@localhost:5432/postgres?sslmode=disable"
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	return db
+}
+
+func TestStore(t *testing.T) {
+	db := setupPostgres(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db, WithTable("arbiter_test_locks"))
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer db.Exec(`DROP TABLE "arbiter_test_locks"`)
+
+	client := arbiter.NewClient(nil, arbiter.WithStore(store))
+	other := arbiter.NewClient(nil, arbiter.WithStore(NewStore(db, WithTable("arbiter_test_locks"))))
+
+	lock := client.NewLock("test-lock")
+	if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	fence := lock.Fence()
+	if fence == 0 {
+		t.Error("Expected a fencing token")
+	}
+
+	if acquired, err := other.NewLock("test-lock").TryLock(ctx); err != nil || acquired {
+		t.Errorf("Expected lock to be held, got: %v, %v", acquired, err)
+	}
+	if err := lock.Refresh(ctx); err != nil {
+		t.Errorf("Failed to refresh lock: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+
+	next := other.NewLock("test-lock")
+	if acquired, err := next.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire released lock, got: %v, %v", acquired, err)
+	}
+	if next.Fence() <= fence {
+		t.Errorf("Expected fencing token above %d, got: %d", fence, next.Fence())
+	}
+	next.Unlock(ctx)
+
+	if _, err := client.IsLocked(ctx, "test-lock"); !errors.Is(err, arbiter.ErrStoreUnsupported) {
+		t.Errorf("Expected ErrStoreUnsupported, got: %v", err)
+	}
+}
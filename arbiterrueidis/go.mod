@@ -0,0 +1,17 @@
+module github.com/huimingz/arbiter/arbiterrueidis
+
+go 1.21
+
+require (
+	github.com/huimingz/arbiter v0.0.0
+	github.com/redis/go-redis/v9 v9.4.0
+	github.com/redis/rueidis v1.0.31
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
+
+replace github.com/huimingz/arbiter => ../
@@ -0,0 +1,266 @@
+// Package arbiterrueidis runs the lock operations of arbiter on a rueidis client, whose
+// auto-pipelining serves many concurrent acquisitions over few connections.
+package arbiterrueidis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+
+	"github.com/huimingz/arbiter"
+)
+
+const (
+	// resubscribeDelay is how long Subscribe waits before taking a broken subscription again
+	resubscribeDelay = time.Second
+
+	// subscribeTimeout bounds taking a broken subscription again
+	subscribeTimeout = 5 * time.Second
+)
+
+// keyless are the commands arbiter runs without a key, all others have their key as
+// first argument and are routed by it in a cluster
+var keyless = map[string]bool{"HELLO": true, "INFO": true, "CONFIG": true, "ROLE": true}
+
+// Executor runs the commands of arbiter on a rueidis client. It implements
+// arbiter.RedisExecutor and arbiter.ReplicationExecutor.
+type Executor struct {
+	client rueidis.Client
+}
+
+// New returns an Executor running on client
+func New(client rueidis.Client) *Executor {
+	return &Executor{client: client}
+}
+
+// WithClient runs every Redis command of an arbiter client on client, which needs no
+// go-redis client then: arbiter.NewClient(nil, WithClient(client)). Routes and RedLock
+// instances still run on their own go-redis clients.
+func WithClient(client rueidis.Client) arbiter.ClientOption {
+	return arbiter.WithExecutor(New(client))
+}
+
+func (e *Executor) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	cmd := e.client.B().Eval().Script(script).Numkeys(int64(len(keys))).Key(keys...).Arg(argv(args)...).Build()
+	return reply(e.client.Do(ctx, cmd))
+}
+
+func (e *Executor) EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) (interface{}, error) {
+	cmd := e.client.B().Evalsha().Sha1(sha).Numkeys(int64(len(keys))).Key(keys...).Arg(argv(args)...).Build()
+	return reply(e.client.Do(ctx, cmd))
+}
+
+func (e *Executor) ScriptLoad(ctx context.Context, script string) (string, error) {
+	sha, err := e.client.Do(ctx, e.client.B().ScriptLoad().Script(script).Build()).ToString()
+	return sha, wrapError(err)
+}
+
+func (e *Executor) Publish(ctx context.Context, channel, message string) error {
+	return wrapError(e.client.Do(ctx, e.client.B().Publish().Channel(channel).Message(message).Build()).Error())
+}
+
+func (e *Executor) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return reply(e.client.Do(ctx, e.command(args)))
+}
+
+func (e *Executor) DoMulti(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error) {
+	built := make(rueidis.Commands, len(cmds))
+	for i, args := range cmds {
+		built[i] = e.command(args)
+	}
+
+	replies := make([]interface{}, len(cmds))
+	for i, res := range e.client.DoMulti(ctx, built...) {
+		v, err := reply(res)
+		if _, ok := err.(redisError); ok {
+			v = err
+		} else if err != nil {
+			return nil, err
+		}
+		replies[i] = v
+	}
+	return replies, nil
+}
+
+// Scan calls fn for every key matching match. Every node known to the client is
+// scanned except the replicas of a cluster, which would report the keys again.
+func (e *Executor) Scan(ctx context.Context, match string, fn func(key string) error) error {
+	for _, node := range e.client.Nodes() {
+		if role, err := node.Do(ctx, node.B().Role().Build()).ToArray(); err == nil && len(role) > 0 {
+			if name, _ := role[0].ToString(); name != "master" {
+				continue
+			}
+		}
+
+		var cursor uint64
+		for {
+			entry, err := node.Do(ctx, node.B().Scan().Cursor(cursor).Match(match).Count(100).Build()).AsScanEntry()
+			if err != nil {
+				return wrapError(err)
+			}
+			for _, key := range entry.Elements {
+				if err := fn(key); err != nil {
+					return err
+				}
+			}
+			if cursor = entry.Cursor; cursor == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// command builds the command of args, routed by its key in a cluster
+func (e *Executor) command(args []interface{}) rueidis.Completed {
+	tokens := argv(args)
+	cmd := e.client.B().Arbitrary(tokens[0])
+	if len(tokens) > 1 && !keyless[strings.ToUpper(tokens[0])] {
+		return cmd.Keys(tokens[1]).Args(tokens[2:]...).Build()
+	}
+	return cmd.Args(tokens[1:]...).Build()
+}
+
+// Subscribe calls fn with the payload of every message on channel until the returned
+// function is called. It returns once Redis confirmed the subscription. A subscription
+// broken by a connection failure is taken again after resubscribeDelay.
+func (e *Executor) Subscribe(ctx context.Context, channel string, fn func(payload string)) (func(), error) {
+	broken, closeConn, err := e.subscribe(ctx, channel, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				closeConn()
+				return
+			case <-broken:
+			}
+			closeConn()
+
+			// Messages published until the subscription is taken again are missed
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(resubscribeDelay):
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+				next, nextClose, err := e.subscribe(ctx, channel, fn)
+				cancel()
+				if err == nil {
+					broken, closeConn = next, nextClose
+					break
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+// subscribe subscribes a dedicated connection to channel and waits for the confirmation.
+// The returned channel is closed once the subscription broke, the returned function
+// closes the connection.
+func (e *Executor) subscribe(ctx context.Context, channel string, fn func(payload string)) (<-chan error, func(), error) {
+	conn, _ := e.client.Dedicate()
+	confirmed := make(chan struct{})
+	var once sync.Once
+	broken := conn.SetPubSubHooks(rueidis.PubSubHooks{
+		OnMessage: func(msg rueidis.PubSubMessage) {
+			fn(msg.Message)
+		},
+		OnSubscription: func(s rueidis.PubSubSubscription) {
+			if s.Kind == "subscribe" && s.Channel == channel {
+				once.Do(func() { close(confirmed) })
+			}
+		},
+	})
+
+	if err := conn.Do(ctx, conn.B().Subscribe().Channel(channel).Build()).Error(); err != nil {
+		conn.Close()
+		return nil, nil, wrapError(err)
+	}
+	select {
+	case <-confirmed:
+		return broken, conn.Close, nil
+	case err := <-broken:
+		conn.Close()
+		if err == nil {
+			err = errors.New("subscription closed")
+		}
+		return nil, nil, err
+	case <-ctx.Done():
+		conn.Close()
+		return nil, nil, ctx.Err()
+	}
+}
+
+// EvalWait runs script followed by WAIT on one dedicated connection to the primary
+// owning keys[0]. It implements arbiter.ReplicationExecutor.
+func (e *Executor) EvalWait(ctx context.Context, script string, keys []string, replicas int, timeout time.Duration, args ...interface{}) (interface{}, int, error) {
+	var (
+		res        interface{}
+		replicated int64
+	)
+	err := e.client.Dedicated(func(conn rueidis.DedicatedClient) error {
+		results := conn.DoMulti(ctx,
+			conn.B().Eval().Script(script).Numkeys(int64(len(keys))).Key(keys...).Arg(argv(args)...).Build(),
+			conn.B().Wait().Numreplicas(int64(replicas)).Timeout(timeout.Milliseconds()).Build())
+
+		var err error
+		if res, err = reply(results[0]); err != nil {
+			return err
+		}
+		replicated, err = results[1].AsInt64()
+		return wrapError(err)
+	})
+	return res, int(replicated), err
+}
+
+// argv formats script arguments the way go-redis sends them
+func argv(args []interface{}) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = fmt.Sprint(arg)
+	}
+	return out
+}
+
+// reply converts a reply to the types arbiter expects, a nil reply to nil
+func reply(res rueidis.RedisResult) (interface{}, error) {
+	v, err := res.ToAny()
+	if rueidis.IsRedisNil(err) {
+		return nil, nil
+	}
+	return v, wrapError(err)
+}
+
+// redisError marks an error replied by Redis, which arbiter tells apart from connection
+// errors by the RedisError method of go-redis errors
+type redisError struct {
+	err *rueidis.RedisError
+}
+
+func (e redisError) Error() string {
+	return e.err.Error()
+}
+
+func (redisError) RedisError() {}
+
+// wrapError marks errors replied by Redis as redisError
+func wrapError(err error) error {
+	if rerr, ok := rueidis.IsRedisErr(err); ok {
+		return redisError{rerr}
+	}
+	return err
+}
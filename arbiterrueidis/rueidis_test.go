@@ -0,0 +1,93 @@
+package arbiterrueidis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/rueidis"
+
+	"github.com/huimingz/arbiter"
+)
+
+var (
+	_ arbiter.RedisExecutor       = (*Executor)(nil)
+	_ arbiter.ReplicationExecutor = (*Executor)(nil)
+)
+
+func setupRueidis(t *testing.T) rueidis.Client {
+	client, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{"localhost:6379"}})
+	if err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+	return client
+}
+
+func TestExecutor(t *testing.T) {
+	rc := setupRueidis(t)
+	defer rc.Close()
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer redisClient.Close()
+
+	ctx := context.Background()
+	client := arbiter.NewClient(nil, arbiter.WithKeyPrefix("test-rueidis:"), WithClient(rc))
+	plain := arbiter.NewClient(redisClient, arbiter.WithKeyPrefix("test-rueidis:"))
+
+	lock := client.NewLock("test-lock", arbiter.WithLeaseTime(time.Second))
+	if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	if lock.Fence() == 0 {
+		t.Error("Expected a fencing token")
+	}
+
+	// Clients on go-redis see the lock held through rueidis
+	if acquired, err := plain.NewLock("test-lock").TryLock(ctx); err != nil || acquired {
+		t.Errorf("Expected lock to be held, got: %v, %v", acquired, err)
+	}
+	if locked, err := client.IsLocked(ctx, "test-lock"); err != nil || !locked {
+		t.Errorf("Expected lock to be held, got: %v, %v", locked, err)
+	}
+	if err := client.Warmup(ctx); err != nil {
+		t.Errorf("Failed to warm up: %v", err)
+	}
+	if err := lock.Refresh(ctx); err != nil {
+		t.Errorf("Failed to refresh lock: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+	if locked, err := plain.IsLocked(ctx, "test-lock"); err != nil || locked {
+		t.Errorf("Expected lock to be released, got: %v, %v", locked, err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	rc := setupRueidis(t)
+	defer rc.Close()
+
+	ctx := context.Background()
+	executor := New(rc)
+	messages := make(chan string, 1)
+	stop, err := executor.Subscribe(ctx, "test-rueidis:events", func(payload string) {
+		messages <- payload
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer stop()
+
+	// Subscribe returned after the confirmation, the message cannot be missed
+	if err := executor.Publish(ctx, "test-rueidis:events", "key"); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	select {
+	case payload := <-messages:
+		if payload != "key" {
+			t.Errorf("Expected payload key, got: %s", payload)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the message right after subscribing")
+	}
+}
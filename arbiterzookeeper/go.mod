@@ -0,0 +1,16 @@
+module github.com/huimingz/arbiter/arbiterzookeeper
+
+go 1.21
+
+require (
+	github.com/go-zookeeper/zk v1.0.3
+	github.com/huimingz/arbiter v0.0.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/redis/go-redis/v9 v9.4.0 // indirect
+)
+
+replace github.com/huimingz/arbiter => ../
@@ -0,0 +1,209 @@
+// Package arbiterzookeeper runs arbiter locks on the classic ZooKeeper lock recipe of
+// ephemeral sequential nodes, for strongly consistent locks that do not rely on leases
+// kept by Redis.
+package arbiterzookeeper
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-zookeeper/zk"
+
+	"github.com/huimingz/arbiter"
+)
+
+const (
+	// defaultRoot is the node lock nodes are created below
+	defaultRoot = "/arbiter"
+
+	// nodePrefix is the name of lock nodes before their sequence number
+	nodePrefix = "lock-"
+)
+
+// Store is an arbiter.Store on ZooKeeper. An acquisition creates an ephemeral sequential
+// node below the node of the lock and holds the lock while its node has the lowest
+// sequence number, which serves as fencing token counted from 1. A try-lock that is
+// not first removes its node again. Nodes live as long as the ZooKeeper session of the
+// connection, so the session timeout takes the place of the lease.
+type Store struct {
+	conn *zk.Conn
+	root string
+
+	mu    sync.Mutex
+	nodes map[string]string
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithRoot sets the node lock nodes are created below, "/arbiter" by default
+func WithRoot(root string) Option {
+	return func(s *Store) {
+		s.root = root
+	}
+}
+
+// NewStore returns a Store on conn
+func NewStore(conn *zk.Conn, opts ...Option) *Store {
+	s := &Store{conn: conn, root: defaultRoot, nodes: make(map[string]string)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewClient returns an arbiter client whose locks run on the ZooKeeper ensemble of conn.
+// Operations that need Redis return arbiter.ErrStoreUnsupported.
+func NewClient(conn *zk.Conn, opts ...Option) *arbiter.Client {
+	return arbiter.NewClient(nil, arbiter.WithStore(NewStore(conn, opts...)))
+}
+
+func (s *Store) TryAcquire(ctx context.Context, req arbiter.LeaseRequest) (arbiter.AcquireResult, error) {
+	// The owner re-enters the lock its node still holds
+	if node, ok := s.cached(req.Key, req.Owner); ok {
+		exists, _, err := s.conn.Exists(node)
+		if err != nil {
+			return arbiter.AcquireResult{}, err
+		}
+		if exists {
+			return arbiter.AcquireResult{Acquired: true, Fence: fence(node)}, nil
+		}
+		s.forget(req.Key, req.Owner)
+	}
+
+	parent := s.lockPath(req.Key)
+	if err := s.ensure(parent); err != nil {
+		return arbiter.AcquireResult{}, err
+	}
+	node, err := s.conn.CreateProtectedEphemeralSequential(parent+"/"+nodePrefix, []byte(req.Owner), zk.WorldACL(zk.PermAll))
+	if err != nil {
+		return arbiter.AcquireResult{}, err
+	}
+
+	children, _, err := s.conn.Children(parent)
+	if err != nil {
+		s.conn.Delete(node, -1)
+		return arbiter.AcquireResult{}, err
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return sequence(children[i]) < sequence(children[j])
+	})
+	first := parent + "/" + children[0]
+	if first == node {
+		s.mu.Lock()
+		s.nodes[req.Key+"\x00"+req.Owner] = node
+		s.mu.Unlock()
+		return arbiter.AcquireResult{Acquired: true, Fence: fence(node)}, nil
+	}
+
+	// Try-locks do not queue, the node is removed again
+	if err := s.conn.Delete(node, -1); err != nil && !errors.Is(err, zk.ErrNoNode) {
+		return arbiter.AcquireResult{}, err
+	}
+
+	var res arbiter.AcquireResult
+	if req.ReadHolder {
+		owner, _, err := s.conn.Get(first)
+		if errors.Is(err, zk.ErrNoNode) {
+			// The holder released the lock meanwhile
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+		res.Holder = arbiter.LockInfo{Held: true, Name: req.Name, Owner: string(owner), Fence: fence(first)}
+	}
+	return res, nil
+}
+
+func (s *Store) Release(ctx context.Context, key, owner string) (bool, error) {
+	node, ok := s.cached(key, owner)
+	if !ok {
+		return false, nil
+	}
+	s.forget(key, owner)
+
+	err := s.conn.Delete(node, -1)
+	if errors.Is(err, zk.ErrNoNode) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Extend checks that the node of the owner still exists. The ZooKeeper session keeps it
+// alive, so req.Lease is not applied.
+func (s *Store) Extend(ctx context.Context, req arbiter.LeaseRequest) (bool, error) {
+	node, ok := s.cached(req.Key, req.Owner)
+	if !ok {
+		return false, nil
+	}
+
+	exists, _, err := s.conn.Exists(node)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		// The session expired and took the node with it
+		s.forget(req.Key, req.Owner)
+	}
+	return exists, nil
+}
+
+// Watch is not supported
+func (s *Store) Watch(ctx context.Context, fn func(key string)) (func(), error) {
+	return nil, arbiter.ErrStoreUnsupported
+}
+
+// ensure creates the persistent node p and its parents unless they exist
+func (s *Store) ensure(p string) error {
+	var current string
+	for _, part := range strings.Split(strings.Trim(p, "/"), "/") {
+		current += "/" + part
+		_, err := s.conn.Create(current, nil, 0, zk.WorldACL(zk.PermAll))
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) cached(key, owner string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[key+"\x00"+owner]
+	return node, ok
+}
+
+func (s *Store) forget(key, owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.nodes, key+"\x00"+owner)
+}
+
+// lockPath returns the node of the lock key, escaped to a single path element
+func (s *Store) lockPath(key string) string {
+	return path.Join(s.root, url.PathEscape(key))
+}
+
+// sequence returns the sequence number ZooKeeper appended to the name of node
+func sequence(node string) int64 {
+	i := strings.LastIndex(node, nodePrefix)
+	if i < 0 {
+		return 0
+	}
+	seq, _ := strconv.ParseInt(node[i+len(nodePrefix):], 10, 64)
+	return seq
+}
+
+// fence returns the fencing token of node, its sequence number counted from 1
+func fence(node string) int64 {
+	return sequence(node) + 1
+}
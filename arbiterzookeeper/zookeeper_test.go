@@ -0,0 +1,69 @@
+package arbiterzookeeper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+
+	"github.com/huimingz/arbiter"
+)
+
+func setupZooKeeper(t *testing.T) *zk.Conn {
+	// Connect retries in the background, a server that does not listen would stall the test
+	probe, err := net.DialTimeout("tcp", "localhost:2181", time.Second)
+	if err != nil {
+		t.Skipf("ZooKeeper is not available: %v", err)
+	}
+	probe.Close()
+
+	conn, _, err := zk.Connect([]string{"localhost:2181"}, 5*time.Second)
+	if err != nil {
+		t.Skipf("ZooKeeper is not available: %v", err)
+	}
+	return conn
+}
+
+func TestStore(t *testing.T) {
+	conn := setupZooKeeper(t)
+	defer conn.Close()
+
+	ctx := context.Background()
+	client := NewClient(conn, WithRoot("/arbiter-test"))
+	other := NewClient(conn, WithRoot("/arbiter-test"))
+
+	lock := client.NewLock("test/lock")
+	if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	fence := lock.Fence()
+	if fence == 0 {
+		t.Error("Expected a fencing token")
+	}
+
+	if acquired, err := other.NewLock("test/lock").TryLock(ctx); err != nil || acquired {
+		t.Errorf("Expected lock to be held, got: %v, %v", acquired, err)
+	}
+	if err := lock.Refresh(ctx); err != nil {
+		t.Errorf("Failed to refresh lock: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+
+	next := other.NewLock("test/lock")
+	if acquired, err := next.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire released lock, got: %v, %v", acquired, err)
+	}
+	if next.Fence() <= fence {
+		t.Errorf("Expected fencing token above %d, got: %d", fence, next.Fence())
+	}
+	next.Unlock(ctx)
+
+	if _, err := client.IsLocked(ctx, "test/lock"); !errors.Is(err, arbiter.ErrStoreUnsupported) {
+		t.Errorf("Expected ErrStoreUnsupported, got: %v", err)
+	}
+}
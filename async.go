@@ -0,0 +1,25 @@
+package arbiter
+
+import "context"
+
+func (l *lockImpl) LockAsync(ctx context.Context) <-chan error {
+	return lockAsync(ctx, l.Lock)
+}
+
+func (l *scopedLock) LockAsync(ctx context.Context) <-chan error {
+	return lockAsync(ctx, l.Lock)
+}
+
+func (m *multiLock) LockAsync(ctx context.Context) <-chan error {
+	return lockAsync(ctx, m.Lock)
+}
+
+// lockAsync runs lock in a goroutine and delivers its result on a buffered channel, so
+// the goroutine finishes even if the caller never receives
+func lockAsync(ctx context.Context, lock func(ctx context.Context) error) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- lock(ctx)
+	}()
+	return done
+}
@@ -0,0 +1,54 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockAsync(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	holder := client.NewLock("test-lockasync", WithLeaseTime(time.Minute))
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	waiter := client.NewLock("test-lockasync", WithRetryInterval(10*time.Millisecond))
+	done := waiter.LockAsync(ctx)
+	select {
+	case err := <-done:
+		t.Fatalf("Expected to wait while the lock is held, got: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected to acquire released lock, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the result after the release")
+	}
+	if waiter.Fence() == 0 {
+		t.Error("Expected the lock to be held")
+	}
+
+	// Cancelling the context ends a pending acquisition
+	cancelCtx, cancel := context.WithCancel(ctx)
+	done = holder.LockAsync(cancelCtx)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the result after the cancellation")
+	}
+}
@@ -0,0 +1,61 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnauthorized can be returned by an Authorizer to deny a privileged operation
+var ErrUnauthorized = errors.New("operation not authorized")
+
+// AdminOp identifies a privileged operation checked by the Authorizer
+type AdminOp string
+
+const (
+	OpForceUnlock      AdminOp = "force_unlock"
+	OpFreeze           AdminOp = "freeze"
+	OpUnfreeze         AdminOp = "unfreeze"
+	OpAnnotate         AdminOp = "annotate"
+	OpRemoveAnnotation AdminOp = "remove_annotation"
+	OpSteal            AdminOp = "steal"
+)
+
+// Authorizer is consulted before every privileged operation on the lock or pattern target.
+// Returning an error denies the operation and is passed back to the caller unchanged.
+// The identity of the caller can be read with IdentityFromContext.
+type Authorizer func(ctx context.Context, op AdminOp, target string) error
+
+// WithAuthorizer sets the authorization callback for privileged operations.
+// Without one every operation is allowed.
+func WithAuthorizer(authorizer Authorizer) ClientOption {
+	return func(c *Client) {
+		c.authorizer = authorizer
+	}
+}
+
+type identityKey struct{}
+
+// ContextWithIdentity returns a context carrying the identity of the caller for the Authorizer
+func ContextWithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity stored by ContextWithIdentity
+func IdentityFromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	return identity, ok
+}
+
+// authorize consults the authorizer of the client
+func (c *Client) authorize(ctx context.Context, op AdminOp, target string) error {
+	if c.authorizer == nil {
+		return nil
+	}
+
+	if err := c.authorizer(ctx, op, target); err != nil {
+		identity, _ := IdentityFromContext(ctx)
+		c.logger.Warn(ctx, "Denied %s of %s for identity %q, error: %v", op, target, identity, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAuthorizer(t *testing.T) {
+	type check struct {
+		op       AdminOp
+		target   string
+		identity string
+	}
+	var checks []check
+
+	client := NewClient(nil,
+		WithLogger(&NoopLogger{}),
+		WithAuthorizer(func(ctx context.Context, op AdminOp, target string) error {
+			identity, _ := IdentityFromContext(ctx)
+			checks = append(checks, check{op: op, target: target, identity: identity})
+			return ErrUnauthorized
+		}),
+	)
+	admin := client.Admin()
+	ctx := ContextWithIdentity(context.Background(), "alice")
+
+	// Denied operations fail before reaching Redis
+	operations := map[AdminOp]func() error{
+		OpForceUnlock:      func() error { return admin.ForceUnlock(ctx, "payments") },
+		OpFreeze:           func() error { return admin.Freeze(ctx, "payments") },
+		OpUnfreeze:         func() error { return admin.Unfreeze(ctx, "payments") },
+		OpAnnotate:         func() error { return admin.Annotate(ctx, "payments", "note", "value") },
+		OpRemoveAnnotation: func() error { return admin.RemoveAnnotation(ctx, "payments", "note") },
+		OpSteal: func() error {
+			_, err := admin.Steal(ctx, "payments")
+			return err
+		},
+	}
+
+	for op, call := range operations {
+		checks = nil
+		if err := call(); err != ErrUnauthorized {
+			t.Errorf("%s: expected unauthorized error, got: %v", op, err)
+		}
+		if len(checks) != 1 || checks[0] != (check{op: op, target: "payments", identity: "alice"}) {
+			t.Errorf("%s: unexpected checks: %+v", op, checks)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+)
+
+// AcquireAvailable tries each named lock once and returns the ones acquired, keyed by
+// name. Unlike an all-or-nothing acquisition it succeeds partially: locks that are held
+// elsewhere, frozen, rejected by the name policy or over quota are left out, so shard
+// processing jobs can work on whatever is free and come back for the rest.
+// On any other error the locks acquired so far are released and the error is returned.
+func (c *Client) AcquireAvailable(ctx context.Context, names []string, opts ...Option) (map[string]Lock, error) {
+	acquired := make(map[string]Lock, len(names))
+	for _, name := range names {
+		if _, dup := acquired[name]; dup {
+			continue
+		}
+
+		lock := c.NewLock(name, opts...)
+		ok, err := lock.TryLock(ctx)
+		if err != nil && !unavailable(err) {
+			for _, l := range acquired {
+				l.Unlock(context.WithoutCancel(ctx))
+			}
+			return nil, err
+		}
+		if ok {
+			acquired[name] = lock
+		}
+	}
+
+	c.logger.Debug(ctx, "Acquired %d of %d available locks", len(acquired), len(names))
+	return acquired, nil
+}
+
+// ProcessAvailable acquires the available locks like AcquireAvailable and calls fn for
+// each of them in order of names, releasing every lock once its callback returns.
+// It returns the names that were processed and the first callback error, after
+// which the remaining locks are released without being processed.
+func (c *Client) ProcessAvailable(ctx context.Context, names []string, fn func(ctx context.Context, name string) error, opts ...Option) ([]string, error) {
+	acquired, err := c.AcquireAvailable(ctx, names, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var processed []string
+	var fnErr error
+	for _, name := range names {
+		lock, ok := acquired[name]
+		if !ok {
+			continue
+		}
+		delete(acquired, name)
+
+		if fnErr == nil {
+			if fnErr = fn(ctx, name); fnErr == nil {
+				processed = append(processed, name)
+			}
+		}
+		if err := lock.Unlock(context.WithoutCancel(ctx)); err != nil {
+			c.logger.Warn(ctx, "Failed to release processed lock: %s, error: %v", name, err)
+		}
+	}
+	return processed, fnErr
+}
+
+// unavailable reports whether a TryLock error only means the lock cannot be taken now
+func unavailable(err error) bool {
+	return err == ErrLockFrozen || err == ErrLockNameRejected || err == ErrReservedLockName ||
+		errors.Is(err, ErrQuotaExceeded)
+}
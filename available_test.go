@@ -0,0 +1,72 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAcquireAvailable(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-available:"), WithDeniedNames("denied-*"))
+	ctx := context.Background()
+
+	busy := client.NewLock("shard-2")
+	if err := busy.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer busy.Unlock(ctx)
+
+	names := []string{"shard-1", "shard-2", "denied-3", "shard-4"}
+
+	t.Run("partial success", func(t *testing.T) {
+		acquired, err := client.AcquireAvailable(ctx, names)
+		if err != nil {
+			t.Fatalf("Failed to acquire locks: %v", err)
+		}
+		defer func() {
+			for _, lock := range acquired {
+				lock.Unlock(ctx)
+			}
+		}()
+
+		if len(acquired) != 2 || acquired["shard-1"] == nil || acquired["shard-4"] == nil {
+			t.Fatalf("Unexpected acquired locks: %v", acquired)
+		}
+	})
+
+	t.Run("process", func(t *testing.T) {
+		var seen []string
+		processed, err := client.ProcessAvailable(ctx, names, func(ctx context.Context, name string) error {
+			seen = append(seen, name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to process locks: %v", err)
+		}
+		if len(processed) != 2 || processed[0] != "shard-1" || processed[1] != "shard-4" {
+			t.Fatalf("Unexpected processed names: %v", processed)
+		}
+
+		for _, name := range seen {
+			if locked, _ := client.IsLocked(ctx, name); locked {
+				t.Errorf("Lock should be released after processing: %s", name)
+			}
+		}
+	})
+
+	t.Run("callback error stops processing", func(t *testing.T) {
+		failure := errors.New("failure")
+		processed, err := client.ProcessAvailable(ctx, names, func(ctx context.Context, name string) error {
+			return failure
+		})
+		if err != failure || len(processed) != 0 {
+			t.Fatalf("Expected callback error, got: %v, %v", processed, err)
+		}
+		if locked, _ := client.IsLocked(ctx, "shard-4"); locked {
+			t.Error("Unprocessed locks should be released")
+		}
+	})
+}
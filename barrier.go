@@ -0,0 +1,185 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// ErrNotEntered is returned by DoubleBarrier.Leave for participants that did not enter
+var ErrNotEntered = errors.New("barrier not entered")
+
+// Barrier is a distributed cyclic barrier. Enter blocks until all parties entered,
+// then every participant unblocks together and the next round begins.
+type Barrier interface {
+	// Enter blocks until all parties of the round entered or ctx is done
+	Enter(ctx context.Context) error
+}
+
+// DoubleBarrier is a distributed barrier with an enter and a leave phase, for batch
+// jobs that must start together and finish together
+type DoubleBarrier interface {
+	// Enter blocks until all parties entered or ctx is done
+	Enter(ctx context.Context) error
+
+	// Leave blocks until all parties left or ctx is done
+	Leave(ctx context.Context) error
+}
+
+type barrier struct {
+	client  *Client
+	name    string
+	keys    []string
+	parties int
+	cyclic  bool
+	value   string
+	keep    time.Duration
+	logger  Logger
+
+	round int64
+}
+
+// NewBarrier creates a cyclic barrier for parties participants, one instance per
+// participant. The barrier state expires once idle for the lease time of opts.
+func (c *Client) NewBarrier(name string, parties int, opts ...Option) Barrier {
+	return c.newBarrier(name, parties, true, opts)
+}
+
+// NewDoubleBarrier creates a double barrier for parties participants, one instance
+// per participant. Participants arriving while the previous round is still leaving
+// wait for the next round.
+func (c *Client) NewDoubleBarrier(name string, parties int, opts ...Option) DoubleBarrier {
+	return c.newBarrier(name, parties, false, opts)
+}
+
+func (c *Client) newBarrier(name string, parties int, cyclic bool, opts []Option) *barrier {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	c = c.route(name)
+	base := c.primitiveKey("barrier", name)
+	return &barrier{
+		client:  c,
+		name:    name,
+		keys:    []string{base, base + ":parties"},
+		parties: parties,
+		cyclic:  cyclic,
+		value:   generateValue(),
+		keep:    options.LeaseTime,
+		logger:  c.logger,
+	}
+}
+
+func (b *barrier) Enter(ctx context.Context) error {
+	entered := false
+	return b.await(ctx, func() (bool, error) {
+		// Entering again after the round passed would join the next one
+		if !entered {
+			round, err := replyInt(b.client.runScript(ctx, lua.BarrierEnter, b.keys, b.value, b.parties,
+				btoi(b.cyclic), b.client.eventsChannel(), b.keep.Milliseconds()))
+			if err != nil || round < 0 {
+				return false, err
+			}
+			b.round, entered = round, true
+		}
+
+		reply, err := b.client.do(ctx, "HMGET", b.keys[0], "round", "open")
+		if err != nil {
+			return false, err
+		}
+		state, _ := reply.([]interface{})
+		if len(state) != 2 {
+			return false, fmt.Errorf("unexpected reply: %v", reply)
+		}
+		current, open := parseRound(state[0]), parseRound(state[1])
+		return current > b.round || (state[1] != nil && open == b.round), nil
+	})
+}
+
+func (b *barrier) Leave(ctx context.Context) error {
+	left := false
+	return b.await(ctx, func() (bool, error) {
+		if !left {
+			round, err := replyInt(b.client.runScript(ctx, lua.BarrierLeave, b.keys, b.value,
+				b.client.eventsChannel(), b.keep.Milliseconds()))
+			if err != nil {
+				return false, err
+			}
+			if round < 0 {
+				return false, ErrNotEntered
+			}
+			b.round, left = round, true
+		}
+
+		current, err := b.client.do(ctx, "HGET", b.keys[0], "round")
+		if err != nil {
+			return false, err
+		}
+		return parseRound(current) > b.round, nil
+	})
+}
+
+// await calls passed until it reports the barrier passed, re-checking whenever the
+// barrier changes. Changes are published over pub/sub, the periodic re-check covers
+// messages lost while the subscription reconnects.
+func (b *barrier) await(ctx context.Context, passed func() (bool, error)) error {
+	if err := b.client.policy.check(b.name); err != nil {
+		return err
+	}
+
+	changed := make(chan struct{}, 1)
+	stopListening, err := b.client.notifier.listen(ctx, func(key string) {
+		if key != b.keys[0] {
+			return
+		}
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer stopListening()
+
+	recheck := time.NewTicker(latchRecheck)
+	defer recheck.Stop()
+
+	for {
+		ok, err := passed()
+		if err != nil {
+			return err
+		}
+		if ok {
+			b.logger.Debug(ctx, "Passed barrier: %s", b.name)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-recheck.C:
+		}
+	}
+}
+
+// parseRound reads a round number of the barrier hash, 0 if it is missing
+func parseRound(v interface{}) int64 {
+	s, _ := v.(string)
+	round, _ := strconv.ParseInt(s, 10, 64)
+	return round
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
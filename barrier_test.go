@@ -0,0 +1,95 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-barrier:"))
+	defer client.Close()
+	ctx := context.Background()
+
+	t.Run("participants unblock together in every round", func(t *testing.T) {
+		const parties = 3
+		var entered atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < parties; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				b := client.NewBarrier("test-cyclic", parties)
+				for round := 1; round <= 2; round++ {
+					entered.Add(1)
+					if err := b.Enter(ctx); err != nil {
+						t.Errorf("Failed to enter barrier: %v", err)
+						return
+					}
+					if n := entered.Load(); n < int32(round*parties) {
+						t.Errorf("Participant %d passed round %d after only %d entries", i, round, n)
+					}
+				}
+			}(i)
+			time.Sleep(50 * time.Millisecond)
+		}
+		wg.Wait()
+	})
+
+	t.Run("barrier blocks until all parties entered", func(t *testing.T) {
+		waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		if err := client.NewBarrier("test-incomplete", 2).Enter(waitCtx); err != context.DeadlineExceeded {
+			t.Fatalf("Expected deadline error, got: %v", err)
+		}
+	})
+
+	t.Run("double barrier enters and leaves together", func(t *testing.T) {
+		const parties = 2
+		var left atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < parties; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				b := client.NewDoubleBarrier("test-double", parties)
+				if err := b.Enter(ctx); err != nil {
+					t.Errorf("Failed to enter barrier: %v", err)
+					return
+				}
+
+				// The second participant works longer before leaving
+				time.Sleep(time.Duration(i) * 100 * time.Millisecond)
+				left.Add(1)
+				if err := b.Leave(ctx); err != nil {
+					t.Errorf("Failed to leave barrier: %v", err)
+					return
+				}
+				if left.Load() != parties {
+					t.Errorf("Participant %d passed the leave phase early", i)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		// The next round starts afresh
+		next := client.NewDoubleBarrier("test-double", 1)
+		if err := next.Enter(ctx); err != nil {
+			t.Fatalf("Failed to enter next round: %v", err)
+		}
+		if err := next.Leave(ctx); err != nil {
+			t.Fatalf("Failed to leave next round: %v", err)
+		}
+	})
+
+	t.Run("leave without enter", func(t *testing.T) {
+		if err := client.NewDoubleBarrier("test-not-entered", 2).Leave(ctx); err != ErrNotEntered {
+			t.Fatalf("Expected not entered error, got: %v", err)
+		}
+	})
+}
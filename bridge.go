@@ -0,0 +1,95 @@
+package arbiter
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Publisher is the interface implemented by message brokers such as Kafka or NATS.
+// The key identifies the lock an event belongs to and can be used for partitioning.
+type Publisher interface {
+	Publish(ctx context.Context, subject, key string, payload []byte) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface
+type PublisherFunc func(ctx context.Context, subject, key string, payload []byte) error
+
+// Publish calls f(ctx, subject, key, payload)
+func (f PublisherFunc) Publish(ctx context.Context, subject, key string, payload []byte) error {
+	return f(ctx, subject, key, payload)
+}
+
+// BridgeSink is an EventSink that publishes events as JSON to a message broker,
+// letting the client event stream flow into existing event infrastructure.
+type BridgeSink struct {
+	publisher Publisher
+	subject   func(Event) string
+
+	queue eventQueue
+}
+
+// BridgeOption is a function type for setting bridge sink options
+type BridgeOption func(*BridgeSink)
+
+// WithBridgeSubject sets the subject or topic an event is published to.
+// By default events go to "arbiter.events.<type>".
+func WithBridgeSubject(subject func(Event) string) BridgeOption {
+	return func(s *BridgeSink) {
+		s.subject = subject
+	}
+}
+
+// WithBridgeQueueSize sets how many events may wait for publishing before new ones are dropped
+func WithBridgeQueueSize(size int) BridgeOption {
+	return func(s *BridgeSink) {
+		s.queue.queue = make(chan Event, size)
+	}
+}
+
+// WithBridgeLogger sets the logger reporting failed publishes
+func WithBridgeLogger(logger Logger) BridgeOption {
+	return func(s *BridgeSink) {
+		s.queue.logger = logger
+	}
+}
+
+// NewBridgeSink creates a sink publishing events through publisher until Close is called
+func NewBridgeSink(publisher Publisher, opts ...BridgeOption) *BridgeSink {
+	s := &BridgeSink{
+		publisher: publisher,
+		subject: func(event Event) string {
+			return "arbiter.events." + string(event.Type)
+		},
+		queue: eventQueue{
+			name:   "bridge",
+			logger: newDefaultLogger(),
+			queue:  make(chan Event, 256),
+		},
+	}
+	s.queue.deliver = s.publish
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.queue.start()
+	return s
+}
+
+// Emit queues the event for publishing, dropping it if the queue is full
+func (s *BridgeSink) Emit(ctx context.Context, event Event) {
+	s.queue.push(ctx, event)
+}
+
+// Close stops accepting events and waits until the queued ones are published
+func (s *BridgeSink) Close() {
+	s.queue.close()
+}
+
+func (s *BridgeSink) publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.publisher.Publish(ctx, s.subject(event), event.Name, payload)
+}
@@ -0,0 +1,77 @@
+package arbiter
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+// recordingSink is an EventSink recording every event it receives
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordingSink) Emit(ctx context.Context, event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *recordingSink) types() []EventType {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	types := make([]EventType, len(s.events))
+	for i, event := range s.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestBridgeSink(t *testing.T) {
+	type message struct {
+		subject string
+		key     string
+		event   Event
+	}
+	var published []message
+
+	publisher := PublisherFunc(func(ctx context.Context, subject, key string, payload []byte) error {
+		var event Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return err
+		}
+		published = append(published, message{subject: subject, key: key, event: event})
+		return nil
+	})
+
+	t.Run("default subject", func(t *testing.T) {
+		published = nil
+		sink := NewBridgeSink(publisher, WithBridgeLogger(&NoopLogger{}))
+		sink.Emit(context.Background(), Event{Type: EventLockLost, Name: "test-bridge"})
+		sink.Close()
+
+		if len(published) != 1 {
+			t.Fatalf("Expected 1 message, got %d", len(published))
+		}
+		if msg := published[0]; msg.subject != "arbiter.events.lock_lost" || msg.key != "test-bridge" || msg.event.Type != EventLockLost {
+			t.Errorf("Unexpected message: %+v", msg)
+		}
+	})
+
+	t.Run("custom subject", func(t *testing.T) {
+		published = nil
+		sink := NewBridgeSink(publisher,
+			WithBridgeLogger(&NoopLogger{}),
+			WithBridgeSubject(func(Event) string { return "coordination" }),
+		)
+		sink.Emit(context.Background(), Event{Type: EventForceUnlock, Name: "test-bridge"})
+		sink.Close()
+
+		if len(published) != 1 || published[0].subject != "coordination" {
+			t.Errorf("Unexpected messages: %+v", published)
+		}
+	})
+}
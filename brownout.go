@@ -0,0 +1,67 @@
+package arbiter
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// brownoutWindow is how many recent round trips decide whether Redis is under pressure
+	brownoutWindow = 64
+
+	// brownoutFactor is how many times longer leases get during a brownout
+	brownoutFactor = 4
+)
+
+// brownout lengthens leases while Redis latency is high
+type brownout struct {
+	threshold time.Duration
+	maxLease  time.Duration
+
+	samples atomic.Int64
+	active  atomic.Bool
+}
+
+// WithBrownout lengthens leases, and with them the refresh interval of watchdogs, while the
+// 99th percentile latency of recent acquisitions and refreshes exceeds threshold. Leases
+// grow fourfold, capped at maxLease, which cuts refresh load and keeps locks alive through
+// partial outages. Normal leases return once latency falls below half the threshold.
+func WithBrownout(threshold, maxLease time.Duration) ClientOption {
+	return func(c *Client) {
+		c.brownout = &brownout{threshold: threshold, maxLease: maxLease}
+	}
+}
+
+// Brownout reports whether the client currently lengthens leases due to Redis pressure
+func (c *Client) Brownout() bool {
+	return c.brownout != nil && c.brownout.active.Load()
+}
+
+// updateBrownout re-evaluates Redis pressure every brownoutWindow/4 round trips
+func (c *Client) updateBrownout() {
+	b := c.brownout
+	if b == nil || b.samples.Add(1)%(brownoutWindow/4) != 0 {
+		return
+	}
+
+	p99 := c.latency.recentPercentile(0.99, brownoutWindow)
+	switch {
+	case !b.active.Load() && p99 > b.threshold:
+		b.active.Store(true)
+		c.logger.Warn(context.Background(), "Entering brownout, Redis p99 latency %v exceeds %v", p99, b.threshold)
+		c.metrics.SetGauge(MetricBrownout, 1)
+	case b.active.Load() && p99 < b.threshold/2:
+		b.active.Store(false)
+		c.logger.Info(context.Background(), "Leaving brownout, Redis p99 latency %v", p99)
+		c.metrics.SetGauge(MetricBrownout, 0)
+	}
+}
+
+// brownoutLease returns lease, lengthened during a brownout
+func (c *Client) brownoutLease(lease time.Duration) time.Duration {
+	if !c.Brownout() {
+		return lease
+	}
+	return max(lease, min(brownoutFactor*lease, c.brownout.maxLease))
+}
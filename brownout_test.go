@@ -0,0 +1,60 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBrownout(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	metrics := newRecordingMetrics()
+	client := NewClient(redisClient, WithKeyPrefix("test-brownout:"), WithMetrics(metrics),
+		WithBrownout(50*time.Millisecond, 30*time.Second))
+	ctx := context.Background()
+
+	ttl := func() time.Duration {
+		return redisClient.PTTL(ctx, client.lockKey("test-lease")).Val()
+	}
+
+	lock := client.NewLock("test-lease", WithLeaseTime(10*time.Second))
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer lock.Unlock(ctx)
+	if client.Brownout() || ttl() > 10*time.Second {
+		t.Fatal("Lease should be normal without pressure")
+	}
+
+	for i := 0; i < brownoutWindow; i++ {
+		client.observe("refresh", time.Now().Add(-200*time.Millisecond))
+	}
+	if !client.Brownout() {
+		t.Fatal("Client should enter brownout under high latency")
+	}
+	if err := lock.Refresh(ctx); err != nil {
+		t.Fatalf("Failed to refresh lock: %v", err)
+	}
+	// Leases grow fourfold up to the cap
+	if got := ttl(); got <= 29*time.Second || got > 30*time.Second {
+		t.Fatalf("Expected lease capped at 30s, got: %v", got)
+	}
+	if metrics.gauges[MetricBrownout] != 1 {
+		t.Error("Brownout gauge should be set")
+	}
+
+	for i := 0; i < brownoutWindow; i++ {
+		client.observe("refresh", time.Now())
+	}
+	if client.Brownout() {
+		t.Fatal("Client should leave brownout once latency subsides")
+	}
+	if err := lock.Refresh(ctx); err != nil {
+		t.Fatalf("Failed to refresh lock: %v", err)
+	}
+	if got := ttl(); got > 10*time.Second {
+		t.Fatalf("Expected normal lease, got: %v", got)
+	}
+}
@@ -0,0 +1,89 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// Quota tracks a consumable budget per window shared by every process using its name,
+// e.g. 10k emails a day per tenant. Windows are aligned to multiples of their length
+// since the Unix epoch, so a daily quota rolls over at midnight UTC.
+type Quota struct {
+	client *Client
+	name   string
+	key    string
+	limit  int64
+	window time.Duration
+	logger Logger
+}
+
+// QuotaUsage is the state of a quota in its current window
+type QuotaUsage struct {
+	// Used is the budget consumed in the window
+	Used int64
+	// Remaining is the budget left in the window
+	Remaining int64
+	// ResetAt is when the window ends and the budget is restored
+	ResetAt time.Time
+}
+
+// NewQuota creates the quota name granting limit per window. Every process must use
+// the same limit and window.
+func (c *Client) NewQuota(name string, limit int64, window time.Duration) *Quota {
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	return &Quota{
+		client: c,
+		name:   name,
+		key:    c.internalKey("quota:" + name),
+		limit:  limit,
+		window: window,
+		logger: c.logger,
+	}
+}
+
+// Consume takes n from the budget of the current window if that much is left, and
+// reports whether it did. Nothing is consumed if n exceeds the rest.
+func (q *Quota) Consume(ctx context.Context, n int64) (bool, QuotaUsage, error) {
+	if err := q.client.policy.check(q.name); err != nil {
+		return false, QuotaUsage{}, err
+	}
+	return q.eval(ctx, max(n, 1))
+}
+
+// Remaining returns the usage of the current window
+func (q *Quota) Remaining(ctx context.Context) (QuotaUsage, error) {
+	_, usage, err := q.eval(ctx, 0)
+	return usage, err
+}
+
+// Reset restores the full budget of the current window
+func (q *Quota) Reset(ctx context.Context) error {
+	if err := q.client.policy.check(q.name); err != nil {
+		return err
+	}
+	_, err := q.client.do(ctx, "DEL", q.key)
+	return err
+}
+
+// eval runs the consume script for n, 0 only reading the usage
+func (q *Quota) eval(ctx context.Context, n int64) (bool, QuotaUsage, error) {
+	res, err := replyInts(q.client.runScript(ctx, lua.QuotaConsume, []string{q.key},
+		time.Now().UnixMilli(), q.window.Milliseconds(), q.limit, n))
+	if err != nil {
+		q.logger.Error(ctx, "Failed to read quota: %s, error: %v", q.name, err)
+		return false, QuotaUsage{}, err
+	}
+
+	usage := QuotaUsage{
+		Used:      res[1],
+		Remaining: max(q.limit-res[1], 0),
+		ResetAt:   time.UnixMilli(res[2]),
+	}
+	if res[0] == 0 {
+		q.logger.Debug(ctx, "Quota exhausted: %s", q.name)
+	}
+	return res[0] == 1, usage, nil
+}
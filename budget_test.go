@@ -0,0 +1,49 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuota(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-quota:"))
+	ctx := context.Background()
+
+	t.Run("budget is consumed across instances", func(t *testing.T) {
+		quota := client.NewQuota("test-emails", 10, 24*time.Hour)
+		quota.Reset(ctx)
+
+		if ok, usage, err := quota.Consume(ctx, 7); err != nil || !ok || usage.Remaining != 3 {
+			t.Fatalf("Expected 7 consumed and 3 left, got: %v, %+v, %v", ok, usage, err)
+		}
+		if ok, usage, err := client.NewQuota("test-emails", 10, 24*time.Hour).Consume(ctx, 4); err != nil || ok || usage.Used != 7 {
+			t.Fatalf("Consuming more than the rest should fail without consuming, got: %v, %+v, %v", ok, usage, err)
+		}
+
+		usage, err := quota.Remaining(ctx)
+		if err != nil || usage.Remaining != 3 {
+			t.Fatalf("Expected 3 left, got: %+v, %v", usage, err)
+		}
+		if want := time.Now().Truncate(24 * time.Hour).Add(24 * time.Hour); !usage.ResetAt.Equal(want) {
+			t.Errorf("Daily window should reset at midnight UTC %v, got %v", want, usage.ResetAt)
+		}
+	})
+
+	t.Run("window rolls over", func(t *testing.T) {
+		quota := client.NewQuota("test-rollover", 1, 200*time.Millisecond)
+		quota.Reset(ctx)
+
+		ok, usage, err := quota.Consume(ctx, 1)
+		if err != nil || !ok {
+			t.Fatalf("Failed to consume: %v, %v", ok, err)
+		}
+		time.Sleep(time.Until(usage.ResetAt) + 10*time.Millisecond)
+		if ok, _, err := quota.Consume(ctx, 1); err != nil || !ok {
+			t.Errorf("Budget should be restored in the next window, got: %v, %v", ok, err)
+		}
+	})
+}
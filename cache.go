@@ -0,0 +1,125 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// maxCachedStates bounds the number of lock states kept by the state cache
+	maxCachedStates = 10000
+
+	// cacheWatchTimeout bounds subscribing the state cache to lock changes, and
+	// cacheWatchRetry is how long IsLocked waits before subscribing again after a failure
+	cacheWatchTimeout = 5 * time.Second
+	cacheWatchRetry   = 5 * time.Second
+)
+
+// stateCache keeps recently read lock states, invalidated through the notifier
+type stateCache struct {
+	maxStaleness time.Duration
+
+	// watching is set once the cache is subscribed to lock changes, a failed attempt
+	// is retried by IsLocked from retryAt on
+	watchMu  sync.Mutex
+	watching bool
+	retryAt  time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedState
+}
+
+type cachedState struct {
+	locked  bool
+	fetched time.Time
+}
+
+// WithStateCache enables a local cache for IsLocked. Cached states are served for at
+// most maxStaleness and dropped as soon as a lock change is published over pub/sub,
+// which cuts Redis reads for very hot checks such as routing decisions.
+func WithStateCache(maxStaleness time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cache = &stateCache{maxStaleness: maxStaleness, entries: make(map[string]cachedState)}
+	}
+}
+
+// IsLocked reports whether the named lock is currently held by anyone
+func (c *Client) IsLocked(ctx context.Context, name string) (bool, error) {
+	if routed := c.route(name); routed != c {
+		return routed.IsLocked(ctx, name)
+	}
+
+	key := c.lockKey(name)
+	if c.cache == nil {
+		return c.isLocked(ctx, key)
+	}
+
+	c.cache.watch(c)
+	if locked, ok := c.cache.get(key); ok {
+		return locked, nil
+	}
+
+	locked, err := c.isLocked(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	c.cache.put(key, locked)
+	return locked, nil
+}
+
+func (c *Client) isLocked(ctx context.Context, key string) (bool, error) {
+	n, err := replyInt(c.do(ctx, "EXISTS", key))
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// watch subscribes the cache to lock changes unless it is subscribed. The subscription
+// lives as long as the client rather than the IsLocked call opening it. Without it the
+// cache still honors maxStaleness.
+func (s *stateCache) watch(c *Client) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	if s.watching || time.Now().Before(s.retryAt) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWatchTimeout)
+	defer cancel()
+	if _, err := c.store.Watch(ctx, s.invalidate); err != nil {
+		c.logger.Warn(ctx, "State cache runs without invalidation, error: %v", err)
+		s.retryAt = time.Now().Add(cacheWatchRetry)
+		return
+	}
+	s.watching = true
+}
+
+func (s *stateCache) get(key string) (bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Since(entry.fetched) > s.maxStaleness {
+		return false, false
+	}
+	return entry.locked, true
+}
+
+func (s *stateCache) put(key string, locked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) >= maxCachedStates {
+		s.entries = make(map[string]cachedState)
+	}
+	s.entries[key] = cachedState{locked: locked, fetched: time.Now()}
+}
+
+func (s *stateCache) invalidate(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
@@ -0,0 +1,134 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsLocked(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	ctx := context.Background()
+
+	t.Run("uncached", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-islocked:"))
+		lock := client.NewLock("test-uncached")
+
+		if locked, err := client.IsLocked(ctx, "test-uncached"); err != nil || locked {
+			t.Fatalf("Lock should be free, got: %v, %v", locked, err)
+		}
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		if locked, err := client.IsLocked(ctx, "test-uncached"); err != nil || !locked {
+			t.Fatalf("Lock should be held, got: %v, %v", locked, err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+	})
+
+	t.Run("cache invalidated by lock changes", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-islocked:"), WithStateCache(time.Hour))
+		defer client.Close()
+		lock := client.NewLock("test-cached")
+
+		if locked, err := client.IsLocked(ctx, "test-cached"); err != nil || locked {
+			t.Fatalf("Lock should be free, got: %v, %v", locked, err)
+		}
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		waitFor(t, func() bool {
+			locked, err := client.IsLocked(ctx, "test-cached")
+			return err == nil && locked
+		})
+
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+
+		waitFor(t, func() bool {
+			locked, err := client.IsLocked(ctx, "test-cached")
+			return err == nil && !locked
+		})
+	})
+
+	t.Run("bounded staleness", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-islocked:"), WithStateCache(200*time.Millisecond))
+		defer client.Close()
+		key := client.lockKey("test-stale")
+
+		if locked, err := client.IsLocked(ctx, "test-stale"); err != nil || locked {
+			t.Fatalf("Lock should be free, got: %v, %v", locked, err)
+		}
+
+		// Writes that bypass the lock scripts are not published
+		redisClient.HSet(ctx, key, "owner", "someone")
+		defer redisClient.Del(ctx, key)
+
+		if locked, _ := client.IsLocked(ctx, "test-stale"); locked {
+			t.Fatal("Cached state should be served within max staleness")
+		}
+
+		time.Sleep(250 * time.Millisecond)
+		if locked, err := client.IsLocked(ctx, "test-stale"); err != nil || !locked {
+			t.Fatalf("Stale state should be refetched, got: %v, %v", locked, err)
+		}
+	})
+}
+
+// waitFor polls cond for up to a second
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStateCacheResubscribe(t *testing.T) {
+	executor := &fakeExecutor{
+		commands:     map[string]interface{}{"EXISTS": int64(1)},
+		calls:        make(map[string]int),
+		subscribeErr: errors.New("subscribe failed"),
+	}
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithExecutor(executor), WithStateCache(time.Hour))
+	defer client.Close()
+
+	// A cancelled context of the first caller does not keep the cache unsubscribed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.IsLocked(ctx, "test-cached"); err != nil {
+		t.Fatalf("Failed to check lock: %v", err)
+	}
+	if executor.subscriber != nil {
+		t.Fatal("Subscription should have failed")
+	}
+
+	executor.subscribeErr = nil
+	client.cache.retryAt = time.Time{}
+	if _, err := client.IsLocked(context.Background(), "test-cached"); err != nil {
+		t.Fatalf("Failed to check lock: %v", err)
+	}
+	if executor.subscriber == nil {
+		t.Fatal("Cache should subscribe again after a failure")
+	}
+
+	// Invalidated states are read again
+	executor.subscriber(client.lockKey("test-cached"))
+	if _, err := client.IsLocked(context.Background(), "test-cached"); err != nil {
+		t.Fatalf("Failed to check lock: %v", err)
+	}
+	if n := executor.calls["EXISTS"]; n != 2 {
+		t.Errorf("Expected the invalidated state to be read again, got %d reads", n)
+	}
+}
@@ -0,0 +1,148 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Capabilities reports what the Redis of a client supports
+type Capabilities struct {
+	// Version is the server version, e.g. "7.2.4", empty if it could not be detected
+	Version string
+	Major   int
+	Minor   int
+
+	// Functions reports support for Redis Functions (Redis 7.0 and later)
+	Functions bool
+
+	// RESP3 reports whether the client talks RESP3 to the server
+	RESP3 bool
+
+	// KeyspaceNotifications reports whether keyspace or keyevent notifications are
+	// configured, false when CONFIG GET is not permitted
+	KeyspaceNotifications bool
+
+	// Modules lists the names of the loaded modules
+	Modules []string
+}
+
+// AtLeast reports whether the server version is at least major.minor
+func (c Capabilities) AtLeast(major, minor int) bool {
+	return c.Major > major || (c.Major == major && c.Minor >= minor)
+}
+
+// capabilityCache remembers the capabilities of a client once detected
+type capabilityCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+}
+
+// Capabilities detects the version, protocol and optional features of the Redis of the
+// client on first use and returns the cached report afterwards, so optional subsystems
+// can be enabled up front instead of failing at first use. Connection errors are
+// returned and not cached.
+func (c *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	c.capabilities.mu.Lock()
+	defer c.capabilities.mu.Unlock()
+
+	if c.capabilities.caps != nil {
+		return *c.capabilities.caps, nil
+	}
+
+	caps, err := c.detectCapabilities(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	c.logger.Debug(ctx, "Detected Redis capabilities: %+v", caps)
+	c.capabilities.caps = &caps
+	return caps, nil
+}
+
+func (c *Client) detectCapabilities(ctx context.Context) (Capabilities, error) {
+	var caps Capabilities
+
+	// HELLO with the protocol the connection already speaks reports it without switching.
+	// Other executors are asked without one, which keeps the protocol as well.
+	hello := []interface{}{"HELLO"}
+	if e, ok := c.executor.(*goRedisExecutor); ok {
+		protocol := redisProtocol(e.redis)
+		if protocol == 0 {
+			protocol = 3
+		}
+		hello = append(hello, protocol)
+	}
+	reply, err := c.do(ctx, hello...)
+	switch {
+	case err == nil:
+		fields := replyMap(reply)
+		caps.Version, _ = fields["version"].(string)
+		proto, _ := fields["proto"].(int64)
+		caps.RESP3 = proto == 3
+		if modules, ok := fields["modules"].([]interface{}); ok {
+			for _, module := range modules {
+				if name, ok := replyMap(module)["name"].(string); ok {
+					caps.Modules = append(caps.Modules, name)
+				}
+			}
+		}
+	case isRedisError(err):
+		// Servers before 6.0 only report their version through INFO
+		info, err := c.do(ctx, "INFO", "server")
+		if err != nil && !isRedisError(err) {
+			return caps, err
+		}
+		text, _ := info.(string)
+		caps.Version = infoField(text, "redis_version")
+	default:
+		return caps, err
+	}
+
+	fmt.Sscanf(caps.Version, "%d.%d", &caps.Major, &caps.Minor)
+	caps.Functions = caps.AtLeast(7, 0)
+
+	config, err := replyFields(c.do(ctx, "CONFIG", "GET", "notify-keyspace-events"))
+	if err != nil && !isRedisError(err) {
+		return caps, err
+	}
+	events := config["notify-keyspace-events"]
+	caps.KeyspaceNotifications = strings.ContainsAny(events, "KE")
+
+	return caps, nil
+}
+
+// replyMap returns the fields of a map reply, sent as a map in RESP3 and a flat array in RESP2
+func replyMap(reply interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	switch reply := reply.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range reply {
+			if key, ok := k.(string); ok {
+				fields[key] = v
+			}
+		}
+	case map[string]interface{}:
+		for k, v := range reply {
+			fields[k] = v
+		}
+	case []interface{}:
+		for i := 0; i+1 < len(reply); i += 2 {
+			if key, ok := reply[i].(string); ok {
+				fields[key] = reply[i+1]
+			}
+		}
+	}
+	return fields
+}
+
+// infoField returns a field of an INFO reply
+func infoField(info, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), field+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient)
+	caps, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to detect capabilities: %v", err)
+	}
+
+	if caps.Version == "" || caps.Major == 0 {
+		t.Fatalf("Version should be detected: %+v", caps)
+	}
+	if caps.Functions != caps.AtLeast(7, 0) {
+		t.Errorf("Functions should follow the version: %+v", caps)
+	}
+}
+
+func TestCapabilityParsing(t *testing.T) {
+	resp2 := replyMap([]interface{}{"server", "redis", "version", "7.2.4", "proto", int64(2)})
+	resp3 := replyMap(map[interface{}]interface{}{"server": "redis", "version": "7.2.4", "proto": int64(3)})
+	for _, fields := range []map[string]interface{}{resp2, resp3} {
+		if fields["version"] != "7.2.4" {
+			t.Errorf("Unexpected fields: %v", fields)
+		}
+	}
+
+	info := "# Server\r\nredis_version:5.0.14\r\nredis_mode:standalone\r\n"
+	if got := infoField(info, "redis_version"); got != "5.0.14" {
+		t.Errorf("Unexpected version: %q", got)
+	}
+
+	caps := Capabilities{Major: 6, Minor: 2}
+	if !caps.AtLeast(6, 0) || !caps.AtLeast(5, 9) || caps.AtLeast(6, 3) || caps.AtLeast(7, 0) {
+		t.Errorf("Unexpected version comparison for %+v", caps)
+	}
+}
@@ -0,0 +1,109 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"sync"
+)
+
+// cardinalityGuard tracks the distinct lock names created by a client
+type cardinalityGuard struct {
+	limit    int
+	patterns []string
+	buckets  int
+
+	mu       sync.Mutex
+	names    map[string]struct{}
+	exceeded bool
+
+	// coalesced holds the distinct names mapped onto a bucket, counted once each
+	coalesced map[string]struct{}
+}
+
+// maxCoalescedNames bounds the names remembered for MetricLockNamesCoalesced
+const maxCoalescedNames = 1 << 16
+
+// WithCardinalityLimit warns once the client has created more than limit distinct lock names.
+// Unbounded lock names usually indicate a bug and bloat Redis. Names beyond the limit are
+// counted in MetricLockCardinalityExceeded but no longer remembered, bounding memory usage.
+func WithCardinalityLimit(limit int) ClientOption {
+	return func(c *Client) {
+		c.cardinality.limit = limit
+	}
+}
+
+// WithNameCoalescing maps lock names matching any pattern onto one of buckets locks
+// chosen by hashing the name, bounding the number of keys a name family can create.
+// Distinct names may then share a lock, which is coarser but never less exclusive.
+// Every process sharing these locks must use the same patterns and bucket count.
+func WithNameCoalescing(buckets int, patterns ...string) ClientOption {
+	return func(c *Client) {
+		c.cardinality.buckets = buckets
+		c.cardinality.patterns = append(c.cardinality.patterns, patterns...)
+	}
+}
+
+// track records name and reports it to the logger and metrics when the limit is crossed
+func (g *cardinalityGuard) track(ctx context.Context, c *Client, name string) {
+	if g.limit <= 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.names == nil {
+		g.names = make(map[string]struct{})
+	}
+	if _, ok := g.names[name]; ok {
+		return
+	}
+
+	if len(g.names) >= g.limit {
+		if !g.exceeded {
+			g.exceeded = true
+			c.logger.Warn(ctx, "Distinct lock names exceeded limit %d, latest: %s", g.limit, name)
+		}
+		c.metrics.IncCounter(MetricLockCardinalityExceeded, 1)
+		return
+	}
+
+	g.names[name] = struct{}{}
+	c.metrics.SetGauge(MetricDistinctLockNames, float64(len(g.names)))
+}
+
+// coalesce returns the name the lock is stored under
+func (g *cardinalityGuard) coalesce(c *Client, name string) string {
+	if g.buckets <= 0 {
+		return name
+	}
+
+	for _, pattern := range g.patterns {
+		if ok, _ := path.Match(pattern, name); !ok {
+			continue
+		}
+
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		g.countCoalesced(c, name, pattern)
+		return fmt.Sprintf("%s#%d", pattern, h.Sum32()%uint32(g.buckets))
+	}
+	return name
+}
+
+// countCoalesced counts name in MetricLockNamesCoalesced the first time it is coalesced
+func (g *cardinalityGuard) countCoalesced(c *Client, name, pattern string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.coalesced == nil {
+		g.coalesced = make(map[string]struct{})
+	}
+	if _, ok := g.coalesced[name]; ok || len(g.coalesced) >= maxCoalescedNames {
+		return
+	}
+	g.coalesced[name] = struct{}{}
+	c.metrics.IncCounter(MetricLockNamesCoalesced, 1, "pattern", pattern)
+}
@@ -0,0 +1,129 @@
+package arbiter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingMetrics struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	gauges   map[string]float64
+	observed map[string]int
+	labels   map[string][]string
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		counters: make(map[string]int64),
+		gauges:   make(map[string]float64),
+		observed: make(map[string]int),
+		labels:   make(map[string][]string),
+	}
+}
+
+func (m *recordingMetrics) IncCounter(name string, delta int64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += delta
+	m.labels[name] = labels
+}
+
+func (m *recordingMetrics) SetGauge(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name] = value
+}
+
+func (m *recordingMetrics) Observe(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observed[name]++
+}
+
+func (m *recordingMetrics) observations(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.observed[name]
+}
+
+func TestCardinalityGuard(t *testing.T) {
+	t.Run("limit crossed", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		client := NewClient(nil,
+			WithLogger(&NoopLogger{}),
+			WithMetrics(metrics),
+			WithCardinalityLimit(2),
+		)
+
+		client.NewLock("a")
+		client.NewLock("b")
+		client.NewLock("a")
+		client.NewLock("c")
+		client.NewLock("d")
+
+		if got := metrics.gauges[MetricDistinctLockNames]; got != 2 {
+			t.Errorf("distinct names = %v, want 2", got)
+		}
+		if got := metrics.counters[MetricLockCardinalityExceeded]; got != 2 {
+			t.Errorf("exceeded = %v, want 2", got)
+		}
+	})
+
+	t.Run("coalescing", func(t *testing.T) {
+		client := NewClient(nil,
+			WithLogger(&NoopLogger{}),
+			WithNameCoalescing(4, "session:*"),
+		)
+
+		if key := client.lockKey("orders:1"); key != defaultKeyPrefix+"orders:1" {
+			t.Errorf("unmatched name should keep its key, got %s", key)
+		}
+
+		buckets := make(map[string]struct{})
+		for _, name := range []string{"session:1", "session:2", "session:3", "session:4", "session:5", "session:6"} {
+			key := client.lockKey(name)
+			if !strings.HasPrefix(key, defaultKeyPrefix+"session:*#") {
+				t.Fatalf("unexpected coalesced key %s", key)
+			}
+			if key != client.lockKey(name) {
+				t.Fatalf("coalescing of %s is not deterministic", name)
+			}
+			buckets[key] = struct{}{}
+		}
+		if len(buckets) > 4 {
+			t.Errorf("names spread over %d buckets, want at most 4", len(buckets))
+		}
+	})
+
+	t.Run("coalesced metrics", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		client := NewClient(nil,
+			WithLogger(&NoopLogger{}),
+			WithMetrics(metrics),
+			WithStore(newMemoryStore()),
+			WithNameCoalescing(4, "session:*"),
+		)
+
+		// Every distinct name counts once, however often its key is derived
+		for i := 0; i < 3; i++ {
+			client.lockKey("session:1")
+			client.lockKey("session:2")
+		}
+		if got := metrics.counters[MetricLockNamesCoalesced]; got != 2 {
+			t.Errorf("coalesced names = %v, want 2", got)
+		}
+
+		lock := client.NewLock("session:1")
+		if err := lock.Lock(context.Background()); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer lock.Unlock(context.Background())
+		labels := metrics.labels[MetricLockAcquisitions]
+		if len(labels) != 2 || labels[0] != LabelLock || !strings.HasPrefix(labels[1], "session:*#") {
+			t.Errorf("acquisitions should be labelled by bucket, got %v", labels)
+		}
+	})
+}
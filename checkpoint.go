@@ -0,0 +1,83 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+)
+
+// Checkpoint refreshes the lease of the lock running a Do callback and verifies in the
+// same round trip that it is still the owner. It returns ErrLockLost once the lock was
+// lost, after which the callback should abort without touching the protected resource.
+type Checkpoint func(ctx context.Context) error
+
+func (l *lockImpl) Do(ctx context.Context, fn func(ctx context.Context, checkpoint Checkpoint) error) error {
+	if err := l.Lock(ctx); err != nil {
+		return err
+	}
+	if outer := l.reentered(); outer != nil {
+		// The outer holder keeps the lease, checkpoints refresh it
+		panicked, fnErr := protect(func() error { return fn(ctx, outer.checkpointRefresh) })
+		l.Unlock(context.WithoutCancel(ctx))
+		if panicked != nil {
+			return l.options.handlePanic(ctx, panicked)
+		}
+		return fnErr
+	}
+
+	g, fnCtx := l.Group(ctx)
+	fnCtx = ContextWithLock(fnCtx, l)
+
+	// Without a watchdog each step has until the lease lapses to reach the next checkpoint
+	var expired *time.Timer
+	if !l.options.EnableWatchDog && !l.options.Permanent {
+		expired = time.AfterFunc(time.Duration(l.lease.Load()), func() { g.cancel(ErrLockLost) })
+	}
+
+	checkpoint := func(ctx context.Context) error {
+		if context.Cause(fnCtx) == ErrLockLost {
+			return ErrLockLost
+		}
+
+		err := l.Refresh(ctx)
+		if err == ErrLockNotHeld || err == ErrLockReacquired {
+			l.logger.Warn(ctx, "Checkpoint found lock lost: %s", l.key)
+			g.cancel(ErrLockLost)
+			return ErrLockLost
+		}
+		if err != nil {
+			return err
+		}
+
+		if expired != nil {
+			expired.Reset(time.Duration(l.lease.Load()))
+		}
+		return nil
+	}
+
+	panicked, fnErr := protect(func() error { return fn(fnCtx, checkpoint) })
+	if expired != nil {
+		expired.Stop()
+	}
+	lost := context.Cause(fnCtx) == ErrLockLost
+
+	err := l.Unlock(context.WithoutCancel(ctx))
+	switch {
+	case panicked != nil:
+		return l.options.handlePanic(ctx, panicked)
+	case fnErr != nil:
+		return fnErr
+	case lost:
+		return ErrLockLost
+	}
+	return err
+}
+
+// checkpointRefresh is the checkpoint of a Do callback re-entering l through its
+// context, reporting ErrLockLost once l was lost
+func (l *lockImpl) checkpointRefresh(ctx context.Context) error {
+	err := l.Refresh(ctx)
+	if err == ErrLockNotHeld || err == ErrLockReacquired {
+		return ErrLockLost
+	}
+	return err
+}
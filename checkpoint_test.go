@@ -0,0 +1,162 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDoCheckpoint(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-checkpoint:"))
+	ctx := context.Background()
+
+	t.Run("checkpoints extend the lease", func(t *testing.T) {
+		lock := client.NewLock("test-steps", WithLeaseTime(time.Second))
+		steps := 0
+		err := lock.Do(ctx, func(ctx context.Context, checkpoint Checkpoint) error {
+			for i := 0; i < 3; i++ {
+				if err := checkpoint(ctx); err != nil {
+					return err
+				}
+				if ttl := redisClient.PTTL(ctx, client.lockKey("test-steps")).Val(); ttl <= 900*time.Millisecond {
+					t.Errorf("Checkpoint should renew the lease, got TTL: %v", ttl)
+				}
+				steps++
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+		if steps != 3 {
+			t.Errorf("Expected 3 steps, got: %d", steps)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-steps"); locked {
+			t.Fatal("Do should release the lock")
+		}
+	})
+
+	t.Run("callback error is returned", func(t *testing.T) {
+		failure := errors.New("step failed")
+		err := client.NewLock("test-failure").Do(ctx, func(context.Context, Checkpoint) error { return failure })
+		if err != failure {
+			t.Fatalf("Expected callback error, got: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-failure"); locked {
+			t.Fatal("Do should release the lock after a failure")
+		}
+	})
+
+	t.Run("checkpoint detects lost lock", func(t *testing.T) {
+		lock := client.NewLock("test-lost")
+		err := lock.Do(ctx, func(ctx context.Context, checkpoint Checkpoint) error {
+			if err := client.Admin().ForceUnlock(ctx, "test-lost"); err != nil {
+				t.Fatalf("Failed to force unlock: %v", err)
+			}
+			if err := checkpoint(ctx); err != ErrLockLost {
+				t.Fatalf("Expected lost error, got: %v", err)
+			}
+			if context.Cause(ctx) != ErrLockLost {
+				t.Errorf("Expected context cause to be lost error, got: %v", context.Cause(ctx))
+			}
+			return nil
+		})
+		if err != ErrLockLost {
+			t.Fatalf("Expected lost error, got: %v", err)
+		}
+	})
+
+	t.Run("lease lapse cancels the step", func(t *testing.T) {
+		lock := client.NewLock("test-lapse", WithLeaseTime(200*time.Millisecond))
+		err := lock.Do(ctx, func(ctx context.Context, checkpoint Checkpoint) error {
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+				t.Fatal("Context should be cancelled when the lease lapses")
+			}
+			if err := checkpoint(ctx); err != ErrLockLost {
+				t.Errorf("Expected lost error after lapse, got: %v", err)
+			}
+			return context.Cause(ctx)
+		})
+		if err != ErrLockLost {
+			t.Fatalf("Expected lost error, got: %v", err)
+		}
+	})
+
+	t.Run("acquisition error is returned", func(t *testing.T) {
+		holder := client.NewLock("test-busy")
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer holder.Unlock(ctx)
+
+		called := false
+		err := client.NewLock("test-busy", WithWaitTimeout(200*time.Millisecond)).Do(ctx, func(context.Context, Checkpoint) error {
+			called = true
+			return nil
+		})
+		if err != ErrLockTimeout || called {
+			t.Fatalf("Expected timeout without running fn, got: %v, called: %v", err, called)
+		}
+	})
+}
+
+func TestDoPanic(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-panic:"))
+	ctx := context.Background()
+	crash := func(ctx context.Context, checkpoint Checkpoint) error { panic("boom") }
+
+	t.Run("panics again after unlock by default", func(t *testing.T) {
+		defer func() {
+			if value := recover(); value != "boom" {
+				t.Errorf("Expected the panic to propagate, got: %v", value)
+			}
+			if locked, _ := client.IsLocked(ctx, "test-repanic"); locked {
+				t.Error("Do should release the lock before panicking again")
+			}
+		}()
+		client.NewLock("test-repanic").Do(ctx, crash)
+		t.Error("Do should panic")
+	})
+
+	t.Run("converts the panic to an error", func(t *testing.T) {
+		err := client.NewLock("test-error", WithPanicPolicy(PanicError)).Do(ctx, crash)
+		if !errors.Is(err, ErrPanicked) || !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("Expected panic error, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "goroutine") {
+			t.Errorf("Error should carry the stack, got: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-error"); locked {
+			t.Error("Do should release the lock")
+		}
+	})
+
+	t.Run("custom handler decides", func(t *testing.T) {
+		handled := errors.New("handled")
+		var got interface{}
+		lock := client.NewMultiLock([]string{"test-a", "test-b"}, WithPanicHandler(
+			func(ctx context.Context, value interface{}, stack []byte) error {
+				got = value
+				if locked, _ := client.IsLocked(ctx, "test-a"); locked {
+					t.Error("Handler should run after the lock was released")
+				}
+				return handled
+			}))
+		if err := lock.Do(ctx, crash); err != handled {
+			t.Fatalf("Expected the error of the handler, got: %v", err)
+		}
+		if got != "boom" {
+			t.Errorf("Handler should get the panic value, got: %v", got)
+		}
+	})
+}
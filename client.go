@@ -1,22 +1,63 @@
 package arbiter
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 const (
 	defaultKeyPrefix = "arbiter:"
+
+	// reservedPrefix starts the names of keys used internally by arbiter.
+	// Lock names containing it are rejected so they never collide.
+	reservedPrefix = "__arbiter__:"
 )
 
 // Client represents a distributed lock client
 type Client struct {
-	redis  *redis.Client
-	logger Logger
-	prefix string
+	redis   redis.UniversalClient
+	logger  Logger
+	prefix  string
+	hashTag bool
+	policy  namePolicy
+
+	metrics     Metrics
+	cardinality cardinalityGuard
+
+	notifier *notifier
+	releases *notifier
+	store    Store
+	executor RedisExecutor
+	cache    *stateCache
+	sinks    []EventSink
+
+	authorizer Authorizer
+
+	namespace string
+	quotas    map[string]NamespaceQuota
+	opts      []ClientOption
+	ownsRedis bool
+
+	routes   []*lockRoute
+	role     roleGuard
+	handoff  *handoff
+	latency  *latencyStats
+	brownout *brownout
+
+	capabilities capabilityCache
+	compression  compression
+	gcPolicy     GCPolicy
+	tombstones   time.Duration
+
+	warmupSubscription bool
+	functions          bool
+
+	redLock        []redis.UniversalClient
+	redLockClients []*Client
 }
 
 // ClientOption is a function type for setting client options
@@ -37,20 +78,64 @@ func WithKeyPrefix(prefix string) ClientOption {
 }
 
 // NewClient creates a new distributed lock client
-func NewClient(redis *redis.Client, opts ...ClientOption) *Client {
+func NewClient(redis redis.UniversalClient, opts ...ClientOption) *Client {
 	c := &Client{
-		redis:  redis,
-		logger: newDefaultLogger(),
-		prefix: defaultKeyPrefix,
+		redis:   redis,
+		logger:  newDefaultLogger(),
+		prefix:  defaultKeyPrefix,
+		metrics: &NoopMetrics{},
+		latency: &latencyStats{},
+		opts:    opts,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	c.notifier = newNotifier(c, c.eventsChannel(), c.logger)
+	c.releases = newNotifier(c, c.releasesChannel(), c.logger)
+	if c.executor == nil && c.redis != nil {
+		c.executor = &goRedisExecutor{redis: c.redis}
+	}
+	if c.store == nil {
+		c.store = &redisStore{client: c}
+	}
+	c.checkRouting()
+	c.initRoutes()
+	c.initRedLock()
+
+	for _, pattern := range c.policy.invalid() {
+		c.logger.Warn(context.Background(), "Ignoring malformed lock name pattern: %s", pattern)
+	}
+
 	return c
 }
 
+// Close releases the resources held by the client. The Redis client is left open
+// unless the client was created by Open.
+func (c *Client) Close() error {
+	err := c.notifier.close()
+	if cerr := c.releases.close(); err == nil {
+		err = cerr
+	}
+	for _, route := range c.routes {
+		if cerr := route.client.Close(); err == nil {
+			err = cerr
+		}
+	}
+	for _, instance := range c.redLockClients {
+		if cerr := instance.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if c.ownsRedis {
+		if cerr := c.redis.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 // NewLock creates a new distributed lock instance
 func (c *Client) NewLock(name string, opts ...Option) Lock {
 	options := defaultOptions()
@@ -58,15 +143,46 @@ func (c *Client) NewLock(name string, opts ...Option) Lock {
 		opt(options)
 	}
 
-	return newLock(c.redis, fmt.Sprintf("%s%s", c.prefix, name), options, c.logger)
+	c.cardinality.track(context.Background(), c, name)
+	return newLock(c.route(name), name, options)
 }
 
-// generateValue generates a random string as lock value
-func generateValue() string {
-	b := make([]byte, 16)
-	_, err := rand.Read(b)
-	if err != nil {
-		panic(err) // This should never happen
+// key returns the Redis key for the given lock name
+func (c *Client) key(name string) string {
+	return fmt.Sprintf("%s%s", c.prefix, name)
+}
+
+// internalKey returns the Redis key of an internal structure that no lock name can reach
+func (c *Client) internalKey(name string) string {
+	return c.key(reservedPrefix + name)
+}
+
+// lockKey returns the Redis key a lock name is stored under
+func (c *Client) lockKey(name string) string {
+	name = c.cardinality.coalesce(c, name)
+	if c.hashTag {
+		return c.key("{" + name + "}")
 	}
-	return base64.StdEncoding.EncodeToString(b)
+	return c.key(name)
+}
+
+// frozenKey returns the Redis key of the set of exactly frozen lock names seen by lockKey
+func (c *Client) frozenKey(lockKey string) string {
+	return c.slotKey(lockKey, "frozen")
+}
+
+// frozenPrefixKey returns the Redis key of the set of frozen name prefix patterns seen by lockKey
+func (c *Client) frozenPrefixKey(lockKey string) string {
+	return c.slotKey(lockKey, "frozen-prefixes")
+}
+
+// fenceKey returns the Redis key of the fencing counter of a lock key.
+// Counters have no TTL so tokens keep increasing across acquisitions.
+func (c *Client) fenceKey(lockKey string) string {
+	return c.internalKey("fence:" + strings.TrimPrefix(lockKey, c.prefix))
+}
+
+// generateValue generates a random token as lock value
+func generateValue() string {
+	return NewToken().String()
 }
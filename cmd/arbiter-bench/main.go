@@ -0,0 +1,85 @@
+// Command arbiter-bench drives a contention scenario against a backend opened by URL
+// and prints throughput, fairness and lost locks. Run it from several processes at
+// once with the same flags to contend across processes.
+//
+// Usage:
+//
+//	arbiter-bench -dsn redis://localhost:6379/0 -workers 32 -locks 4 -hold 10ms -duration 30s
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/huimingz/arbiter"
+	"github.com/huimingz/arbiter/arbiterbench"
+)
+
+func main() {
+	var (
+		dsn      = flag.String("dsn", "redis://localhost:6379/0", "data source name of the backend")
+		workers  = flag.Int("workers", 8, "number of concurrent workers")
+		locks    = flag.Int("locks", 1, "number of lock names the workers compete for")
+		hold     = flag.Duration("hold", 0, "how long a lock is held once acquired")
+		pause    = flag.Duration("pause", 0, "how long a worker pauses between acquisitions")
+		duration = flag.Duration("duration", 10*time.Second, "how long the scenario runs")
+		wait     = flag.Duration("wait", 0, "wait timeout of every acquisition, backend default when 0")
+		lease    = flag.Duration("lease", 0, "lease time of every lock, backend default when 0")
+		prefix   = flag.String("prefix", "arbiter-bench:", "prefix of the lock names")
+		asJSON   = flag.Bool("json", false, "print the report as JSON")
+	)
+	flag.Parse()
+
+	if err := run(*dsn, *asJSON, arbiterbench.Scenario{
+		Workers:     *workers,
+		Locks:       *locks,
+		Hold:        *hold,
+		Pause:       *pause,
+		Duration:    *duration,
+		Prefix:      *prefix,
+		LockOptions: lockOptions(*wait, *lease),
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "arbiter-bench:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dsn string, asJSON bool, scenario arbiterbench.Scenario) error {
+	locker, err := arbiter.Open(dsn)
+	if err != nil {
+		return err
+	}
+	defer locker.Close()
+
+	// Interrupting ends the run early and still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := arbiterbench.Run(ctx, locker, scenario)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.Print(os.Stdout)
+}
+
+func lockOptions(wait, lease time.Duration) []arbiter.Option {
+	var opts []arbiter.Option
+	if wait > 0 {
+		opts = append(opts, arbiter.WithWaitTimeout(wait))
+	}
+	if lease > 0 {
+		opts = append(opts, arbiter.WithLeaseTime(lease))
+	}
+	return opts
+}
@@ -0,0 +1,30 @@
+// Command arbiter-dashboard prints a Grafana dashboard JSON model for the metrics
+// emitted by arbiter clients.
+//
+// Usage:
+//
+//	arbiter-dashboard -datasource prometheus -selector 'job="orders"' > arbiter.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/huimingz/arbiter"
+)
+
+func main() {
+	var opts arbiter.DashboardOptions
+	flag.StringVar(&opts.Title, "title", "Arbiter", "dashboard title")
+	flag.StringVar(&opts.Datasource, "datasource", "", "UID of the Prometheus datasource, the default datasource when empty")
+	flag.StringVar(&opts.Selector, "selector", "", `label matchers added to every query, e.g. job="orders"`)
+	flag.Parse()
+
+	data, err := arbiter.GrafanaDashboard(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "arbiter-dashboard:", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(append(data, '\n'))
+}
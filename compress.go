@@ -0,0 +1,119 @@
+package arbiter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+)
+
+// compressedMarker starts stored values that are compressed. It is followed by the
+// compressor name, another marker and the compressed payload.
+const compressedMarker = "\x00"
+
+// Compressor compresses large metadata values, e.g. an adapter for snappy or zstd
+type Compressor interface {
+	// Name identifies the compressor in stored values, so readers can decompress them
+	Name() string
+
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// compression compresses values stored with locks beyond a size threshold
+type compression struct {
+	compressor Compressor
+	threshold  int
+
+	// decoders are the compressors able to read stored values by name
+	decoders map[string]Compressor
+}
+
+// WithCompression compresses annotation values longer than threshold bytes with
+// compressor, keeping Redis memory predictable for metadata heavy users. Compressed values
+// are flagged so they are decompressed transparently when read. Gzip values can always be
+// read, values of other compressors need the compressor configured on the reader too.
+func WithCompression(compressor Compressor, threshold int) ClientOption {
+	return func(c *Client) {
+		if c.compression.decoders == nil {
+			c.compression.decoders = make(map[string]Compressor)
+		}
+		c.compression.compressor = compressor
+		c.compression.threshold = threshold
+		c.compression.decoders[compressor.Name()] = compressor
+	}
+}
+
+// GzipCompressor returns a Compressor using gzip from the standard library
+func GzipCompressor() Compressor {
+	return gzipCompressor{}
+}
+
+// encodeValue compresses value if it exceeds the threshold of the client
+func (c *Client) encodeValue(ctx context.Context, value string) string {
+	z := c.compression
+	if z.compressor == nil || len(value) <= z.threshold {
+		return value
+	}
+
+	data, err := z.compressor.Compress([]byte(value))
+	if err != nil {
+		c.logger.Warn(ctx, "Storing value uncompressed, error: %v", err)
+		return value
+	}
+	return compressedMarker + z.compressor.Name() + compressedMarker + string(data)
+}
+
+// decodeValue returns a stored value, decompressing flagged values
+func (c *Client) decodeValue(ctx context.Context, value string) string {
+	rest, ok := strings.CutPrefix(value, compressedMarker)
+	if !ok {
+		return value
+	}
+	name, data, ok := strings.Cut(rest, compressedMarker)
+	if !ok {
+		return value
+	}
+
+	compressor, ok := c.compression.decoders[name]
+	if !ok && name == (gzipCompressor{}).Name() {
+		compressor, ok = gzipCompressor{}, true
+	}
+	if !ok {
+		c.logger.Warn(ctx, "Cannot decompress value compressed with %s", name)
+		return value
+	}
+
+	decoded, err := compressor.Decompress([]byte(data))
+	if err != nil {
+		c.logger.Warn(ctx, "Failed to decompress value, error: %v", err)
+		return value
+	}
+	return string(decoded)
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string { return "gzip" }
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,77 @@
+package arbiter
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// reverseCompressor reverses values, standing in for third party compressors
+type reverseCompressor struct{}
+
+func (reverseCompressor) Name() string { return "reverse" }
+
+func (reverseCompressor) Compress(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[len(data)-1-i] = b
+	}
+	return out, nil
+}
+
+func (r reverseCompressor) Decompress(data []byte) ([]byte, error) {
+	return r.Compress(data)
+}
+
+func TestCompression(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-compress:"), WithCompression(GzipCompressor(), 64))
+	ctx := context.Background()
+
+	lock := client.NewLock("test-large")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer lock.Unlock(ctx)
+
+	large := strings.Repeat("incident timeline entry; ", 100)
+	if err := client.Admin().Annotate(ctx, "test-large", "timeline", large); err != nil {
+		t.Fatalf("Failed to annotate lock: %v", err)
+	}
+	if err := client.Admin().Annotate(ctx, "test-large", "incident", "INC-1"); err != nil {
+		t.Fatalf("Failed to annotate lock: %v", err)
+	}
+
+	stored := redisClient.HGetAll(ctx, client.lockKey("test-large")).Val()
+	if len(stored[annotationFieldPrefix+"timeline"]) >= len(large) {
+		t.Error("Large value should be stored compressed")
+	}
+	if stored[annotationFieldPrefix+"incident"] != "INC-1" {
+		t.Error("Small value should be stored as is")
+	}
+
+	// Readers decompress gzip values without configuring compression
+	for _, reader := range []*Client{client, NewClient(redisClient, WithKeyPrefix("test-compress:"))} {
+		infos, err := reader.InspectLocks(ctx, []string{"test-large"})
+		if err != nil {
+			t.Fatalf("Failed to inspect lock: %v", err)
+		}
+		if infos[0].Annotations["timeline"] != large || infos[0].Annotations["incident"] != "INC-1" {
+			t.Errorf("Unexpected annotations: %v", infos[0].Annotations)
+		}
+	}
+
+	t.Run("custom compressor", func(t *testing.T) {
+		custom := NewClient(redisClient, WithKeyPrefix("test-compress:"), WithCompression(reverseCompressor{}, 8))
+		if err := custom.Admin().Annotate(ctx, "test-large", "owner", "team payments"); err != nil {
+			t.Fatalf("Failed to annotate lock: %v", err)
+		}
+
+		infos, _ := custom.InspectLocks(ctx, []string{"test-large"})
+		if infos[0].Annotations["owner"] != "team payments" || infos[0].Annotations["timeline"] != large {
+			t.Errorf("Unexpected annotations: %v", infos[0].Annotations)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// ErrLimitReached is returned by concurrency limiters whose slots are all in use
+var ErrLimitReached = errors.New("concurrency limit reached")
+
+// ConcurrencyLimiter is a bulkhead capping the operations in flight across all
+// instances sharing its name. Slots expire after their TTL unless refreshed, so the
+// slots of crashed holders are freed automatically.
+type ConcurrencyLimiter struct {
+	client *Client
+	name   string
+	key    string
+	limit  int
+	ttl    time.Duration
+	logger Logger
+}
+
+// ConcurrencySlot is a slot taken from a ConcurrencyLimiter
+type ConcurrencySlot struct {
+	limiter *ConcurrencyLimiter
+	token   string
+}
+
+// NewConcurrencyLimiter creates the concurrency limiter name allowing limit operations
+// in flight, whose slots expire after ttl unless refreshed. Every process must use the
+// same limit and TTL.
+func (c *Client) NewConcurrencyLimiter(name string, limit int, ttl time.Duration) *ConcurrencyLimiter {
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	return &ConcurrencyLimiter{
+		client: c,
+		name:   name,
+		key:    c.internalKey("concurrency:" + name),
+		limit:  limit,
+		ttl:    ttl,
+		logger: c.logger,
+	}
+}
+
+// Acquire takes a slot without waiting and returns ErrLimitReached if all are in use.
+// The caller releases the slot, and refreshes it if the operation may outlast the TTL.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (*ConcurrencySlot, error) {
+	if err := l.client.policy.check(l.name); err != nil {
+		return nil, err
+	}
+	if err := l.client.checkRole(ctx); err != nil {
+		return nil, err
+	}
+
+	token := generateValue()
+	ok, err := l.client.runScriptBool(ctx, lua.ConcurrencyAcquire, []string{l.key},
+		token, l.limit, time.Now().UnixMilli(), l.ttl.Milliseconds())
+	if err != nil {
+		l.logger.Error(ctx, "Failed to acquire slot of concurrency limiter: %s, error: %v", l.name, err)
+		return nil, err
+	}
+	if !ok {
+		l.logger.Debug(ctx, "Concurrency limit reached: %s", l.name)
+		return nil, ErrLimitReached
+	}
+	return &ConcurrencySlot{limiter: l, token: token}, nil
+}
+
+// Do runs fn in a slot and releases it afterwards, refreshing the slot every third of
+// the TTL while fn runs. It returns ErrLimitReached without running fn if all slots
+// are in use.
+func (l *ConcurrencyLimiter) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	slot, err := l.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer slot.Release(context.WithoutCancel(ctx))
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := slot.Refresh(ctx); err != nil {
+					l.logger.Warn(ctx, "Failed to refresh slot of concurrency limiter: %s, error: %v", l.name, err)
+				}
+			}
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// InFlight returns how many slots are in use
+func (l *ConcurrencyLimiter) InFlight(ctx context.Context) (int, error) {
+	n, err := replyInt(l.client.do(ctx, "ZCOUNT", l.key, strconv.FormatInt(time.Now().UnixMilli()+1, 10), "+inf"))
+	return int(n), err
+}
+
+// Release frees the slot
+func (s *ConcurrencySlot) Release(ctx context.Context) error {
+	l := s.limiter
+	if _, err := l.client.do(ctx, "ZREM", l.key, s.token); err != nil {
+		l.logger.Error(ctx, "Failed to release slot of concurrency limiter: %s, error: %v", l.name, err)
+		return err
+	}
+	return nil
+}
+
+// Refresh extends the slot by the TTL and returns ErrLockNotHeld if it had expired
+func (s *ConcurrencySlot) Refresh(ctx context.Context) error {
+	l := s.limiter
+	ok, err := l.client.runScriptBool(ctx, lua.ConcurrencyRefresh, []string{l.key},
+		s.token, l.limit, time.Now().UnixMilli(), l.ttl.Milliseconds())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
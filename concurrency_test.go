@@ -0,0 +1,75 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-concurrency:"))
+	ctx := context.Background()
+
+	t.Run("slots are capped across instances", func(t *testing.T) {
+		first := client.NewConcurrencyLimiter("test-bulkhead", 2, 10*time.Second)
+		second := client.NewConcurrencyLimiter("test-bulkhead", 2, 10*time.Second)
+		redisClient.Del(ctx, first.key)
+
+		a, err := first.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Failed to acquire slot: %v", err)
+		}
+		if _, err := second.Acquire(ctx); err != nil {
+			t.Fatalf("Failed to acquire slot: %v", err)
+		}
+		if _, err := second.Acquire(ctx); !errors.Is(err, ErrLimitReached) {
+			t.Fatalf("Expected ErrLimitReached, got: %v", err)
+		}
+		if n, _ := first.InFlight(ctx); n != 2 {
+			t.Errorf("Expected 2 in flight, got %d", n)
+		}
+
+		if err := a.Release(ctx); err != nil {
+			t.Fatalf("Failed to release slot: %v", err)
+		}
+		if _, err := second.Acquire(ctx); err != nil {
+			t.Errorf("Released slot should be free again: %v", err)
+		}
+	})
+
+	t.Run("slots of crashed holders expire", func(t *testing.T) {
+		limiter := client.NewConcurrencyLimiter("test-expiry", 1, 100*time.Millisecond)
+		redisClient.Del(ctx, limiter.key)
+
+		if _, err := limiter.Acquire(ctx); err != nil {
+			t.Fatalf("Failed to acquire slot: %v", err)
+		}
+		time.Sleep(150 * time.Millisecond)
+		if _, err := limiter.Acquire(ctx); err != nil {
+			t.Errorf("Expired slot should be free again: %v", err)
+		}
+	})
+
+	t.Run("do keeps the slot while running", func(t *testing.T) {
+		limiter := client.NewConcurrencyLimiter("test-do", 1, 150*time.Millisecond)
+		redisClient.Del(ctx, limiter.key)
+
+		err := limiter.Do(ctx, func(ctx context.Context) error {
+			time.Sleep(300 * time.Millisecond)
+			if _, err := limiter.Acquire(ctx); !errors.Is(err, ErrLimitReached) {
+				t.Errorf("Slot should be kept while running, got: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+		if n, _ := limiter.InFlight(ctx); n != 0 {
+			t.Errorf("Slot should be released after Do, %d in flight", n)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// Cond is a distributed condition variable associated with a lock, like sync.Cond
+// across processes. Waiters release the lock while they wait for a Signal or
+// Broadcast and hold it again when Wait returns, so producers and consumers
+// coordinate on state guarded by the lock.
+type Cond struct {
+	client *Client
+	name   string
+	key    string
+	lock   Lock
+	logger Logger
+}
+
+// NewCond creates the condition variable name associated with lock. Every process
+// waiting on or signalling the condition must use the same name and lock name.
+func (c *Client) NewCond(name string, lock Lock) *Cond {
+	c = c.route(name)
+	return &Cond{
+		client: c,
+		name:   name,
+		key:    c.internalKey("cond:" + name),
+		lock:   lock,
+		logger: c.logger,
+	}
+}
+
+// Wait releases the held lock, blocks until the condition is signalled, then acquires
+// the lock again. Wakeups can be spurious, so check the condition in a loop:
+//
+//	for !ready() {
+//		if err := cond.Wait(ctx); err != nil {
+//			return err
+//		}
+//	}
+//
+// Wait returns with the lock held unless it returns an error. If ctx is done while
+// waiting, it returns ctx.Err() without the lock.
+func (c *Cond) Wait(ctx context.Context) error {
+	if err := c.client.policy.check(c.name); err != nil {
+		return err
+	}
+
+	waiter := generateValue()
+	woken := make(chan struct{}, 1)
+	stopListening, err := c.client.notifier.listen(ctx, func(key string) {
+		if key != c.key && key != c.key+"#"+waiter {
+			return
+		}
+		select {
+		case woken <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer stopListening()
+
+	// Registered after subscribing, so no signal sent after the release is missed
+	if err := c.enter(ctx, waiter); err != nil {
+		return err
+	}
+	if err := c.lock.Unlock(ctx); err != nil {
+		c.leave(ctx, waiter)
+		return err
+	}
+
+	renew := time.NewTicker(waiterTTL / 2)
+	defer renew.Stop()
+	for waiting := true; waiting; {
+		select {
+		case <-ctx.Done():
+			c.leave(ctx, waiter)
+			return ctx.Err()
+		case <-woken:
+			waiting = false
+		case <-renew.C:
+			if err := c.enter(ctx, waiter); err != nil {
+				c.logger.Warn(ctx, "Failed to renew waiter of condition: %s, error: %v", c.name, err)
+			}
+		}
+	}
+
+	c.logger.Debug(ctx, "Woken on condition: %s", c.name)
+	return c.lock.Lock(ctx)
+}
+
+// Signal wakes one waiter and reports whether one was waiting. Like with sync.Cond,
+// the caller should hold the lock while changing the condition.
+func (c *Cond) Signal(ctx context.Context) (bool, error) {
+	woken, err := c.client.runScriptBool(ctx, lua.CondSignal, []string{c.key},
+		time.Now().UnixMilli(), c.client.eventsChannel())
+	if err != nil {
+		c.logger.Error(ctx, "Failed to signal condition: %s, error: %v", c.name, err)
+		return false, err
+	}
+	return woken, nil
+}
+
+// Broadcast wakes every waiter and returns how many were waiting
+func (c *Cond) Broadcast(ctx context.Context) (int, error) {
+	woken, err := replyInt(c.client.runScript(ctx, lua.CondBroadcast, []string{c.key},
+		time.Now().UnixMilli(), c.client.eventsChannel()))
+	if err != nil {
+		c.logger.Error(ctx, "Failed to broadcast condition: %s, error: %v", c.name, err)
+		return 0, err
+	}
+	return int(woken), nil
+}
+
+// enter registers or renews waiter. The entry expires unless renewed, so waiters
+// of crashed processes do not swallow signals for long.
+func (c *Cond) enter(ctx context.Context, waiter string) error {
+	expiry := time.Now().Add(waiterTTL)
+	_, err := c.client.runScript(ctx, lua.CondEnter, []string{c.key}, waiter, expiry.UnixMilli(), waiterTTL.Milliseconds())
+	return err
+}
+
+// leave removes waiter, also when ctx was cancelled
+func (c *Cond) leave(ctx context.Context, waiter string) {
+	if _, err := c.client.do(context.WithoutCancel(ctx), "ZREM", c.key, waiter); err != nil {
+		c.logger.Warn(ctx, "Failed to remove waiter of condition: %s, error: %v", c.name, err)
+	}
+}
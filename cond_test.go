@@ -0,0 +1,103 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCond(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-cond:"))
+	ctx := context.Background()
+
+	t.Run("signal wakes a waiter holding the lock again", func(t *testing.T) {
+		consumer := client.NewLock("test-queue", WithWaitTimeout(5*time.Second))
+		if err := consumer.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		cond := client.NewCond("test-ready", consumer)
+		if woken, _ := cond.Signal(ctx); woken {
+			t.Fatal("Signal without waiters should wake nobody")
+		}
+
+		waited := make(chan error, 1)
+		go func() { waited <- cond.Wait(ctx) }()
+
+		// The producer gets the lock the waiter released
+		producer := client.NewLock("test-queue", WithWaitTimeout(5*time.Second))
+		if err := producer.Lock(ctx); err != nil {
+			t.Fatalf("Producer should acquire the released lock: %v", err)
+		}
+		waitFor(t, func() bool {
+			n, _ := redisClient.ZCard(ctx, cond.key).Result()
+			return n == 1
+		})
+		if woken, err := client.NewCond("test-ready", producer).Signal(ctx); err != nil || !woken {
+			t.Fatalf("Signal should wake the waiter, got: %v, %v", woken, err)
+		}
+		producer.Unlock(ctx)
+
+		if err := <-waited; err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+		if err := consumer.Unlock(ctx); err != nil {
+			t.Errorf("Waiter should hold the lock again: %v", err)
+		}
+	})
+
+	t.Run("broadcast wakes every waiter", func(t *testing.T) {
+		waited := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				lock := client.NewLock("test-shared", WithWaitTimeout(5*time.Second))
+				if err := lock.Lock(ctx); err != nil {
+					waited <- err
+					return
+				}
+				err := client.NewCond("test-all", lock).Wait(ctx)
+				if err == nil {
+					err = lock.Unlock(ctx)
+				}
+				waited <- err
+			}()
+		}
+
+		cond := client.NewCond("test-all", nil)
+		waitFor(t, func() bool {
+			n, _ := redisClient.ZCard(ctx, cond.key).Result()
+			return n == 2
+		})
+		if woken, err := cond.Broadcast(ctx); err != nil || woken != 2 {
+			t.Fatalf("Broadcast should wake both waiters, got: %v, %v", woken, err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := <-waited; err != nil {
+				t.Errorf("Wait failed: %v", err)
+			}
+		}
+	})
+
+	t.Run("cancelled waiters leave", func(t *testing.T) {
+		lock := client.NewLock("test-cancel")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		cond := client.NewCond("test-cancel", lock)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		if err := cond.Wait(waitCtx); err != context.DeadlineExceeded {
+			t.Fatalf("Expected deadline error, got: %v", err)
+		}
+		if n, _ := redisClient.ZCard(ctx, cond.key).Result(); n != 0 {
+			t.Errorf("Cancelled waiter should leave, %d waiting", n)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-cancel"); locked {
+			t.Error("Cancelled waiter should not hold the lock")
+		}
+	})
+}
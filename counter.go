@@ -0,0 +1,70 @@
+package arbiter
+
+import (
+	"context"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// Counter is a distributed atomic counter shared by every process using its name, for
+// coordination code that would otherwise take a lock just to bump a shared number.
+// A counter that was never set reads as 0.
+type Counter struct {
+	client *Client
+	name   string
+	key    string
+	logger Logger
+}
+
+// NewCounter creates the counter name
+func (c *Client) NewCounter(name string) *Counter {
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	return &Counter{
+		client: c,
+		name:   name,
+		key:    c.internalKey("counter:" + name),
+		logger: c.logger,
+	}
+}
+
+// Incr adds 1 to the counter and returns the new value
+func (c *Counter) Incr(ctx context.Context) (int64, error) {
+	return c.Add(ctx, 1)
+}
+
+// Decr subtracts 1 from the counter and returns the new value
+func (c *Counter) Decr(ctx context.Context) (int64, error) {
+	return c.Add(ctx, -1)
+}
+
+// Add adds delta to the counter and returns the new value
+func (c *Counter) Add(ctx context.Context, delta int64) (int64, error) {
+	if err := c.client.policy.check(c.name); err != nil {
+		return 0, err
+	}
+	value, err := replyInt(c.client.do(ctx, "INCRBY", c.key, delta))
+	if err != nil {
+		c.logger.Error(ctx, "Failed to update counter: %s, error: %v", c.name, err)
+		return 0, err
+	}
+	return value, nil
+}
+
+// Get returns the value of the counter
+func (c *Counter) Get(ctx context.Context) (int64, error) {
+	return replyInt(c.client.do(ctx, "GET", c.key))
+}
+
+// CompareAndSwap sets the counter to new if it holds old and reports whether it did
+func (c *Counter) CompareAndSwap(ctx context.Context, old, new int64) (bool, error) {
+	if err := c.client.policy.check(c.name); err != nil {
+		return false, err
+	}
+	swapped, err := c.client.runScriptBool(ctx, lua.CounterCAS, []string{c.key}, old, new)
+	if err != nil {
+		c.logger.Error(ctx, "Failed to swap counter: %s, error: %v", c.name, err)
+		return false, err
+	}
+	return swapped, nil
+}
@@ -0,0 +1,45 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-counter:"))
+	ctx := context.Background()
+
+	counter := client.NewCounter("test-jobs")
+	redisClient.Del(ctx, counter.key)
+
+	if value, err := counter.Get(ctx); err != nil || value != 0 {
+		t.Fatalf("Unset counter should read 0, got: %v, %v", value, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.NewCounter("test-jobs").Incr(ctx)
+		}()
+	}
+	wg.Wait()
+	if value, _ := counter.Decr(ctx); value != 9 {
+		t.Fatalf("Expected 9 after 10 increments and a decrement, got %d", value)
+	}
+
+	if swapped, err := counter.CompareAndSwap(ctx, 5, 100); err != nil || swapped {
+		t.Fatalf("Swap from a wrong value should fail, got: %v, %v", swapped, err)
+	}
+	if swapped, err := counter.CompareAndSwap(ctx, 9, 100); err != nil || !swapped {
+		t.Fatalf("Swap from the current value should succeed, got: %v, %v", swapped, err)
+	}
+	if value, _ := counter.Get(ctx); value != 100 {
+		t.Errorf("Expected 100 after swap, got %d", value)
+	}
+}
@@ -0,0 +1,96 @@
+package arbiter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DashboardOptions configures the Grafana dashboard generated by GrafanaDashboard
+type DashboardOptions struct {
+	// Title is the dashboard title, "Arbiter" if empty
+	Title string
+
+	// Datasource is the UID of the Prometheus datasource, the default datasource if empty
+	Datasource string
+
+	// Selector is added to every query, e.g. `job="orders"`, to scope the dashboard
+	Selector string
+}
+
+// dashboardPanel is a time series panel of the generated dashboard
+type dashboardPanel struct {
+	title  string
+	unit   string
+	expr   string
+	legend string
+}
+
+// GrafanaDashboard returns a Grafana dashboard JSON model matched to the metric names
+// and labels the client emits, for a Metrics sink exporting them to Prometheus with
+// histograms for observed values. It can be imported as is or provisioned from a file.
+func GrafanaDashboard(opts DashboardOptions) ([]byte, error) {
+	if opts.Title == "" {
+		opts.Title = "Arbiter"
+	}
+	sel := "{" + opts.Selector + "}"
+	bySel := func(name string) string { return name + sel }
+
+	panels := []dashboardPanel{
+		{"Acquisitions by lock", "ops",
+			fmt.Sprintf("sum by (%s) (rate(%s[$__rate_interval]))", LabelLock, bySel(MetricLockAcquisitions)),
+			"{{" + LabelLock + "}}"},
+		{"Wait p99 by lock", "s",
+			fmt.Sprintf("histogram_quantile(0.99, sum by (%s, le) (rate(%s[$__rate_interval])))", LabelLock, bySel(MetricLockWait+"_bucket")),
+			"{{" + LabelLock + "}}"},
+		{"Lost locks", "short",
+			fmt.Sprintf("sum by (%s) (increase(%s[$__rate_interval]))", LabelLock, bySel(MetricLockLost)),
+			"{{" + LabelLock + "}}"},
+		{"Watchdog failures", "short",
+			fmt.Sprintf("sum by (%s) (increase(%s[$__rate_interval]))", LabelLock, bySel(MetricWatchdogFailures)),
+			"{{" + LabelLock + "}}"},
+		{"Redis latency p99", "s",
+			fmt.Sprintf("histogram_quantile(0.99, sum by (op, le) (rate(%s[$__rate_interval])))", bySel(MetricOperationLatency+"_bucket")),
+			"{{op}}"},
+		{"Brownout", "short", fmt.Sprintf("max(%s)", bySel(MetricBrownout)), "brownout"},
+		{"Distinct lock names", "short", fmt.Sprintf("max(%s)", bySel(MetricDistinctLockNames)), "names"},
+		{"Cardinality exceeded", "short",
+			fmt.Sprintf("sum(increase(%s[$__rate_interval]))", bySel(MetricLockCardinalityExceeded)), "exceeded"},
+	}
+
+	var datasource any
+	if opts.Datasource != "" {
+		datasource = map[string]string{"type": "prometheus", "uid": opts.Datasource}
+	}
+
+	models := make([]map[string]any, len(panels))
+	for i, p := range panels {
+		models[i] = map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]string{"unit": p.unit},
+				"overrides": []any{},
+			},
+			"targets": []map[string]any{{
+				"refId":        "A",
+				"datasource":   datasource,
+				"expr":         p.expr,
+				"legendFormat": p.legend,
+			}},
+		}
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"title":         opts.Title,
+		"uid":           nil,
+		"tags":          []string{"arbiter"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"panels":        models,
+	}, "", "  ")
+}
@@ -0,0 +1,46 @@
+package arbiter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGrafanaDashboard(t *testing.T) {
+	data, err := GrafanaDashboard(DashboardOptions{Datasource: "prom", Selector: `job="orders"`})
+	if err != nil {
+		t.Fatalf("GrafanaDashboard failed: %v", err)
+	}
+
+	var dashboard struct {
+		Title  string `json:"title"`
+		Panels []struct {
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		t.Fatalf("Dashboard is not valid JSON: %v", err)
+	}
+	if dashboard.Title != "Arbiter" {
+		t.Errorf("Expected default title, got: %s", dashboard.Title)
+	}
+
+	var exprs []string
+	for _, p := range dashboard.Panels {
+		for _, target := range p.Targets {
+			if !strings.Contains(target.Expr, `{job="orders"}`) {
+				t.Errorf("Query of panel %q should carry the selector: %s", p.Title, target.Expr)
+			}
+			exprs = append(exprs, target.Expr)
+		}
+	}
+	all := strings.Join(exprs, "\n")
+	for _, name := range []string{MetricLockAcquisitions, MetricLockWait + "_bucket", MetricLockLost, MetricWatchdogFailures} {
+		if !strings.Contains(all, name) {
+			t.Errorf("Dashboard should query %s", name)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// deadlineMargin is the least time before the deadline of ctx the last attempt of a
+// waiting Lock call starts, leaving room for scheduling besides the round trip
+const deadlineMargin = 10 * time.Millisecond
+
+// WithContextWaitTimeout makes Lock calls without a wait timeout wait until the deadline
+// of their ctx and give up with ErrLockTimeout, as if the deadline was the wait timeout.
+// The last attempt is planned to finish before the deadline, so the caller keeps the
+// rest of its ctx for the error path.
+func WithContextWaitTimeout() Option {
+	return func(o *LockOptions) {
+		o.ContextWaitTimeout = true
+	}
+}
+
+// attemptCutoff returns the latest time an attempt may start and still finish before
+// the deadline of ctx, judged by recent round trips, or the zero time if ctx has none
+func (l *lockImpl) attemptCutoff(ctx context.Context) time.Time {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.Time{}
+	}
+	margin := max(l.client.latency.percentile(0.99), deadlineMargin)
+	return deadline.Add(-margin)
+}
+
+// contextWaitDeadline returns the wait deadline of a Lock call, moved up to the attempt
+// cutoff of ctx with WithContextWaitTimeout when no wait timeout is set
+func (l *lockImpl) contextWaitDeadline(ctx context.Context, deadline time.Time) time.Time {
+	if !l.options.ContextWaitTimeout || l.options.WaitTimeout > 0 {
+		return deadline
+	}
+	if cutoff := l.attemptCutoff(ctx); !cutoff.IsZero() && (deadline.IsZero() || cutoff.Before(deadline)) {
+		return cutoff
+	}
+	return deadline
+}
+
+// errDeadline returns the error of a Lock call that cannot attempt again before the
+// deadline of ctx
+func (l *lockImpl) errDeadline() error {
+	return fmt.Errorf("lock %s not acquired before the context deadline: %w", l.name, context.DeadlineExceeded)
+}
@@ -0,0 +1,59 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestContextDeadline(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	holder := client.NewLock("test-deadline", WithLeaseTime(time.Minute))
+	if err := holder.Lock(context.Background()); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	t.Run("gives up before the deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := client.NewLock("test-deadline", WithRetryInterval(time.Second), WithWaitTimeout(time.Minute)).Lock(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) || err == context.DeadlineExceeded {
+			t.Fatalf("Expected wrapped deadline error, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+			t.Errorf("Expected to give up by the deadline, took %v", elapsed)
+		}
+	})
+
+	t.Run("last retry before the deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		// The store reports no release, only the retry planned before the deadline sees it
+		time.AfterFunc(100*time.Millisecond, func() { holder.Unlock(context.Background()) })
+		waiter := client.NewLock("test-deadline", WithRetryInterval(time.Second))
+		if err := waiter.Lock(ctx); err != nil {
+			t.Fatalf("Expected to acquire lock before the deadline, got: %v", err)
+		}
+		waiter.Unlock(context.Background())
+	})
+
+	t.Run("deadline as wait timeout", func(t *testing.T) {
+		if err := holder.Lock(context.Background()); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		err := client.NewLock("test-deadline", WithContextWaitTimeout()).Lock(ctx)
+		if !errors.Is(err, ErrLockTimeout) {
+			t.Fatalf("Expected ErrLockTimeout, got: %v", err)
+		}
+		if ctx.Err() != nil {
+			t.Error("Expected to give up before ctx expired")
+		}
+	})
+}
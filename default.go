@@ -0,0 +1,51 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrNoDefaultClient is returned by the package-level helpers before SetDefault was called
+var ErrNoDefaultClient = errors.New("no default client set")
+
+var defaultClient atomic.Pointer[Client]
+
+// SetDefault sets the client used by the package-level helpers Acquire and WithLock,
+// for small applications that do not want to pass a Client through every layer
+func SetDefault(client *Client) {
+	defaultClient.Store(client)
+}
+
+// Default returns the client set by SetDefault, nil if none was set
+func Default() *Client {
+	return defaultClient.Load()
+}
+
+// Acquire acquires the named lock with the default client and returns it held. The
+// caller releases it with Unlock.
+func Acquire(ctx context.Context, name string, opts ...Option) (Lock, error) {
+	client := Default()
+	if client == nil {
+		return nil, ErrNoDefaultClient
+	}
+
+	lock := client.NewLock(name, opts...)
+	if err := lock.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// WithLock runs fn holding the named lock of the default client and releases it
+// afterwards, like Lock.Do without checkpoints
+func WithLock(ctx context.Context, name string, fn func(ctx context.Context) error, opts ...Option) error {
+	client := Default()
+	if client == nil {
+		return ErrNoDefaultClient
+	}
+
+	return client.NewLock(name, opts...).Do(ctx, func(ctx context.Context, _ Checkpoint) error {
+		return fn(ctx)
+	})
+}
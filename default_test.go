@@ -0,0 +1,45 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDefaultClient(t *testing.T) {
+	ctx := context.Background()
+	defer SetDefault(nil)
+
+	SetDefault(nil)
+	if _, err := Acquire(ctx, "test-lock"); err != ErrNoDefaultClient {
+		t.Fatalf("Expected ErrNoDefaultClient, got: %v", err)
+	}
+
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-default:"))
+	SetDefault(client)
+
+	lock, err := Acquire(ctx, "test-lock")
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if locked, _ := client.IsLocked(ctx, "test-lock"); !locked {
+		t.Error("Lock should be held")
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+
+	ran := false
+	err = WithLock(ctx, "test-lock", func(ctx context.Context) error {
+		ran, _ = client.IsLocked(ctx, "test-lock")
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatalf("fn should run holding the lock, got: %v, %v", ran, err)
+	}
+	if locked, _ := client.IsLocked(ctx, "test-lock"); locked {
+		t.Error("Lock should be released after WithLock")
+	}
+}
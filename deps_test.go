@@ -0,0 +1,51 @@
+package arbiter
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// coreDependencies are the only non-standard packages the core may import, go-redis and its own dependencies
+var coreDependencies = []string{
+	"github.com/huimingz/arbiter",
+	"github.com/redis/go-redis/v9",
+	"github.com/cespare/xxhash/v2",
+	"github.com/dgryski/go-rendezvous",
+}
+
+func TestCoreDependencies(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	for _, tags := range []string{"", "arbiterminimal"} {
+		out, err := exec.Command(goTool, "list", "-deps", "-tags", tags, ".").Output()
+		if err != nil {
+			t.Fatalf("Failed to list dependencies: %v", err)
+		}
+
+		for _, pkg := range strings.Fields(string(out)) {
+			if tags != "" && pkg == "net/http" {
+				t.Errorf("Minimal build should not import net/http")
+			}
+			if !strings.Contains(strings.Split(pkg, "/")[0], ".") {
+				continue // standard library
+			}
+			if !hasModulePrefix(pkg, coreDependencies) {
+				t.Errorf("Core package imports %s, move it to a submodule", pkg)
+			}
+		}
+	}
+}
+
+// hasModulePrefix reports whether pkg belongs to one of modules
+func hasModulePrefix(pkg string, modules []string) bool {
+	for _, module := range modules {
+		if pkg == module || strings.HasPrefix(pkg, module+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package arbiter
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Locker is a coordination backend handing out locks, as returned by Open
+type Locker interface {
+	// NewLock creates a new lock instance
+	NewLock(name string, opts ...Option) Lock
+
+	// Close releases the resources of the backend
+	Close() error
+}
+
+// Driver opens Lockers for a backend registered with Register
+type Driver interface {
+	// Open returns a Locker for the data source name, e.g. "redis://localhost:6379/0"
+	Open(dsn string) (Locker, error)
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+)
+
+func init() {
+	Register("redis", redisDriver{})
+	Register("rediss", redisDriver{})
+}
+
+// Register makes a driver available under the URL scheme name.
+// It panics if driver is nil or a driver is already registered under name.
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver == nil {
+		panic("arbiter: Register driver is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("arbiter: Register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers returns the sorted names of the registered drivers
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens a Locker for the data source name, selecting the driver by its URL
+// scheme, so one binary can support several backends chosen by configuration.
+func Open(dsn string) (Locker, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("arbiter: invalid data source name: %w", err)
+	}
+
+	driversMu.RLock()
+	driver, ok := drivers[u.Scheme]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("arbiter: unknown driver %q (forgotten import?)", u.Scheme)
+	}
+
+	return driver.Open(dsn)
+}
+
+// redisDriver opens Clients from redis:// and rediss:// URLs as accepted by
+// redis.ParseURL, with an optional "prefix" query parameter for the key prefix.
+type redisDriver struct{}
+
+func (redisDriver) Open(dsn string) (Locker, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []ClientOption
+	query := u.Query()
+	if query.Has("prefix") {
+		opts = append(opts, WithKeyPrefix(query.Get("prefix")))
+		query.Del("prefix")
+		u.RawQuery = query.Encode()
+	}
+
+	options, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClient(redis.NewClient(options), opts...)
+	c.ownsRedis = true
+	return c, nil
+}
@@ -0,0 +1,75 @@
+package arbiter
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type fakeDriver struct {
+	dsn string
+}
+
+func (d *fakeDriver) Open(dsn string) (Locker, error) {
+	d.dsn = dsn
+	return NewClient(nil, WithLogger(&NoopLogger{})), nil
+}
+
+func TestDrivers(t *testing.T) {
+	t.Run("register and open", func(t *testing.T) {
+		driver := &fakeDriver{}
+		Register("test-fake", driver)
+
+		if _, err := Open("test-fake://host/path"); err != nil {
+			t.Fatalf("Failed to open: %v", err)
+		}
+		if driver.dsn != "test-fake://host/path" {
+			t.Errorf("Driver received dsn %q", driver.dsn)
+		}
+
+		names := strings.Join(Drivers(), ",")
+		if names != "redis,rediss,test-fake" {
+			t.Errorf("Drivers() = %s", names)
+		}
+	})
+
+	t.Run("duplicate registration panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Register should panic for a duplicate name")
+			}
+		}()
+		Register("redis", redisDriver{})
+	})
+
+	t.Run("unknown driver", func(t *testing.T) {
+		if _, err := Open("etcd://localhost:2379"); err == nil {
+			t.Error("Open should fail for unregistered drivers")
+		}
+	})
+
+	t.Run("redis driver", func(t *testing.T) {
+		redisClient := setupRedis(t)
+		redisClient.Close()
+
+		locker, err := Open("redis://localhost:6379/0?prefix=test-driver:")
+		if err != nil {
+			t.Fatalf("Failed to open: %v", err)
+		}
+		defer locker.Close()
+
+		client := locker.(*Client)
+		if client.prefix != "test-driver:" {
+			t.Errorf("Unexpected prefix %q", client.prefix)
+		}
+
+		ctx := context.Background()
+		lock := locker.NewLock("test-open")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+	})
+}
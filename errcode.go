@@ -0,0 +1,143 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrorCode classifies the errors returned by arbiter, so callers and middleware
+// can switch on one code instead of matching sentinels of every subsystem
+type ErrorCode int
+
+const (
+	// CodeOK is the code of a nil error
+	CodeOK ErrorCode = iota
+	// CodeUnknown is the code of errors arbiter does not classify
+	CodeUnknown
+	// CodeTimeout means waiting for a lock timed out
+	CodeTimeout
+	// CodeCanceled means the context of the call was cancelled
+	CodeCanceled
+	// CodeNotHeld means the lock was not held by the caller
+	CodeNotHeld
+	// CodeHeldByOther means another owner holds the lock
+	CodeHeldByOther
+	// CodeBackendUnavailable means Redis could not serve the call, e.g. unreachable or a replica
+	CodeBackendUnavailable
+	// CodeQuorumNotReached means too few RedLock instances agreed
+	CodeQuorumNotReached
+	// CodeLost means a held lock was lost
+	CodeLost
+	// CodeInvalidated means the acquisition was superseded, e.g. reacquired after another owner
+	CodeInvalidated
+	// CodeRejected means the call was refused, e.g. by a freeze, policy or quota, or for an invalid argument
+	CodeRejected
+)
+
+// codeNames are the names returned by ErrorCode.String
+var codeNames = map[ErrorCode]string{
+	CodeOK:                 "ok",
+	CodeUnknown:            "unknown",
+	CodeTimeout:            "timeout",
+	CodeCanceled:           "canceled",
+	CodeNotHeld:            "not_held",
+	CodeHeldByOther:        "held_by_other",
+	CodeBackendUnavailable: "backend_unavailable",
+	CodeQuorumNotReached:   "quorum_not_reached",
+	CodeLost:               "lost",
+	CodeInvalidated:        "invalidated",
+	CodeRejected:           "rejected",
+}
+
+// String returns the snake case name of the code, suitable as a metric label
+func (c ErrorCode) String() string {
+	if name, ok := codeNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// sentinelCodes maps the sentinel errors of the package to their codes, in the
+// order they are checked
+var sentinelCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrLockTimeout, CodeTimeout},
+	{ErrMaxRetries, CodeTimeout},
+	{ErrLockNotHeld, CodeNotHeld},
+	{ErrNotEntered, CodeNotHeld},
+	{ErrStateLocked, CodeHeldByOther},
+	{ErrUpgradeDeadlock, CodeHeldByOther},
+	{ErrReplicaRedis, CodeBackendUnavailable},
+	{ErrAttemptTimeout, CodeBackendUnavailable},
+	{ErrNotReplicated, CodeBackendUnavailable},
+	{ErrNoQuorum, CodeQuorumNotReached},
+	{ErrLockLost, CodeLost},
+	{ErrLockReacquired, CodeInvalidated},
+	{ErrLockIDMismatch, CodeInvalidated},
+	{ErrStaleFence, CodeInvalidated},
+	{ErrLockFrozen, CodeRejected},
+	{ErrLockNameRejected, CodeRejected},
+	{ErrReservedLockName, CodeRejected},
+	{ErrQuotaExceeded, CodeRejected},
+	{ErrUnauthorized, CodeRejected},
+	{ErrInvalidToken, CodeRejected},
+	{ErrInvalidLease, CodeRejected},
+	{ErrBurstExceeded, CodeRejected},
+	{ErrNoDefaultClient, CodeRejected},
+	{ErrLimitReached, CodeRejected},
+	{ErrHashTagged, CodeRejected},
+	{ErrStoreUnsupported, CodeRejected},
+}
+
+// unavailablePrefixes start the Redis error replies of a server that cannot serve commands right now
+var unavailablePrefixes = []string{"LOADING", "READONLY", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN"}
+
+// Code returns the code of an error returned by arbiter. Wrapped errors are
+// classified by the sentinel they wrap, and Redis connection failures are
+// reported as CodeBackendUnavailable.
+func Code(err error) ErrorCode {
+	if err == nil {
+		return CodeOK
+	}
+
+	for _, sentinel := range sentinelCodes {
+		if errors.Is(err, sentinel.err) {
+			return sentinel.code
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case isUnavailable(err):
+		return CodeBackendUnavailable
+	}
+	return CodeUnknown
+}
+
+// isUnavailable reports whether err means Redis could not be reached or cannot serve commands
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, redis.ErrClosed) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if strings.Contains(err.Error(), "connection pool timeout") {
+		return true
+	}
+	for _, prefix := range unavailablePrefixes {
+		if redis.HasErrorPrefix(err, prefix) {
+			return true
+		}
+	}
+	return false
+}
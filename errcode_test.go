@@ -0,0 +1,51 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCode
+	}{
+		{nil, CodeOK},
+		{errors.New("boom"), CodeUnknown},
+		{ErrLockTimeout, CodeTimeout},
+		{context.DeadlineExceeded, CodeTimeout},
+		{context.Canceled, CodeCanceled},
+		{ErrLockNotHeld, CodeNotHeld},
+		{fmt.Errorf("%w: terraform/prod", ErrStateLocked), CodeHeldByOther},
+		{ErrReplicaRedis, CodeBackendUnavailable},
+		{redis.ErrClosed, CodeBackendUnavailable},
+		{redis.Nil, CodeUnknown},
+		{ErrNoQuorum, CodeQuorumNotReached},
+		{ErrLockLost, CodeLost},
+		{ErrLockReacquired, CodeInvalidated},
+		{ErrLockFrozen, CodeRejected},
+		{fmt.Errorf("%w: namespace a allows 1 held locks", ErrQuotaExceeded), CodeRejected},
+		{errors.Join(ErrLockLost, errors.New("cleanup failed")), CodeLost},
+	}
+
+	for _, tt := range tests {
+		if got := Code(tt.err); got != tt.want {
+			t.Errorf("Code(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCodeUnreachableRedis(t *testing.T) {
+	client := NewClient(redis.NewClient(&redis.Options{Addr: "localhost:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1}))
+	defer client.Close()
+
+	_, err := client.NewLock("test-unreachable").TryLock(context.Background())
+	if code := Code(err); code != CodeBackendUnavailable {
+		t.Fatalf("Expected backend unavailable, got: %s, %v", code, err)
+	}
+}
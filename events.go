@@ -0,0 +1,53 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+)
+
+// EventType identifies a significant lock event
+type EventType string
+
+const (
+	// EventForceUnlock is emitted when an operator releases a lock regardless of its owner
+	EventForceUnlock EventType = "force_unlock"
+	// EventSteal is emitted when an operator takes a lock over regardless of its owner
+	EventSteal EventType = "steal"
+	// EventLockLost is emitted when the watchdog can no longer keep a held lock alive
+	EventLockLost EventType = "lock_lost"
+	// EventWatchdogStall is emitted when the watchdog ran late, e.g. after a long GC pause,
+	// and verifies the lock before refreshing it
+	EventWatchdogStall EventType = "watchdog_stall"
+)
+
+// Event describes a significant lock event delivered to event sinks
+type Event struct {
+	Type   EventType `json:"type"`
+	Name   string    `json:"name"`
+	Owner  string    `json:"owner,omitempty"`
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// EventSink is the interface that receives lock events.
+// Emit is called synchronously from lock operations and must not block.
+type EventSink interface {
+	Emit(ctx context.Context, event Event)
+}
+
+// WithEventSink adds a sink receiving the events of the client
+func WithEventSink(sink EventSink) ClientOption {
+	return func(c *Client) {
+		c.sinks = append(c.sinks, sink)
+	}
+}
+
+// emit delivers an event to every sink of the client
+func (c *Client) emit(ctx context.Context, event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, sink := range c.sinks {
+		sink.Emit(ctx, event)
+	}
+}
@@ -0,0 +1,301 @@
+package arbiter
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisExecutor is the small set of Redis commands the client runs on. The client
+// executes them on its go-redis client by default. Adapters for other Redis clients,
+// like rueidis, or other go-redis major versions implement it and are set with
+// WithExecutor. Locks keep their keys and scripts, so clients on different executors
+// share them. Errors replied by Redis itself, like NOSCRIPT or WRONGTYPE, must have the
+// RedisError method of redis.Error, so they are told apart from connection errors.
+type RedisExecutor interface {
+	// Eval runs script with keys and args and returns its reply: integers as int64,
+	// strings as string, arrays as []interface{} and a nil reply as nil
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+	// EvalSha runs the script loaded under the SHA1 digest sha like Eval. It fails with
+	// the NOSCRIPT error of Redis if the script is not loaded.
+	EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) (interface{}, error)
+
+	// ScriptLoad loads script into the script cache and returns its SHA1 digest
+	ScriptLoad(ctx context.Context, script string) (string, error)
+
+	// Publish publishes message on channel
+	Publish(ctx context.Context, channel, message string) error
+
+	// Subscribe calls fn with the payload of every message on channel until the
+	// returned function is called. It returns once the subscription is confirmed, so
+	// no message published afterwards is missed. fn must not block.
+	Subscribe(ctx context.Context, channel string, fn func(payload string)) (func(), error)
+
+	// Do runs a single command and returns its reply like Eval. Maps are replied as
+	// maps keyed by strings or interfaces in RESP3 and as flat arrays in RESP2.
+	Do(ctx context.Context, args ...interface{}) (interface{}, error)
+
+	// DoMulti runs cmds in one round trip and returns the reply of each like Do. A
+	// command failed by Redis has its error as reply, other errors fail the call.
+	DoMulti(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error)
+
+	// Scan calls fn for every key matching the glob pattern match, on each primary of
+	// a cluster. fn is called by one node at a time.
+	Scan(ctx context.Context, match string, fn func(key string) error) error
+}
+
+// ReplicationExecutor is implemented by executors that can wait for the replication of
+// a script, needed by WithReplicationWait
+type ReplicationExecutor interface {
+	// EvalWait runs script like Eval followed by WAIT for replicas on the connection to
+	// the primary owning keys[0], since WAIT only covers the writes of its own
+	// connection. It returns the script reply and the number of replicas that
+	// acknowledged the writes within timeout.
+	EvalWait(ctx context.Context, script string, keys []string, replicas int, timeout time.Duration, args ...interface{}) (interface{}, int, error)
+}
+
+// WithExecutor runs every Redis command of the client on e instead of a go-redis
+// client, which may then be nil: NewClient(nil, WithExecutor(e)). Routed locks and the
+// instances of WithRedLockInstances run on their own go-redis clients.
+func WithExecutor(e RedisExecutor) ClientOption {
+	return func(c *Client) {
+		c.executor = e
+	}
+}
+
+// scriptDigests caches the SHA1 digest of every script run by runScript
+var scriptDigests sync.Map
+
+// runScript runs script by its digest, sending the script itself only when Redis does
+// not have it loaded yet. Clients created without Redis fail with ErrStoreUnsupported.
+func (c *Client) runScript(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	if c.executor == nil {
+		return nil, ErrStoreUnsupported
+	}
+	if reply, ok, err := c.fcall(ctx, script, keys, args...); ok {
+		return reply, err
+	}
+
+	sha, ok := scriptDigests.Load(script)
+	if !ok {
+		sum := sha1.Sum([]byte(script))
+		sha, _ = scriptDigests.LoadOrStore(script, hex.EncodeToString(sum[:]))
+	}
+
+	reply, err := c.executor.EvalSha(ctx, sha.(string), keys, args...)
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		return c.executor.Eval(ctx, script, keys, args...)
+	}
+	return reply, err
+}
+
+// runScriptBool runs a script replying 1 or 0 like runScript
+func (c *Client) runScriptBool(ctx context.Context, script string, keys []string, args ...interface{}) (bool, error) {
+	return replyBool(c.runScript(ctx, script, keys, args...))
+}
+
+// do runs a single command on the executor of the client like RedisExecutor.Do.
+// Clients created without Redis fail with ErrStoreUnsupported.
+func (c *Client) do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if c.executor == nil {
+		return nil, ErrStoreUnsupported
+	}
+	return c.executor.Do(ctx, args...)
+}
+
+// doMulti runs cmds in one round trip like RedisExecutor.DoMulti
+func (c *Client) doMulti(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error) {
+	if c.executor == nil {
+		return nil, ErrStoreUnsupported
+	}
+	return c.executor.DoMulti(ctx, cmds...)
+}
+
+// scan calls fn for every key matching match like RedisExecutor.Scan
+func (c *Client) scan(ctx context.Context, match string, fn func(key string) error) error {
+	if c.executor == nil {
+		return ErrStoreUnsupported
+	}
+	return c.executor.Scan(ctx, match, fn)
+}
+
+// replyBool returns a reply of 1 or 0 as a bool, a nil reply as false
+func replyBool(reply interface{}, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	switch reply := reply.(type) {
+	case int64:
+		return reply == 1, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected reply: %v", reply)
+}
+
+// replyInt returns an integer reply, also one sent as a string like by GET, and a nil
+// reply as 0
+func replyInt(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch reply := reply.(type) {
+	case int64:
+		return reply, nil
+	case string:
+		return strconv.ParseInt(reply, 10, 64)
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unexpected reply: %v", reply)
+}
+
+// replyInts returns an array reply of integers
+func replyInts(reply interface{}, err error) ([]int64, error) {
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply: %v", reply)
+	}
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		if ints[i], err = replyInt(value, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ints, nil
+}
+
+// replyStrings returns an array or set reply of strings
+func replyStrings(reply interface{}, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok && reply != nil {
+		return nil, fmt.Errorf("unexpected reply: %v", reply)
+	}
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		str, _ := value.(string)
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+// replyFields returns the fields of a hash read by HGETALL, replied as a map in RESP3
+// and a flat array in RESP2
+func replyFields(reply interface{}, err error) (map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	for field, value := range replyMap(reply) {
+		fields[field], _ = value.(string)
+	}
+	return fields, nil
+}
+
+// goRedisExecutor is the default RedisExecutor, running on the go-redis client of a Client
+type goRedisExecutor struct {
+	redis redis.UniversalClient
+}
+
+func (e *goRedisExecutor) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return nilReply(e.redis.Eval(ctx, script, keys, args...).Result())
+}
+
+func (e *goRedisExecutor) EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) (interface{}, error) {
+	return nilReply(e.redis.EvalSha(ctx, sha, keys, args...).Result())
+}
+
+func (e *goRedisExecutor) ScriptLoad(ctx context.Context, script string) (string, error) {
+	return e.redis.ScriptLoad(ctx, script).Result()
+}
+
+func (e *goRedisExecutor) Publish(ctx context.Context, channel, message string) error {
+	return e.redis.Publish(ctx, channel, message).Err()
+}
+
+func (e *goRedisExecutor) Subscribe(ctx context.Context, channel string, fn func(payload string)) (func(), error) {
+	pubsub := e.redis.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			fn(msg.Payload)
+		}
+	}()
+	return func() { pubsub.Close() }, nil
+}
+
+func (e *goRedisExecutor) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return nilReply(e.redis.Do(ctx, args...).Result())
+}
+
+func (e *goRedisExecutor) DoMulti(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error) {
+	pipe := e.redis.Pipeline()
+	results := make([]*redis.Cmd, len(cmds))
+	for i, args := range cmds {
+		results[i] = pipe.Do(ctx, args...)
+	}
+	pipe.Exec(ctx)
+
+	replies := make([]interface{}, len(cmds))
+	for i, cmd := range results {
+		reply, err := nilReply(cmd.Result())
+		if err != nil && !isRedisError(err) {
+			return nil, err
+		}
+		if err != nil {
+			reply = err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+func (e *goRedisExecutor) Scan(ctx context.Context, match string, fn func(key string) error) error {
+	return scanKeys(ctx, e.redis, match, fn)
+}
+
+func (e *goRedisExecutor) EvalWait(ctx context.Context, script string, keys []string, replicas int, timeout time.Duration, args ...interface{}) (interface{}, int, error) {
+	node, err := primaryForKey(ctx, e.redis, keys[0])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var eval, wait *redis.Cmd
+	_, err = node.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		eval = pipe.Eval(ctx, script, keys, args...)
+		wait = pipe.Do(ctx, "WAIT", replicas, timeout.Milliseconds())
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	replicated, err := wait.Int()
+	return eval.Val(), replicated, err
+}
+
+// nilReply turns the redis.Nil error of a nil reply into a nil reply
+func nilReply(reply interface{}, err error) (interface{}, error) {
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return reply, err
+}
@@ -0,0 +1,174 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// fakeExecutor replies to the scripts of the default store and to commands by their
+// name without Redis. It has no script loaded, so every script is sent after a NOSCRIPT
+// reply.
+type fakeExecutor struct {
+	replies  map[string]interface{}
+	commands map[string]interface{}
+	calls    map[string]int
+	shas     int
+	loads    int
+
+	// mu guards commands and calls of Do, which watchers call on their own goroutine
+	mu           sync.Mutex
+	subscriber   func(payload string)
+	subscribeErr error
+}
+
+// reply sets the reply to the command name
+func (s *fakeExecutor) reply(name string, reply interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands[name] = reply
+}
+
+func (s *fakeExecutor) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	s.calls[script]++
+	reply, ok := s.replies[script]
+	if !ok {
+		return nil, errors.New("unexpected script")
+	}
+	return reply, nil
+}
+
+func (s *fakeExecutor) EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) (interface{}, error) {
+	s.shas++
+	return nil, errors.New("NOSCRIPT No matching script. Please use EVAL.")
+}
+
+func (s *fakeExecutor) ScriptLoad(ctx context.Context, script string) (string, error) {
+	s.loads++
+	return "", nil
+}
+
+func (s *fakeExecutor) Publish(ctx context.Context, channel, message string) error {
+	return nil
+}
+
+func (s *fakeExecutor) Subscribe(ctx context.Context, channel string, fn func(payload string)) (func(), error) {
+	if s.subscribeErr != nil {
+		return nil, s.subscribeErr
+	}
+	s.subscriber = fn
+	return func() {}, nil
+}
+
+func (s *fakeExecutor) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, _ := args[0].(string)
+	s.calls[name]++
+	reply, ok := s.commands[name]
+	if !ok {
+		return nil, errors.New("unexpected command")
+	}
+	return reply, nil
+}
+
+func (s *fakeExecutor) DoMulti(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error) {
+	replies := make([]interface{}, len(cmds))
+	for i, args := range cmds {
+		reply, err := s.Do(ctx, args...)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+func (s *fakeExecutor) Scan(ctx context.Context, match string, fn func(key string) error) error {
+	return nil
+}
+
+func TestExecutor(t *testing.T) {
+	// Nothing listens on the address, every lock operation must go to the executor
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer redisClient.Close()
+
+	executor := &fakeExecutor{
+		replies: map[string]interface{}{lua.TryLock: int64(7), lua.Refresh: int64(1), lua.Unlock: nil},
+		calls:   make(map[string]int),
+	}
+	client := NewClient(redisClient, WithLogger(&NoopLogger{}), WithoutReplicaCheck(), WithExecutor(executor))
+	ctx := context.Background()
+
+	lock := client.NewLock("test-executor")
+	if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	if fence := lock.Fence(); fence != 7 {
+		t.Errorf("Expected fence 7, got: %d", fence)
+	}
+	if err := lock.Refresh(ctx); err != nil {
+		t.Errorf("Failed to refresh lock: %v", err)
+	}
+
+	// A nil reply releases nothing
+	if err := lock.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld, got: %v", err)
+	}
+
+	for _, script := range []string{lua.TryLock, lua.Refresh, lua.Unlock} {
+		if executor.calls[script] != 1 {
+			t.Errorf("Expected one call per script, got: %v", executor.calls)
+		}
+	}
+
+	_, err := client.NewLock("test-executor", WithReplicationWait(1, 0)).TryLock(ctx)
+	if err == nil {
+		t.Error("Replication wait should need the go-redis client")
+	}
+	if executor.shas != 3 {
+		t.Errorf("Expected every script to be tried by digest first, got: %d", executor.shas)
+	}
+
+	// RedLock instances run on their own clients
+	redLock := NewClient(redisClient, WithLogger(&NoopLogger{}), WithExecutor(executor), WithRedLockInstances(redisClient))
+	if _, ok := redLock.redLockClients[1].executor.(*goRedisExecutor); !ok {
+		t.Errorf("Expected the RedLock instance to run on its go-redis client, got: %T", redLock.redLockClients[1].executor)
+	}
+}
+
+func TestExecutorWithoutGoRedis(t *testing.T) {
+	executor := &fakeExecutor{
+		replies:  map[string]interface{}{lua.TryLock: int64(1), lua.Unlock: int64(1)},
+		commands: map[string]interface{}{"ROLE": []interface{}{"master"}, "EXISTS": int64(1)},
+		calls:    make(map[string]int),
+	}
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithExecutor(executor))
+	ctx := context.Background()
+
+	if err := client.Warmup(ctx, "test-executor"); err != nil {
+		t.Fatalf("Failed to warm up: %v", err)
+	}
+	if executor.loads == 0 || executor.calls["ROLE"] != 1 {
+		t.Errorf("Warmup should load scripts and check the role, got: %d loads, %v", executor.loads, executor.calls)
+	}
+	if locked, err := client.IsLocked(ctx, "test-executor"); err != nil || !locked {
+		t.Errorf("Expected the lock to be held, got: %v, %v", locked, err)
+	}
+
+	lock := client.NewLock("test-executor")
+	if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("Failed to close client: %v", err)
+	}
+}
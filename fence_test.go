@@ -0,0 +1,91 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFence(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-fence:"))
+	ctx := context.Background()
+
+	t.Run("tokens increase per owner", func(t *testing.T) {
+		lock := client.NewLock("test-increasing")
+		if lock.Fence() != 0 {
+			t.Fatalf("Free lock should have no fence, got: %d", lock.Fence())
+		}
+
+		var previous int64
+		for i := 0; i < 3; i++ {
+			if err := lock.Lock(ctx); err != nil {
+				t.Fatalf("Failed to acquire lock: %v", err)
+			}
+			fence := lock.Fence()
+			if fence <= previous {
+				t.Fatalf("Fence should increase, got %d after %d", fence, previous)
+			}
+			previous = fence
+
+			// Re-entry keeps the token of the acquisition
+			if _, err := lock.TryLock(ctx); err != nil || lock.Fence() != fence {
+				t.Fatalf("Re-entry should keep fence %d, got: %d, %v", fence, lock.Fence(), err)
+			}
+
+			infos, err := client.InspectLocks(ctx, []string{"test-increasing"})
+			if err != nil || infos[0].Fence != fence {
+				t.Fatalf("Inspected fence should be %d, got: %+v, %v", fence, infos, err)
+			}
+
+			if err := lock.Unlock(ctx); err != nil {
+				t.Fatalf("Failed to release lock: %v", err)
+			}
+			if lock.Fence() != 0 {
+				t.Fatalf("Released lock should have no fence, got: %d", lock.Fence())
+			}
+		}
+	})
+
+	t.Run("stale holder has the lower token", func(t *testing.T) {
+		stale := client.NewLock("test-stale")
+		if err := stale.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		staleFence := stale.Fence()
+
+		// The lease lapses while the holder is paused and another owner takes over
+		redisClient.Del(ctx, client.lockKey("test-stale"))
+		current := client.NewLock("test-stale")
+		if err := current.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer current.Unlock(ctx)
+
+		if current.Fence() <= staleFence {
+			t.Fatalf("New owner should have a higher fence, got %d after %d", current.Fence(), staleFence)
+		}
+	})
+
+	t.Run("multi lock fence grows with any member", func(t *testing.T) {
+		multi := client.NewMultiLock([]string{"test-a", "test-b"})
+		if err := multi.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire multi lock: %v", err)
+		}
+		first := multi.Fence()
+		multi.Unlock(ctx)
+
+		single := client.NewLock("test-b")
+		single.Lock(ctx)
+		single.Unlock(ctx)
+
+		if err := multi.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire multi lock: %v", err)
+		}
+		defer multi.Unlock(ctx)
+		if multi.Fence() <= first {
+			t.Fatalf("Multi lock fence should increase, got %d after %d", multi.Fence(), first)
+		}
+	})
+}
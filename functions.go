@@ -0,0 +1,138 @@
+package arbiter
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// FunctionExecutor is implemented by executors that run Redis Functions. Clients with
+// WithFunctions run the lock scripts as functions on such executors and fall back to
+// EVAL on others.
+type FunctionExecutor interface {
+	// FunctionLoad loads the library code on every primary, a library of the same name
+	// already loaded is kept
+	FunctionLoad(ctx context.Context, code string) error
+
+	// FCall calls function with keys and args and returns its reply like Eval
+	FCall(ctx context.Context, function string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// WithFunctions runs the scripts of acquisitions, releases and refreshes as Redis
+// Functions on Redis 7 and later: they are loaded once with FUNCTION LOAD as the library
+// "arbiter_<version>", persisted with the dataset and listed by FUNCTION LIST and in
+// the command stats under their names, e.g. "arbiter_trylock_<version>". Older servers
+// and executors that do not implement FunctionExecutor keep using EVAL.
+func WithFunctions() ClientOption {
+	return func(c *Client) {
+		c.functions = true
+	}
+}
+
+// functionScripts names the scripts run as functions
+var functionScripts = []struct {
+	name   string
+	script string
+}{
+	{"trylock", lua.TryLock},
+	{"unlock", lua.Unlock},
+	{"refresh", lua.Refresh},
+}
+
+// libraryCode is the function library registering functionScripts, and functionNames
+// the function name of each script. Both carry a digest of the scripts, so clients of
+// different versions sharing a server load libraries of their own.
+var libraryCode, functionNames = buildLibrary()
+
+func buildLibrary() (string, map[string]string) {
+	digest := sha1.New()
+	for _, fn := range functionScripts {
+		digest.Write([]byte(fn.script))
+	}
+	version := hex.EncodeToString(digest.Sum(nil))[:12]
+
+	names := make(map[string]string, len(functionScripts))
+	var code strings.Builder
+	fmt.Fprintf(&code, "#!lua name=arbiter_%s\n", version)
+	for _, fn := range functionScripts {
+		name := fmt.Sprintf("arbiter_%s_%s", fn.name, version)
+		names[fn.script] = name
+
+		// The script body runs unchanged with KEYS and ARGV as parameters
+		fmt.Fprintf(&code, "redis.register_function('%s', function(KEYS, ARGV)\n%s\nend)\n", name, fn.script)
+	}
+	return code.String(), names
+}
+
+// fcall runs script as a function if the client uses functions, the executor supports
+// them and the server is Redis 7 or later. It reports false if the script must run
+// with EVAL instead.
+func (c *Client) fcall(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, bool, error) {
+	name, ok := functionNames[script]
+	executor, isFunctions := c.executor.(FunctionExecutor)
+	if !c.functions || !ok || !isFunctions {
+		return nil, false, nil
+	}
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return nil, true, err
+	}
+	if !caps.Functions {
+		return nil, false, nil
+	}
+
+	reply, err := executor.FCall(ctx, name, keys, args...)
+	if err != nil && strings.Contains(err.Error(), "Function not found") {
+		// First call on this server, or it restarted without persistence
+		if err := executor.FunctionLoad(ctx, libraryCode); err != nil {
+			return nil, true, err
+		}
+		reply, err = executor.FCall(ctx, name, keys, args...)
+	}
+	return reply, true, err
+}
+
+// loadFunctions loads the function library if the client runs its scripts as functions
+func (c *Client) loadFunctions(ctx context.Context) error {
+	executor, ok := c.executor.(FunctionExecutor)
+	if !c.functions || !ok {
+		return nil
+	}
+	caps, err := c.Capabilities(ctx)
+	if err != nil || !caps.Functions {
+		return err
+	}
+	return executor.FunctionLoad(ctx, libraryCode)
+}
+
+func (e *goRedisExecutor) FunctionLoad(ctx context.Context, code string) error {
+	load := func(ctx context.Context, node redis.Cmdable) error {
+		err := node.FunctionLoad(ctx, code).Err()
+		if err != nil && strings.Contains(err.Error(), "already exists") {
+			return nil
+		}
+		return err
+	}
+	each := func(ctx context.Context, node *redis.Client) error {
+		return load(ctx, node)
+	}
+
+	// Functions are not propagated across the primaries of a cluster or ring
+	switch rc := e.redis.(type) {
+	case *redis.ClusterClient:
+		return rc.ForEachMaster(ctx, each)
+	case *redis.Ring:
+		return rc.ForEachShard(ctx, each)
+	}
+	return load(ctx, e.redis)
+}
+
+func (e *goRedisExecutor) FCall(ctx context.Context, function string, keys []string, args ...interface{}) (interface{}, error) {
+	return nilReply(e.redis.FCall(ctx, function, keys, args...).Result())
+}
@@ -0,0 +1,131 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// functionExecutor runs the scripts of fakeExecutor as functions once they are loaded
+type functionExecutor struct {
+	*fakeExecutor
+	loads  int
+	fcalls map[string]int
+}
+
+func (s *functionExecutor) FunctionLoad(ctx context.Context, code string) error {
+	s.loads++
+	return nil
+}
+
+func (s *functionExecutor) FCall(ctx context.Context, function string, keys []string, args ...interface{}) (interface{}, error) {
+	if s.loads == 0 {
+		return nil, errors.New("ERR Function not found")
+	}
+	s.fcalls[function]++
+	for script, name := range functionNames {
+		if name == function {
+			return s.replies[script], nil
+		}
+	}
+	return nil, errors.New("unexpected function")
+}
+
+func TestFunctions(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer redisClient.Close()
+	ctx := context.Background()
+
+	newExecutor := func() *functionExecutor {
+		return &functionExecutor{
+			fakeExecutor: &fakeExecutor{
+				replies: map[string]interface{}{lua.TryLock: int64(3), lua.Refresh: int64(1), lua.Unlock: int64(1)},
+				calls:   make(map[string]int),
+			},
+			fcalls: make(map[string]int),
+		}
+	}
+
+	t.Run("redis 7", func(t *testing.T) {
+		executor := newExecutor()
+		client := NewClient(redisClient, WithLogger(&NoopLogger{}), WithoutReplicaCheck(), WithExecutor(executor), WithFunctions())
+		client.capabilities.caps = &Capabilities{Major: 7, Functions: true}
+
+		lock := client.NewLock("test-functions")
+		if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+		}
+		if err := lock.Refresh(ctx); err != nil {
+			t.Errorf("Failed to refresh lock: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Errorf("Failed to release lock: %v", err)
+		}
+
+		if executor.loads != 1 {
+			t.Errorf("Expected the library to be loaded once, got: %d", executor.loads)
+		}
+		if len(executor.fcalls) != 3 || len(executor.calls) != 0 {
+			t.Errorf("Expected every script to run as function, got: %v, %v", executor.fcalls, executor.calls)
+		}
+	})
+
+	t.Run("older redis", func(t *testing.T) {
+		executor := newExecutor()
+		client := NewClient(redisClient, WithLogger(&NoopLogger{}), WithoutReplicaCheck(), WithExecutor(executor), WithFunctions())
+		client.capabilities.caps = &Capabilities{Major: 6}
+
+		if acquired, err := client.NewLock("test-functions").TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+		}
+		if executor.loads != 0 || executor.calls[lua.TryLock] != 1 {
+			t.Errorf("Expected fallback to EVAL, got: %d loads, %v", executor.loads, executor.calls)
+		}
+	})
+}
+
+func TestFunctionLibrary(t *testing.T) {
+	if !strings.HasPrefix(libraryCode, "#!lua name=arbiter_") {
+		t.Errorf("Expected library header, got: %q", strings.SplitN(libraryCode, "\n", 2)[0])
+	}
+	for _, fn := range functionScripts {
+		name := functionNames[fn.script]
+		if !strings.Contains(libraryCode, "redis.register_function('"+name+"'") {
+			t.Errorf("Expected %s to be registered", name)
+		}
+	}
+}
+
+func TestFunctionsRedis(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	ctx := context.Background()
+	client := NewClient(redisClient, WithKeyPrefix("test-functions:"), WithFunctions())
+	caps, err := client.Capabilities(ctx)
+	if err != nil {
+		t.Fatalf("Failed to detect capabilities: %v", err)
+	}
+	if !caps.Functions {
+		t.Skip("Redis Functions need Redis 7")
+	}
+
+	lock := client.NewLock("test-lock")
+	if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+
+	library := strings.TrimPrefix(strings.SplitN(libraryCode, "\n", 2)[0], "#!lua name=")
+	libs, err := redisClient.FunctionList(ctx, redis.FunctionListQuery{LibraryNamePattern: library}).Result()
+	if err != nil || len(libs) != 1 {
+		t.Errorf("Expected library %s to be loaded, got: %v, %v", library, libs, err)
+	}
+}
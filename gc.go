@@ -0,0 +1,132 @@
+package arbiter
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// GCPolicy sets the retention of auxiliary data removed by Client.GC
+type GCPolicy struct {
+	// FenceRetention removes the fencing counters of free locks unused for longer.
+	// A lock taken again after its counter was removed restarts its fencing tokens,
+	// so keep it well above the lifetime of any token held by a resource. 0 keeps
+	// counters forever. It relies on OBJECT IDLETIME, which Redis does not report
+	// under LFU eviction policies.
+	FenceRetention time.Duration
+}
+
+// WithGCPolicy sets the retention policy of Client.GC
+func WithGCPolicy(policy GCPolicy) ClientOption {
+	return func(c *Client) {
+		c.gcPolicy = policy
+	}
+}
+
+// GC removes auxiliary data that accumulates next to locks: expired entries of waiter
+// queues and the namespace quota sets, heartbeat keys and permanent lock registrations
+// left behind by locks that no longer exist, and fencing counters past the retention of
+// the GC policy. It returns how many keys and entries were removed.
+func (c *Client) GC(ctx context.Context) (int, error) {
+	removed := 0
+	for _, backend := range c.backends() {
+		n, err := backend.gc(ctx)
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// RunGC calls GC every interval until ctx is done
+func (c *Client) RunGC(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := c.GC(ctx); err != nil {
+				c.logger.Error(ctx, "Failed to collect auxiliary keys, error: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Client) gc(ctx context.Context) (int, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	removed := 0
+
+	// Sorted sets scored by expiry, Redis deletes them once empty
+	var expiring []string
+	for _, kind := range []string{"held", "waiters"} {
+		shards, err := c.shardKeys(ctx, kind)
+		if err != nil {
+			return removed, err
+		}
+		expiring = append(expiring, shards...)
+	}
+	err := c.scanInternal(ctx, "queue:", func(key string) error {
+		expiring = append(expiring, key)
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	for _, key := range expiring {
+		n, err := replyInt(c.do(ctx, "ZREMRANGEBYSCORE", key, "-inf", now))
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+
+	permanent, err := c.shardKeys(ctx, "permanent")
+	if err != nil {
+		return removed, err
+	}
+	for _, key := range permanent {
+		n, err := replyInt(c.runScript(ctx, lua.CollectPermanent, []string{key}))
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+
+	orphans := map[string]time.Duration{"heartbeat:": 0}
+	if c.gcPolicy.FenceRetention > 0 {
+		orphans["fence:"] = c.gcPolicy.FenceRetention
+	}
+	for kind, retention := range orphans {
+		prefix := c.internalKey(kind)
+		err := c.scanInternal(ctx, kind, func(key string) error {
+			lockKey := c.key(strings.TrimPrefix(key, prefix))
+			idle := int64(math.Ceil(retention.Seconds()))
+			ok, err := c.runScriptBool(ctx, lua.CollectOrphan, []string{key, lockKey}, idle)
+			if ok {
+				removed++
+			}
+			return err
+		})
+		if err != nil {
+			return removed, err
+		}
+	}
+
+	if removed > 0 {
+		c.logger.Info(ctx, "Collected %d auxiliary keys and entries", removed)
+	}
+	return removed, nil
+}
+
+// scanInternal calls fn for every internal key starting with kind
+func (c *Client) scanInternal(ctx context.Context, kind string, fn func(key string) error) error {
+	return c.scan(ctx, escapeGlob(c.internalKey(kind))+"*", fn)
+}
@@ -0,0 +1,70 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGC(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-gc:"), WithGCPolicy(GCPolicy{FenceRetention: time.Second}))
+	ctx := context.Background()
+
+	held := client.NewLock("test-held")
+	if err := held.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer held.Unlock(ctx)
+
+	released := client.NewLock("test-released")
+	if err := released.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if err := released.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+
+	past := float64(time.Now().Add(-time.Minute).UnixMilli())
+	queue := client.queueKey(client.lockKey("test-released"))
+	redisClient.ZAdd(ctx, queue, redis.Z{Score: past, Member: "stale-waiter"})
+	redisClient.ZAdd(ctx, client.heldKey(queue), redis.Z{Score: past, Member: "stale-holder"})
+	redisClient.SAdd(ctx, client.permanentKey(queue), client.lockKey("test-gone"))
+	orphan := client.heartbeatKey(client.lockKey("test-gone"))
+	redisClient.Set(ctx, orphan, "owner", time.Minute)
+
+	removed, err := client.GC(ctx)
+	if err != nil {
+		t.Fatalf("Failed to collect: %v", err)
+	}
+	if removed != 4 {
+		t.Errorf("Expected 4 removed keys and entries, got: %d", removed)
+	}
+	for _, key := range []string{queue, client.heldKey(queue), client.permanentKey(queue), orphan} {
+		if n, _ := redisClient.Exists(ctx, key).Result(); n != 0 {
+			t.Errorf("Auxiliary key should be collected: %s", key)
+		}
+	}
+
+	// Fencing counters are collected once past their retention, unless the lock is held
+	releasedFence := client.fenceKey(client.lockKey("test-released"))
+	heldFence := client.fenceKey(client.lockKey("test-held"))
+	if n, _ := redisClient.Exists(ctx, releasedFence).Result(); n != 1 {
+		t.Fatal("Recent fencing counter should be kept")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := client.GC(ctx); err != nil {
+		t.Fatalf("Failed to collect: %v", err)
+	}
+	if n, _ := redisClient.Exists(ctx, releasedFence).Result(); n != 0 {
+		t.Error("Idle fencing counter of a free lock should be collected")
+	}
+	if n, _ := redisClient.Exists(ctx, heldFence).Result(); n != 1 {
+		t.Error("Fencing counter of a held lock should be kept")
+	}
+}
@@ -0,0 +1,81 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// RateLimitResult is the decision of a GCRA limiter, with the metadata of standard
+// rate limit headers such as RateLimit-Remaining and Retry-After
+type RateLimitResult struct {
+	// Allowed reports whether the events were admitted
+	Allowed bool
+	// Limit is the burst of the limiter
+	Limit int
+	// Remaining is how many more events would be admitted right away
+	Remaining int
+	// RetryAfter is how long until the events would be admitted, 0 if they were
+	RetryAfter time.Duration
+	// ResetAfter is how long until the limiter is back to a full burst
+	ResetAfter time.Duration
+}
+
+// GCRALimiter is a distributed limiter implementing the generic cell rate algorithm,
+// a leaky bucket that stores a single timestamp per name. Unlike RateLimiter it
+// returns the metadata of every decision, for rate limit headers of HTTP services.
+type GCRALimiter struct {
+	client *Client
+	name   string
+	key    string
+	rate   float64
+	burst  int
+	logger Logger
+}
+
+// NewGCRALimiter creates the GCRA limiter name admitting rate events per second with
+// bursts of up to burst events. Every process must use the same rate and burst.
+func (c *Client) NewGCRALimiter(name string, rate float64, burst int) *GCRALimiter {
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	return &GCRALimiter{
+		client: c,
+		name:   name,
+		key:    c.internalKey("gcra:" + name),
+		rate:   rate,
+		burst:  burst,
+		logger: c.logger,
+	}
+}
+
+// Allow decides whether one event is admitted now
+func (l *GCRALimiter) Allow(ctx context.Context) (RateLimitResult, error) {
+	return l.AllowN(ctx, 1)
+}
+
+// AllowN decides whether n events are admitted now, admitting all or none of them.
+// It returns ErrBurstExceeded if n exceeds the burst.
+func (l *GCRALimiter) AllowN(ctx context.Context, n int) (RateLimitResult, error) {
+	if err := l.client.policy.check(l.name); err != nil {
+		return RateLimitResult{}, err
+	}
+	if n > l.burst || l.rate <= 0 {
+		return RateLimitResult{}, ErrBurstExceeded
+	}
+
+	res, err := replyInts(l.client.runScript(ctx, lua.GCRA, []string{l.key},
+		l.burst, l.rate, time.Now().UnixMilli(), n))
+	if err != nil {
+		l.logger.Error(ctx, "Failed to decide on rate limiter: %s, error: %v", l.name, err)
+		return RateLimitResult{}, err
+	}
+
+	return RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      l.burst,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+		ResetAfter: time.Duration(res[3]) * time.Millisecond,
+	}, nil
+}
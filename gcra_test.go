@@ -0,0 +1,44 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGCRALimiter(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-gcra:"))
+	ctx := context.Background()
+
+	limiter := client.NewGCRALimiter("test-api", 10, 3)
+	redisClient.Del(ctx, limiter.key)
+
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Allow(ctx)
+		if err != nil || !res.Allowed {
+			t.Fatalf("Event %d should be admitted, got: %+v, %v", i+1, res, err)
+		}
+		if res.Remaining != 2-i || res.Limit != 3 {
+			t.Errorf("Event %d should leave %d, got: %+v", i+1, 2-i, res)
+		}
+	}
+
+	res, err := limiter.Allow(ctx)
+	if err != nil || res.Allowed {
+		t.Fatalf("Fourth event should be limited, got: %+v, %v", res, err)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 100*time.Millisecond {
+		t.Errorf("Retry should be possible within one interval, got: %v", res.RetryAfter)
+	}
+	if res.ResetAfter <= 200*time.Millisecond || res.ResetAfter > 300*time.Millisecond {
+		t.Errorf("Full burst should return after about 300ms, got: %v", res.ResetAfter)
+	}
+
+	time.Sleep(res.RetryAfter)
+	if res, err := limiter.Allow(ctx); err != nil || !res.Allowed {
+		t.Fatalf("Event should be admitted after RetryAfter, got: %+v, %v", res, err)
+	}
+}
@@ -0,0 +1,111 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrLockLost is the cause of the context of a Group whose lock was lost
+var ErrLockLost = errors.New("lock lost")
+
+// Group is a set of goroutines tied to the lease of a lock, like an errgroup.
+// Their context is cancelled with cause ErrLockLost when the watchdog fails to keep
+// the lease, and Unlock waits for them to finish before releasing the lock.
+// Goroutines of a group must therefore not call Unlock of their own lock.
+type Group struct {
+	cancel context.CancelCauseFunc
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// Go runs fn in a new goroutine of the group. The first non-nil error cancels the
+// context of the group and is returned by Wait.
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		if err := fn(); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel(err)
+			})
+		}
+	}()
+}
+
+// Wait blocks until every goroutine of the group returned and then returns the first error
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+// lockGroups tracks the groups of a lock
+type lockGroups struct {
+	mu     sync.Mutex
+	groups []*Group
+}
+
+func (l *lockImpl) Group(ctx context.Context) (*Group, context.Context) {
+	if outer := l.reentered(); outer != nil {
+		return outer.Group(ctx)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{cancel: cancel}
+
+	l.mu.Lock()
+	held := l.held.Load()
+	l.mu.Unlock()
+	if !held {
+		cancel(ErrLockNotHeld)
+		return g, ctx
+	}
+
+	l.groups.mu.Lock()
+	l.groups.groups = append(l.groups.groups, g)
+	l.groups.mu.Unlock()
+	return g, ctx
+}
+
+// waitGroups waits for the goroutines of every group of the lock and forgets the groups
+func (l *lockImpl) waitGroups() {
+	l.groups.mu.Lock()
+	groups := l.groups.groups
+	l.groups.groups = nil
+	l.groups.mu.Unlock()
+
+	for _, g := range groups {
+		g.wg.Wait()
+		g.cancel(context.Canceled)
+	}
+}
+
+// notifyLost cancels the groups of a lock whose lease is no longer kept, counts it lost
+// and calls the lost callback, once per acquisition
+func (l *lockImpl) notifyLost(ctx context.Context, err error) {
+	// A lost acquisition has ended, a reacquired one is held again
+	if err != ErrLockReacquired {
+		l.held.Store(false)
+	}
+	l.loseGroups()
+	if !l.lostNotified.CompareAndSwap(false, true) {
+		return
+	}
+	l.client.metrics.IncCounter(MetricLockLost, 1, LabelLock, l.label)
+	if l.options.OnLost != nil {
+		l.options.OnLost(ctx, err)
+	}
+}
+
+// loseGroups cancels the groups of a lock whose lease is no longer kept
+func (l *lockImpl) loseGroups() {
+	l.groups.mu.Lock()
+	defer l.groups.mu.Unlock()
+
+	for _, g := range l.groups.groups {
+		g.cancel(ErrLockLost)
+	}
+}
@@ -0,0 +1,87 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLockGroup(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-group:"))
+	ctx := context.Background()
+
+	t.Run("unlock waits for the group", func(t *testing.T) {
+		lock := client.NewLock("test-wait")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		var finished atomic.Bool
+		group, _ := lock.Group(ctx)
+		group.Go(func() error {
+			time.Sleep(100 * time.Millisecond)
+			finished.Store(true)
+			return nil
+		})
+
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+		if !finished.Load() {
+			t.Fatal("Unlock should wait for the goroutines of the group")
+		}
+	})
+
+	t.Run("lost lock cancels the group", func(t *testing.T) {
+		lock := client.NewLock("test-lost", WithWatchDog(true), WithWatchDogTimeout(300*time.Millisecond))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		group, groupCtx := lock.Group(ctx)
+		group.Go(func() error {
+			<-groupCtx.Done()
+			return context.Cause(groupCtx)
+		})
+
+		if err := client.Admin().ForceUnlock(ctx, "test-lost"); err != nil {
+			t.Fatalf("Failed to force unlock: %v", err)
+		}
+		if err := group.Wait(); err != ErrLockLost {
+			t.Fatalf("Expected lost lock error, got: %v", err)
+		}
+		lock.Unlock(ctx)
+	})
+
+	t.Run("first error cancels the group", func(t *testing.T) {
+		lock := client.NewLock("test-error")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer lock.Unlock(ctx)
+
+		failure := errors.New("failure")
+		group, groupCtx := lock.Group(ctx)
+		group.Go(func() error { return failure })
+		group.Go(func() error {
+			<-groupCtx.Done()
+			return nil
+		})
+
+		if err := group.Wait(); err != failure {
+			t.Fatalf("Expected first error, got: %v", err)
+		}
+	})
+
+	t.Run("lock not held", func(t *testing.T) {
+		_, groupCtx := client.NewLock("test-free").Group(ctx)
+		if err := context.Cause(groupCtx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held cause, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrReplicaRedis is returned by acquisitions when the Redis of the client is a replica.
+// Writable replicas accept locks the primary never sees and read-only ones serve stale
+// state, either of which silently breaks mutual exclusion.
+var ErrReplicaRedis = errors.New("redis client is connected to a replica")
+
+// roleGuard remembers the outcome of the replica check of a client
+type roleGuard struct {
+	disabled bool
+
+	// routingErr refuses a cluster client routing commands to replicas, unless
+	// replicaRouting allows it
+	replicaRouting bool
+	routingErr     error
+
+	mu      sync.Mutex
+	checked bool
+	err     error
+}
+
+// WithoutReplicaCheck skips verifying that the Redis of the client is a primary,
+// for deployments whose proxies do not support the ROLE command
+func WithoutReplicaCheck() ClientOption {
+	return func(c *Client) {
+		c.role.disabled = true
+	}
+}
+
+// WithReplicaRouting allows a cluster client with ReadOnly, RouteByLatency or
+// RouteRandomly, which routes read-only commands to replicas. The lock states read by
+// IsLocked, inspection and the state cache may then lag the primaries. Such clients,
+// including the ones of NewFailoverClusterClient and NewUniversalClient, are refused
+// otherwise.
+func WithReplicaRouting() ClientOption {
+	return func(c *Client) {
+		c.role.replicaRouting = true
+	}
+}
+
+// checkRouting refuses a go-redis cluster client that routes commands to replicas
+// unless WithReplicaRouting allows it. It runs once the client is configured.
+func (c *Client) checkRouting() {
+	if c.role.replicaRouting {
+		return
+	}
+	if e, ok := c.executor.(*goRedisExecutor); ok {
+		c.role.routingErr = routingError(e.redis)
+	}
+	if c.role.routingErr != nil {
+		c.logger.Error(context.Background(), "Refusing to acquire locks: %v", c.role.routingErr)
+	}
+}
+
+// routingError returns ErrReplicaRedis for a cluster client configured to send
+// read-only commands to replicas
+func routingError(rc redis.UniversalClient) error {
+	cluster, ok := rc.(*redis.ClusterClient)
+	if !ok {
+		return nil
+	}
+
+	var setting string
+	switch opt := cluster.Options(); {
+	case opt.RouteByLatency:
+		setting = "RouteByLatency"
+	case opt.RouteRandomly:
+		setting = "RouteRandomly"
+	case opt.ReadOnly:
+		setting = "ReadOnly"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: cluster client routes reads to replicas with %s, set WithReplicaRouting to allow it",
+		ErrReplicaRedis, setting)
+}
+
+// checkRole verifies once that the Redis of the client is a primary, refusing
+// configurations that route lock writes to replicas with a configuration error
+func (c *Client) checkRole(ctx context.Context) error {
+	if c.role.routingErr != nil {
+		return c.role.routingErr
+	}
+	if c.role.disabled || c.executor == nil {
+		return nil
+	}
+
+	c.role.mu.Lock()
+	defer c.role.mu.Unlock()
+
+	if c.role.checked {
+		return c.role.err
+	}
+
+	reply, err := c.do(ctx, "ROLE")
+	switch {
+	case isRedisError(err):
+		// Servers and proxies without ROLE cannot be verified, they are trusted
+		c.logger.Warn(ctx, "Cannot verify that Redis is a primary, error: %v", err)
+	case err != nil:
+		// Connection errors are not cached, the next acquisition checks again
+		return err
+	default:
+		role, _ := reply.([]interface{})
+		c.role.err = roleError(role)
+	}
+
+	c.role.checked = true
+	if c.role.err != nil {
+		c.logger.Error(ctx, "Refusing to acquire locks: %v", c.role.err)
+	}
+	return c.role.err
+}
+
+// isRedisError reports whether err was replied by the server, as opposed to a connection error
+func isRedisError(err error) bool {
+	var redisErr redis.Error
+	return errors.As(err, &redisErr) && err != redis.Nil
+}
+
+// roleError returns ErrReplicaRedis unless the ROLE reply reports a primary
+func roleError(reply []interface{}) error {
+	if len(reply) == 0 {
+		return fmt.Errorf("%w: empty ROLE reply", ErrReplicaRedis)
+	}
+	if role, _ := reply[0].(string); role != "master" {
+		return fmt.Errorf("%w: role %v", ErrReplicaRedis, reply[0])
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRoleError(t *testing.T) {
+	if err := roleError([]interface{}{"master", int64(0), []interface{}{}}); err != nil {
+		t.Errorf("Primary should be accepted, got: %v", err)
+	}
+	if err := roleError([]interface{}{"slave", "10.0.0.1", int64(6379), "connected", int64(0)}); !errors.Is(err, ErrReplicaRedis) {
+		t.Errorf("Replica should be refused, got: %v", err)
+	}
+	if err := roleError(nil); !errors.Is(err, ErrReplicaRedis) {
+		t.Errorf("Empty reply should be refused, got: %v", err)
+	}
+}
+
+func TestReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	clusterClient := func(opt *redis.ClusterOptions) redis.UniversalClient {
+		opt.Addrs = []string{"127.0.0.1:1"}
+		return redis.NewClusterClient(opt)
+	}
+
+	tests := []struct {
+		name  string
+		redis redis.UniversalClient
+	}{
+		{"ReadOnly", clusterClient(&redis.ClusterOptions{ReadOnly: true})},
+		{"RouteByLatency", clusterClient(&redis.ClusterOptions{RouteByLatency: true})},
+		{"RouteRandomly", clusterClient(&redis.ClusterOptions{RouteRandomly: true})},
+		{"failover RouteRandomly", redis.NewFailoverClusterClient(&redis.FailoverOptions{
+			MasterName: "mymaster", SentinelAddrs: []string{"127.0.0.1:1"}, RouteRandomly: true})},
+		{"universal ReadOnly", redis.NewUniversalClient(&redis.UniversalOptions{
+			Addrs: []string{"127.0.0.1:1", "127.0.0.1:2"}, ReadOnly: true})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.redis.Close()
+			client := NewClient(tt.redis, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+
+			_, err := client.NewLock("test-routing").TryLock(ctx)
+			if !errors.Is(err, ErrReplicaRedis) || !strings.Contains(err.Error(), "WithReplicaRouting") {
+				t.Fatalf("Expected ErrReplicaRedis naming the opt-out, got: %v", err)
+			}
+		})
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		rc := clusterClient(&redis.ClusterOptions{RouteRandomly: true})
+		defer rc.Close()
+		client := NewClient(rc, WithLogger(&NoopLogger{}), WithReplicaRouting(), WithoutReplicaCheck(),
+			WithStore(newMemoryStore()))
+
+		if acquired, err := client.NewLock("test-routing").TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// handoff hands locks between the goroutines of one process contending for the same
+// lock, so only one of them waits in Redis and the others queue locally in FIFO order
+type handoff struct {
+	maxBatch int
+
+	mu     sync.Mutex
+	queues map[string]*handoffQueue
+}
+
+// handoffQueue holds the local waiters behind the contender of one lock
+type handoffQueue struct {
+	waiters []chan handoffGrant
+	batch   int
+}
+
+// handoffGrant transfers a held lock, the zero value hands over the turn to acquire it in Redis
+type handoffGrant struct {
+	value string
+	fence int64
+	lease time.Duration
+}
+
+// WithLocalHandoff queues Lock calls of this client waiting for the same lock locally.
+// Only the first of them waits in Redis, and Unlock hands the still held lock to the next
+// local waiter without Redis traffic. The handing lock no longer holds it, a second
+// Unlock or Refresh fails with ErrLockNotHeld. The lease continues across handoffs, so
+// holders without watchdog should Refresh long leases. After maxBatch consecutive
+// handoffs the lock is released in Redis so other processes are not starved, 0 is
+// unlimited.
+// Locks taken with TryLock do not take part, and Lock on a held lock re-enters it at once.
+func WithLocalHandoff(maxBatch int) ClientOption {
+	return func(c *Client) {
+		c.handoff = &handoff{maxBatch: maxBatch, queues: make(map[string]*handoffQueue)}
+	}
+}
+
+// join registers a local contender for key. It returns nil if the caller is the first
+// and acquires in Redis, otherwise the channel its grant is delivered on.
+func (h *handoff) join(key string) chan handoffGrant {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	q, ok := h.queues[key]
+	if !ok {
+		h.queues[key] = &handoffQueue{}
+		return nil
+	}
+
+	ch := make(chan handoffGrant, 1)
+	q.waiters = append(q.waiters, ch)
+	return ch
+}
+
+// pass hands the held lock to the next local waiter unless the batch is exhausted,
+// reporting whether it did
+func (h *handoff) pass(key string, grant handoffGrant) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	q := h.queues[key]
+	if len(q.waiters) == 0 || (h.maxBatch > 0 && q.batch >= h.maxBatch) {
+		return false
+	}
+
+	next := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	q.batch++
+	next <- grant
+	return true
+}
+
+// leave is called by the contender that released the lock in Redis or gave up,
+// handing the turn to acquire in Redis to the next local waiter
+func (h *handoff) leave(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	q := h.queues[key]
+	if len(q.waiters) == 0 {
+		delete(h.queues, key)
+		return
+	}
+
+	next := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	q.batch = 0
+	next <- handoffGrant{}
+}
+
+// cancel removes a waiter that gave up. It returns false if a grant was already delivered.
+func (h *handoff) cancel(key string, ch chan handoffGrant) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	q := h.queues[key]
+	for i, waiter := range q.waiters {
+		if waiter == ch {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// lockLocal acquires the lock through the local handoff queue of the client
+func (l *lockImpl) lockLocal(ctx context.Context, deadline time.Time) error {
+	// A held lock re-enters in Redis, queuing behind itself would wait until the deadline
+	if l.held.Load() {
+		return l.lock(ctx, deadline)
+	}
+
+	h := l.client.handoff
+	if ch := h.join(l.key); ch != nil {
+		grant, err := l.awaitHandoff(ctx, ch, deadline)
+		if err != nil {
+			return err
+		}
+		if grant.value != "" {
+			l.adopt(ctx, grant)
+			l.logger.Info(ctx, "Acquired lock handed off locally: %s", l.key)
+			return nil
+		}
+	}
+
+	if err := l.lock(ctx, deadline); err != nil {
+		h.leave(l.key)
+		return err
+	}
+
+	l.mu.Lock()
+	l.contending = true
+	l.mu.Unlock()
+	return nil
+}
+
+// awaitHandoff waits for the grant of a local waiter
+func (l *lockImpl) awaitHandoff(ctx context.Context, ch chan handoffGrant, deadline time.Time) (handoffGrant, error) {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case grant := <-ch:
+		return grant, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		l.logger.Warn(ctx, "Timeout waiting for lock: %s", l.key)
+		err = ErrLockTimeout
+	}
+
+	if l.client.handoff.cancel(l.key, ch) {
+		return handoffGrant{}, err
+	}
+
+	// A grant arrived while giving up, pass it on
+	if grant := <-ch; grant.value != "" {
+		l.adopt(ctx, grant)
+		l.Unlock(context.WithoutCancel(ctx))
+	} else {
+		l.client.handoff.leave(l.key)
+	}
+	return handoffGrant{}, err
+}
+
+// adopt takes over a lock handed off by a local holder
+func (l *lockImpl) adopt(ctx context.Context, grant handoffGrant) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.value = grant.value
+	l.used = true
+	l.fence = grant.fence
+	l.lease.Store(int64(grant.lease))
+	l.held.Store(true)
+	l.contending = true
+	l.acquiredAt = acquireSite()
+
+	if l.options.EnableWatchDog || l.options.Permanent {
+		l.startWatchDog(ctx)
+	}
+}
+
+// handOff passes the held lock to the next local waiter, l.mu must be held
+func (l *lockImpl) handOff(ctx context.Context) bool {
+	if !l.contending || !l.held.Load() {
+		return false
+	}
+	if !l.client.handoff.pass(l.key, handoffGrant{value: l.value, fence: l.fence, lease: time.Duration(l.lease.Load())}) {
+		return false
+	}
+
+	l.stopWatchDog()
+	l.held.Store(false)
+	l.contending = false
+
+	// The adopter holds the lock under the value now, so a second Unlock or Refresh of l
+	// must not act on its behalf. A pinned owner token is shared by design.
+	if l.options.Owner == "" {
+		l.value = generateValue()
+		l.used = false
+	}
+	l.fence = 0
+	l.logger.Debug(ctx, "Handed off lock locally: %s", l.key)
+	return true
+}
+
+// leaveHandoff gives up the local contention of a lock released in Redis, l.mu must be held
+func (l *lockImpl) leaveHandoff() {
+	if l.contending {
+		l.contending = false
+		l.client.handoff.leave(l.key)
+	}
+}
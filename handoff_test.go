@@ -0,0 +1,213 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// handoffWaiters returns the number of local waiters queued for the named lock
+func handoffWaiters(c *Client, name string) int {
+	c.handoff.mu.Lock()
+	defer c.handoff.mu.Unlock()
+
+	if q, ok := c.handoff.queues[c.lockKey(name)]; ok {
+		return len(q.waiters)
+	}
+	return -1
+}
+
+func TestLocalHandoff(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	ctx := context.Background()
+
+	t.Run("waiters are served in order without releasing", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-handoff:"), WithLocalHandoff(0))
+		key := client.lockKey("test-fifo")
+
+		first := client.NewLock("test-fifo")
+		if err := first.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		owner := redisClient.HGet(ctx, key, ownerField).Val()
+
+		order := make(chan int, 2)
+		locks := []Lock{client.NewLock("test-fifo"), client.NewLock("test-fifo")}
+		for i, lock := range locks {
+			go func(i int, lock Lock) {
+				if err := lock.Lock(ctx); err != nil {
+					t.Errorf("Failed to acquire lock: %v", err)
+				}
+				order <- i
+			}(i, lock)
+			waitFor(t, func() bool { return handoffWaiters(client, "test-fifo") == i+1 })
+		}
+
+		if err := first.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to hand off lock: %v", err)
+		}
+		if got := <-order; got != 0 {
+			t.Fatalf("Expected first waiter to be served first, got: %d", got)
+		}
+		if redisClient.HGet(ctx, key, ownerField).Val() != owner {
+			t.Fatal("Handed off lock should keep its owner in Redis")
+		}
+
+		if err := locks[0].Unlock(ctx); err != nil {
+			t.Fatalf("Failed to hand off lock: %v", err)
+		}
+		if got := <-order; got != 1 {
+			t.Fatalf("Expected second waiter, got: %d", got)
+		}
+
+		if err := locks[1].Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-fifo"); locked {
+			t.Fatal("Last local holder should release the lock in Redis")
+		}
+		if n := handoffWaiters(client, "test-fifo"); n != -1 {
+			t.Fatalf("Queue should be removed, got %d waiters", n)
+		}
+	})
+
+	t.Run("batch limit releases in redis", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-handoff:"), WithLocalHandoff(1))
+
+		first := client.NewLock("test-batch")
+		if err := first.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		done := make(chan Lock, 2)
+		for i := 0; i < 2; i++ {
+			lock := client.NewLock("test-batch")
+			go func() {
+				if err := lock.Lock(ctx); err != nil {
+					t.Errorf("Failed to acquire lock: %v", err)
+				}
+				done <- lock
+			}()
+			waitFor(t, func() bool { return handoffWaiters(client, "test-batch") == i+1 })
+		}
+
+		first.Unlock(ctx)
+		second := <-done
+		owner := redisClient.HGet(ctx, client.lockKey("test-batch"), ownerField).Val()
+
+		second.Unlock(ctx)
+		third := <-done
+		if redisClient.HGet(ctx, client.lockKey("test-batch"), ownerField).Val() == owner {
+			t.Fatal("Exhausted batch should re-acquire the lock in Redis")
+		}
+		third.Unlock(ctx)
+	})
+
+	t.Run("waiter timeout", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-handoff:"), WithLocalHandoff(0))
+
+		holder := client.NewLock("test-timeout")
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		waiter := client.NewLock("test-timeout", WithWaitTimeout(100*time.Millisecond))
+		if err := waiter.Lock(ctx); err != ErrLockTimeout {
+			t.Fatalf("Expected timeout, got: %v", err)
+		}
+		if n := handoffWaiters(client, "test-timeout"); n != 0 {
+			t.Fatalf("Timed out waiter should leave the queue, got %d waiters", n)
+		}
+
+		if err := holder.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-timeout"); locked {
+			t.Fatal("Lock should be released in Redis")
+		}
+	})
+}
+
+func TestLocalHandoffReenter(t *testing.T) {
+	store := newMemoryStore()
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(store), WithLocalHandoff(0))
+	ctx := context.Background()
+
+	for _, acquire := range []string{"Lock", "TryLock"} {
+		lock := client.NewLock("test-reenter", WithWaitTimeout(time.Second))
+		if acquire == "Lock" {
+			if err := lock.Lock(ctx); err != nil {
+				t.Fatalf("Failed to acquire lock: %v", err)
+			}
+		} else if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Failed to acquire lock: %v, %v", acquired, err)
+		}
+
+		start := time.Now()
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Expected to re-enter lock taken with %s, got: %v", acquire, err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Re-entering lock taken with %s should not queue locally, took %v", acquire, elapsed)
+		}
+
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+		if owner, held := store.owners[client.lockKey("test-reenter")]; held {
+			t.Fatalf("Lock should be released in the store, held by %s", owner)
+		}
+	}
+	if n := handoffWaiters(client, "test-reenter"); n != -1 {
+		t.Fatalf("Queue should be removed, got %d waiters", n)
+	}
+}
+
+func TestLocalHandoffReleaserDetached(t *testing.T) {
+	store := newMemoryStore()
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(store), WithLocalHandoff(0))
+	ctx := context.Background()
+
+	releaser := client.NewLock("test-detached", WithWaitTimeout(time.Second))
+	if err := releaser.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	adopter := client.NewLock("test-detached", WithWaitTimeout(time.Second))
+	acquired := make(chan error, 1)
+	go func() { acquired <- adopter.Lock(ctx) }()
+	for handoffWaiters(client, "test-detached") != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := releaser.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to hand off lock: %v", err)
+	}
+	if err := <-acquired; err != nil {
+		t.Fatalf("Failed to adopt lock: %v", err)
+	}
+
+	// The releaser no longer speaks for the lock
+	if err := releaser.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld on a second Unlock, got: %v", err)
+	}
+	if err := releaser.Refresh(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld on Refresh after handing off, got: %v", err)
+	}
+	if fence := releaser.Fence(); fence != 0 {
+		t.Errorf("Expected no fence after handing off, got: %d", fence)
+	}
+
+	store.mu.Lock()
+	owner := store.owners[client.lockKey("test-detached")]
+	store.mu.Unlock()
+	if owner != adopter.(*lockImpl).value {
+		t.Fatalf("Expected the adopter to hold the lock, held by %q", owner)
+	}
+	if err := adopter.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release adopted lock: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrHashTagged is returned for operations that need a structure shared by all locks,
+// which hash-tagged keys split across slots
+var ErrHashTagged = errors.New("not supported with hash-tagged keys")
+
+// WithHashTag wraps lock names in Redis Cluster hash tags, so the key of a lock and the
+// keys kept beside it, such as its fencing counter, heartbeat and wait queue, land in
+// one slot and the multi-key scripts run on Redis Cluster with locks spread over all
+// slots. The keys of a fair semaphore or barrier share the slot of its name likewise.
+// Structures shared by all locks, like the frozen and held sets, are split into one
+// shard per lock in its slot: prefix freezes are refused with ErrHashTagged and
+// namespace quotas are not enforced. Keys change with the option, so every client of a
+// key prefix must agree on it.
+func WithHashTag() ClientOption {
+	return func(c *Client) {
+		c.hashTag = true
+	}
+}
+
+// slotKey returns the key of the structure kind shared by all locks as seen by scripts
+// on lockKey. With hash tags that is the shard of the structure in the slot of the lock.
+func (c *Client) slotKey(lockKey, kind string) string {
+	if !c.hashTag {
+		return c.internalKey(kind)
+	}
+	return c.internalKey(kind + ":" + strings.TrimPrefix(lockKey, c.prefix))
+}
+
+// primitiveKey returns the base key of the primitive kind called name. With hash tags
+// the name is wrapped like a lock name, so the keys a script derives from the base key
+// land in one slot.
+func (c *Client) primitiveKey(kind, name string) string {
+	if c.hashTag {
+		name = "{" + name + "}"
+	}
+	return c.internalKey(kind + ":" + name)
+}
+
+// shardKeys returns the keys of the structure kind shared by all locks, every shard of
+// it with hash tags
+func (c *Client) shardKeys(ctx context.Context, kind string) ([]string, error) {
+	if !c.hashTag {
+		return []string{c.internalKey(kind)}, nil
+	}
+
+	var keys []string
+	err := c.scanInternal(ctx, kind+":{", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
+
+// lockName returns the name of the lock stored under lockKey
+func (c *Client) lockName(lockKey string) string {
+	name := strings.TrimPrefix(lockKey, c.prefix)
+	if c.hashTag {
+		name = strings.TrimSuffix(strings.TrimPrefix(name, "{"), "}")
+	}
+	return name
+}
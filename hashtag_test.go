@@ -0,0 +1,115 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// hashSlotTag returns the part of key Redis Cluster hashes, the whole key without a tag
+func hashSlotTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+func TestHashTagKeys(t *testing.T) {
+	client := NewClient(redis.NewClient(&redis.Options{}), WithKeyPrefix("app:"), WithHashTag(),
+		WithNamespaceQuota("tenant", NamespaceQuota{MaxHeld: 1}))
+
+	key := client.lockKey("orders")
+	if key != "app:{orders}" {
+		t.Fatalf("Expected hash-tagged key, got: %s", key)
+	}
+	if name := client.lockName(key); name != "orders" {
+		t.Errorf("Expected lock name orders, got: %s", name)
+	}
+
+	related := []string{
+		client.fenceKey(key), client.heartbeatKey(key), client.queueKey(key), client.backoffKey(key),
+		client.tombstoneKey(key), client.frozenKey(key), client.frozenPrefixKey(key),
+		client.permanentKey(key), client.heldKey(key), client.waitersKey(key), client.rateKey(key, time.Now()),
+	}
+	for _, k := range related {
+		if tag := hashSlotTag(k); tag != "orders" {
+			t.Errorf("Expected %s in the slot of orders, got tag: %s", k, tag)
+		}
+	}
+
+	// The keys of a multi-key primitive share the slot of its name
+	primitives := map[string][]string{
+		"semaphore": client.NewFairSemaphore("orders", 1).(*fairSemaphore).keys,
+		"barrier":   client.newBarrier("orders", 2, false, nil).keys,
+	}
+	for kind, keys := range primitives {
+		for _, k := range keys {
+			if tag := hashSlotTag(k); tag != "orders" {
+				t.Errorf("Expected %s key %s in the slot of orders, got tag: %s", kind, k, tag)
+			}
+		}
+	}
+
+	if _, err := client.frozenSet("orders*"); !errors.Is(err, ErrHashTagged) {
+		t.Errorf("Expected prefix freeze to be refused, got: %v", err)
+	}
+	if set, err := client.frozenSet("orders"); err != nil || set != client.frozenKey(key) {
+		t.Errorf("Expected exact freeze in the shard of the lock, got: %s, %v", set, err)
+	}
+	if quota := client.Namespace("tenant").quota(); quota.MaxHeld != 0 {
+		t.Errorf("Expected quotas not to be enforced, got: %+v", quota)
+	}
+
+	// Without the option keys are unchanged
+	plain := NewClient(redis.NewClient(&redis.Options{}), WithKeyPrefix("app:"))
+	if key := plain.lockKey("orders"); key != "app:orders" || plain.lockName(key) != "orders" {
+		t.Errorf("Expected plain key, got: %s", key)
+	}
+	if key := plain.heldKey(plain.lockKey("orders")); key != plain.internalKey("held") {
+		t.Errorf("Expected shared held set, got: %s", key)
+	}
+}
+
+func TestHashTag(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-hashtag:"), WithHashTag())
+	ctx := context.Background()
+	admin := client.Admin()
+
+	lock := client.NewLock("test-tagged", WithPermanent(time.Second))
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	held, err := admin.ListLocks(ctx)
+	if err != nil || len(held) != 1 || held[0].Name != "test-tagged" {
+		t.Errorf("Expected test-tagged to be listed, got: %+v, %v", held, err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+
+	if err := admin.Freeze(ctx, "test-tagged"); err != nil {
+		t.Fatalf("Failed to freeze lock: %v", err)
+	}
+	defer admin.Unfreeze(ctx, "test-tagged")
+	if _, err := client.NewLock("test-tagged").TryLock(ctx); !errors.Is(err, ErrLockFrozen) {
+		t.Errorf("Expected ErrLockFrozen, got: %v", err)
+	}
+	if frozen, err := admin.Frozen(ctx); err != nil || len(frozen) != 1 || frozen[0] != "test-tagged" {
+		t.Errorf("Expected frozen test-tagged, got: %v, %v", frozen, err)
+	}
+	if err := admin.Freeze(ctx, "test-*"); !errors.Is(err, ErrHashTagged) {
+		t.Errorf("Expected ErrHashTagged, got: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+)
+
+// IDAllocator hands out unique sequential IDs from blocks leased from Redis, so
+// processes generate IDs at high throughput without a Redis call per ID. IDs are
+// unique across all processes sharing the name and increase within a process, but
+// processes interleave, and the rest of a block is skipped when a process exits.
+type IDAllocator struct {
+	client *Client
+	name   string
+	key    string
+	block  int64
+	logger Logger
+
+	mu   sync.Mutex
+	next int64
+	end  int64
+}
+
+// NewIDAllocator creates the ID allocator name leasing blocks of block IDs at a time.
+// IDs start at 1.
+func (c *Client) NewIDAllocator(name string, block int64) *IDAllocator {
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	return &IDAllocator{
+		client: c,
+		name:   name,
+		key:    c.internalKey("ids:" + name),
+		block:  max(block, 1),
+		logger: c.logger,
+	}
+}
+
+// Next returns the next ID, leasing a new block once the current one is used up
+func (a *IDAllocator) Next(ctx context.Context) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.next == a.end {
+		if err := a.lease(ctx); err != nil {
+			return 0, err
+		}
+	}
+	a.next++
+	return a.next, nil
+}
+
+// lease reserves the next block with a single INCRBY, a.mu must be held
+func (a *IDAllocator) lease(ctx context.Context) error {
+	if err := a.client.policy.check(a.name); err != nil {
+		return err
+	}
+	end, err := replyInt(a.client.do(ctx, "INCRBY", a.key, a.block))
+	if err != nil {
+		a.logger.Error(ctx, "Failed to lease ID block: %s, error: %v", a.name, err)
+		return err
+	}
+	a.next, a.end = end-a.block, end
+	a.logger.Debug(ctx, "Leased IDs %d to %d: %s", a.next+1, a.end, a.name)
+	return nil
+}
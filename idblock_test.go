@@ -0,0 +1,51 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestIDAllocator(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-ids:"))
+	ctx := context.Background()
+	redisClient.Del(ctx, client.NewIDAllocator("test-orders", 1).key)
+
+	var (
+		mu  sync.Mutex
+		ids = make(map[int64]bool)
+		wg  sync.WaitGroup
+	)
+	for p := 0; p < 3; p++ {
+		allocator := client.NewIDAllocator("test-orders", 10)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				id, err := allocator.Next(ctx)
+				if err != nil {
+					t.Errorf("Next failed: %v", err)
+					return
+				}
+				mu.Lock()
+				if ids[id] {
+					t.Errorf("Duplicate ID %d", id)
+				}
+				ids[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(ids) != 75 {
+		t.Errorf("Expected 75 unique IDs, got %d", len(ids))
+	}
+	// Three blocks of ten per allocator
+	if leased, _ := redisClient.Get(ctx, client.NewIDAllocator("test-orders", 1).key).Int64(); leased != 90 {
+		t.Errorf("Expected 90 leased IDs, got %d", leased)
+	}
+}
@@ -3,169 +3,722 @@ package arbiter
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/redis/go-redis/v9"
-
 	"github.com/huimingz/arbiter/internal/lua"
 )
 
 var (
 	ErrLockNotHeld = errors.New("lock not held")
 	ErrLockTimeout = errors.New("lock timeout")
+	ErrLockFrozen  = errors.New("lock frozen")
+
+	// ErrReservedLockName is returned by operations on locks whose name collides with internal keys
+	ErrReservedLockName = errors.New("reserved lock name")
+
+	// ErrLockReacquired is returned by Refresh of an auto re-acquiring lock whose lease lapsed
+	// and was taken by another owner before it was re-acquired. The lock is held again.
+	ErrLockReacquired = errors.New("lock reacquired after another owner")
+
+	// ErrAttemptTimeout is returned by acquisitions whose round trip to Redis took longer
+	// than the attempt timeout, e.g. because the backend hangs
+	ErrAttemptTimeout = errors.New("lock attempt timed out")
+
+	// ErrInvalidLease is returned by acquisitions whose lease rounds to zero without
+	// WithNoExpiry, or that are stored without expiry but have no heartbeat
+	ErrInvalidLease = errors.New("invalid lease")
+
+	// ErrNotReplicated is returned by acquisitions with WithReplicationWait that did not
+	// reach enough replicas in time. The lock was released again.
+	ErrNotReplicated = errors.New("lock not replicated")
+
+	// ErrMaxRetries is returned by Lock calls with WithMaxRetries that retried as often as
+	// allowed without acquiring the lock
+	ErrMaxRetries = errors.New("lock max retries exceeded")
 )
 
 type lockImpl struct {
-	redis   *redis.Client
+	client  *Client
+	store   Store
 	name    string
+	label   string
+	key     string
+	frozen  []string
+	aux     []string
+	fences  string
 	value   string
 	options *LockOptions
 	logger  Logger
 
-	watchDogCtx    context.Context
+	// optionsErr refuses every acquisition of options the client cannot serve
+	optionsErr error
+
 	watchDogCancel context.CancelFunc
-	watchDogOnce   sync.Once
 	watchDogDone   chan struct{}
 
+	// held is cleared when the lock is released or lost, the watchdog clears it
+	// without l.mu. held and acquiredAt feed the leak detector of arbiterdebug builds.
+	held       atomic.Bool
+	acquiredAt string
+
+	// outer is the lock of the same key l re-entered through the context, reentries
+	// counts the re-entries not yet undone by Unlock
+	outer     *lockImpl
+	reentries int
+
+	// used is set once value was granted, the next acquisition after the lock was
+	// released or lost draws a new value
+	used bool
+
+	// fence is the fencing token of the current acquisition
+	fence int64
+
+	// lease is the lease set by the last acquisition or refresh
+	lease atomic.Int64
+
+	// lostNotified is set once the lost callback ran for the current acquisition
+	lostNotified atomic.Bool
+
+	// expires is when the lease of the last acquisition or refresh lapses, in Unix milliseconds
+	expires atomic.Int64
+
+	// contending is set while the lock takes part in the local handoff queue of the client
+	contending bool
+
+	groups lockGroups
+
 	mu sync.Mutex
 }
 
-func newLock(redis *redis.Client, name string, options *LockOptions, logger Logger) Lock {
-	return &lockImpl{
-		redis:        redis,
-		name:         name,
-		value:        generateValue(),
-		options:      options,
-		logger:       logger,
-		watchDogDone: make(chan struct{}),
+func newLock(c *Client, name string, options *LockOptions) Lock {
+	key := c.lockKey(name)
+	l := &lockImpl{
+		client:  c,
+		store:   c.store,
+		name:    name,
+		label:   c.cardinality.coalesce(c, name),
+		key:     key,
+		frozen:  []string{c.frozenKey(key), c.frozenPrefixKey(key)},
+		aux:     []string{c.heartbeatKey(key), c.permanentKey(key), c.heldKey(key)},
+		fences:  c.fenceKey(key),
+		value:   options.Owner,
+		options: options,
+		logger:  c.logger,
+	}
+	if l.value == "" {
+		l.value = generateValue()
 	}
+	if options.ReplicationReplicas > 0 {
+		if l.optionsErr = c.checkReplication(); l.optionsErr != nil {
+			l.logger.Error(context.Background(), "Lock cannot be acquired: %s, error: %v", key, l.optionsErr)
+		}
+	}
+	trackLeaks(l)
+	return l
 }
 
 func (l *lockImpl) Lock(ctx context.Context) error {
-	deadline := time.Now().Add(l.options.WaitTimeout)
-	l.logger.Debug(ctx, "Attempting to acquire lock: %s", l.name)
+	if l.reenter(ctx) {
+		return nil
+	}
+	deadline, charge := waitDeadline(ctx, l.options.WaitTimeout)
+	defer charge()
+	return l.lockUntil(ctx, l.contextWaitDeadline(ctx, deadline))
+}
 
-	attempt := 0
-	for {
-		attempt++
-		acquired, err := l.TryLock(ctx)
+func (l *lockImpl) TryLockFor(ctx context.Context, wait time.Duration) (bool, error) {
+	if l.reenter(ctx) {
+		return true, nil
+	}
+	if wait <= 0 {
+		return l.TryLock(ctx)
+	}
+	deadline, charge := waitDeadline(ctx, wait)
+	defer charge()
+
+	err := l.lockUntil(ctx, deadline)
+	if errors.Is(err, ErrLockTimeout) || errors.Is(err, ErrMaxRetries) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// lockUntil acquires the lock, waiting until deadline unless it is the zero time
+func (l *lockImpl) lockUntil(ctx context.Context, deadline time.Time) error {
+	l.logger.Debug(ctx, "Attempting to acquire lock: %s", l.key)
+
+	start := time.Now()
+	var err error
+	if l.client.handoff != nil {
+		err = l.lockLocal(ctx, deadline)
+	} else {
+		err = l.lock(ctx, deadline)
+	}
+	if err == nil {
+		l.client.metrics.IncCounter(MetricLockAcquisitions, 1, LabelLock, l.label)
+		l.client.metrics.Observe(MetricLockWait, time.Since(start).Seconds(), LabelLock, l.label)
+	}
+	return err
+}
+
+// lock acquires the lock in Redis, retrying until deadline unless it is the zero time.
+// Waiting calls are woken by releases of the lock unless retries are spaced by
+// coordinated backoff.
+func (l *lockImpl) lock(ctx context.Context, deadline time.Time) error {
+	var (
+		released <-chan struct{}
+		queued   time.Time
+		retries  int
+	)
+	for attempt := 1; ; attempt++ {
+		acquired, holder, err := l.TryLockInfo(ctx)
 		if err != nil {
-			l.logger.Error(ctx, "Failed to acquire lock: %s, error: %v", l.name, err)
+			l.logger.Error(ctx, "Failed to acquire lock: %s, error: %v", l.key, err)
 			return err
 		}
 		if acquired {
-			l.logger.Info(ctx, "Successfully acquired lock: %s", l.name)
+			l.logger.Info(ctx, "Successfully acquired lock: %s", l.key)
 			return nil
 		}
 
-		if l.options.WaitTimeout > 0 && time.Now().After(deadline) {
-			l.logger.Warn(ctx, "Timeout waiting for lock: %s", l.name)
+		if waitExpired(deadline) {
+			l.logger.Warn(ctx, "Timeout waiting for lock: %s%s", l.key, heldFor(holder))
 			return ErrLockTimeout
 		}
+		if l.options.retriesExhausted(retries) {
+			l.logger.Warn(ctx, "Gave up waiting for lock after %d retries: %s%s", retries, l.key, heldFor(holder))
+			return ErrMaxRetries
+		}
+
+		if err := l.client.enterWait(ctx, l.key, l.value); err != nil {
+			l.logger.Warn(ctx, "Failed to wait for lock: %s, error: %v", l.key, err)
+			return err
+		}
+		// Wait structures are kept in Redis, a client running on a store has none
+		if attempt == 1 && l.client.executor != nil {
+			// Removed in one call as soon as the wait ends, also when ctx was cancelled
+			defer l.client.leaveWait(context.WithoutCancel(ctx), l.key, l.value)
+		}
+		if time.Since(queued) >= waiterTTL/2 && l.client.executor != nil {
+			l.enterQueue(ctx)
+			queued = time.Now()
+		}
+
+		if attempt == 1 && l.options.BackoffSpacing <= 0 {
+			var stop func()
+			released, stop = l.watchReleases(ctx)
+			defer stop()
+
+			// A release before the watch started would be missed, retry right away
+			if released != nil {
+				continue
+			}
+		}
+		retries++
+		delay := l.retryDelay(ctx, retries)
+		if released != nil && l.options.RetryStrategy == nil {
+			// Releases wake the call, the timer only catches lapsed leases
+			delay = releaseWait
+			if l.options.RetryInterval > 0 {
+				delay = l.options.RetryInterval
+			}
+			if holder.TTL > 0 {
+				delay = min(delay, holder.TTL)
+			}
+		}
 
+		// Spread retries never sleep past the deadline
+		if !deadline.IsZero() {
+			delay = min(delay, max(time.Until(deadline), 0))
+		}
+		// The last retry is planned to finish before ctx expires
+		if cutoff := l.attemptCutoff(ctx); !cutoff.IsZero() {
+			remaining := time.Until(cutoff)
+			if remaining <= 0 {
+				l.logger.Warn(ctx, "Context deadline reached waiting for lock: %s", l.key)
+				return l.errDeadline()
+			}
+			delay = min(delay, remaining)
+		}
 		select {
 		case <-ctx.Done():
-			l.logger.Debug(ctx, "Context cancelled while waiting for lock: %s", l.name)
+			l.logger.Debug(ctx, "Context cancelled while waiting for lock: %s", l.key)
 			return ctx.Err()
-		case <-time.After(100 * time.Millisecond): // retry delay
-			continue
+		case <-released:
+		case <-time.After(delay):
 		}
 	}
 }
 
 func (l *lockImpl) TryLock(ctx context.Context) (bool, error) {
+	if l.reenter(ctx) {
+		return true, nil
+	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	leaseTime := l.options.LeaseTime
-	if l.options.EnableWatchDog {
-		leaseTime = l.options.WatchDogTimeout
+	return l.tryLock(ctx)
+}
+
+func (l *lockImpl) TryLockInfo(ctx context.Context) (bool, LockInfo, error) {
+	if l.reenter(ctx) {
+		return true, LockInfo{Name: l.name}, nil
 	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	ok, err := l.redis.Eval(ctx, lua.TryLock, []string{l.name}, l.value, leaseTime.Milliseconds()).Bool()
-	if err != nil {
-		l.logger.Error(ctx, "Error trying to acquire lock: %s", l.name)
+	holder := LockInfo{Name: l.name}
+	acquired, err := l.tryLockHolder(ctx, &holder)
+	return acquired, holder, err
+}
+
+// tryLock attempts one acquisition, l.mu must be held
+func (l *lockImpl) tryLock(ctx context.Context) (bool, error) {
+	return l.tryLockHolder(ctx, nil)
+}
+
+// tryLockHolder attempts one acquisition like tryLock. If holder is not nil and another
+// owner holds the lock, it reads the holder into it in the same round trip.
+func (l *lockImpl) tryLockHolder(ctx context.Context, holder *LockInfo) (bool, error) {
+	l.renewOwner()
+	if err := l.client.policy.check(l.name); err != nil {
+		l.logger.Warn(ctx, "Rejected acquisition of lock: %s, error: %v", l.key, err)
 		return false, err
 	}
-	if !ok {
+	if err := l.client.checkRole(ctx); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	lease := l.leaseTime()
+	if err := l.checkLease(ctx, lease); err != nil {
+		return false, err
+	}
+	if l.optionsErr != nil {
+		return false, l.optionsErr
+	}
+	start := time.Now()
+	res, err := l.attempt(ctx, LeaseRequest{
+		Key:        l.key,
+		Name:       l.name,
+		Owner:      l.value,
+		Lease:      lease,
+		Heartbeat:  l.options.HeartbeatTimeout,
+		Now:        now,
+		Expires:    time.UnixMilli(l.leaseExpiry(now, lease)),
+		ReadHolder: holder != nil,
+	})
+	switch {
+	case errors.Is(err, ErrLockFrozen):
+		l.logger.Warn(ctx, "Rejected acquisition of frozen lock: %s", l.key)
+		return false, err
+	case errors.Is(err, ErrQuotaExceeded):
+		return false, err
+	case err != nil:
+		l.logger.Error(ctx, "Error trying to acquire lock: %s, error: %v", l.key, err)
+		return false, err
+	}
+	l.client.observe("acquire", start)
+	if !res.Acquired {
+		if holder != nil && res.Holder.Held {
+			holder.Held, holder.Owner = true, res.Holder.Owner
+			holder.TTL, holder.Fence = res.Holder.TTL, res.Holder.Fence
+		}
 		return false, nil
 	}
+	if want := l.options.ReplicationReplicas; want > 0 && res.Replicated < want && !l.held.Load() {
+		l.logger.Warn(ctx, "Lock reached %d of %d replicas, releasing: %s", res.Replicated, want, l.key)
+		if _, err := l.store.Release(context.WithoutCancel(ctx), l.key, l.value); err != nil {
+			l.logger.Error(ctx, "Error releasing unreplicated lock: %s, error: %v", l.key, err)
+		}
+		return false, fmt.Errorf("%w: %d of %d replicas acknowledged", ErrNotReplicated, res.Replicated, want)
+	}
+	l.granted(ctx, now, lease, res.Fence)
+	return true, nil
+}
+
+// heldFor describes the holder read by a failed attempt for log messages, e.g.
+// ", held by <owner> for another 1.5s", or returns "" if it was not read
+func heldFor(holder LockInfo) string {
+	switch {
+	case !holder.Held:
+		return ""
+	case holder.TTL <= 0:
+		return fmt.Sprintf(", held by %s without expiry", holder.Owner)
+	}
+	return fmt.Sprintf(", held by %s for another %s", holder.Owner, holder.TTL)
+}
+
+// steal takes the lock over regardless of its owner and returns the previous owner
+func (l *lockImpl) steal(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	if l.options.EnableWatchDog {
-		l.logger.Debug(ctx, "Starting watchdog for lock: %s", l.name)
+	if err := l.client.policy.check(l.name); err != nil {
+		return "", err
+	}
+	if err := l.client.checkRole(ctx); err != nil {
+		return "", err
+	}
+	l.renewOwner()
+
+	now := time.Now()
+	lease := l.leaseTime()
+	if err := l.checkLease(ctx, lease); err != nil {
+		return "", err
+	}
+	keys := append(append([]string{l.key}, l.aux...), l.fences)
+	reply, err := l.client.runScript(ctx, lua.Steal, keys, l.value, lease.Milliseconds(),
+		l.options.HeartbeatTimeout.Milliseconds(), l.client.eventsChannel(),
+		l.leaseExpiry(now, lease), l.client.quota().MaxHeld)
+	res, _ := reply.([]interface{})
+	if err == nil && len(res) < 2 {
+		err = fmt.Errorf("unexpected reply: %v", reply)
+	}
+	if err != nil {
+		l.logger.Error(ctx, "Failed to steal lock: %s, error: %v", l.key, err)
+		return "", err
+	}
+
+	fence, _ := res[0].(int64)
+	previous, _ := res[1].(string)
+	l.granted(ctx, now, lease, fence)
+	return previous, nil
+}
+
+// renewOwner draws a new owner value for a new acquisition if the current one was
+// granted before, so a late release of an earlier acquisition, e.g. a retried call,
+// cannot release the new one. Values set WithOwnerToken are kept, l.mu must be held.
+func (l *lockImpl) renewOwner() {
+	if l.held.Load() || !l.used || l.options.Owner != "" {
+		return
+	}
+	l.stopWatchDog()
+	l.value = generateValue()
+	l.used = false
+}
+
+// pinOwner sets the owner value of every acquisition to value, like WithOwnerToken
+func (l *lockImpl) pinOwner(value string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.value = value
+	l.options.Owner = value
+	l.used = false
+}
+
+// granted records an acquisition made at now and starts the watchdog, l.mu must be held
+func (l *lockImpl) granted(ctx context.Context, now time.Time, lease time.Duration, fence int64) {
+	l.held.Store(true)
+	l.used = true
+	l.acquiredAt = acquireSite()
+	l.fence = fence
+	l.lostNotified.Store(false)
+	l.lease.Store(int64(lease))
+	l.expires.Store(l.leaseExpiry(now, lease))
+	l.holdTombstone(ctx, fence, now)
+
+	if l.options.EnableWatchDog || l.options.Permanent {
+		l.logger.Debug(ctx, "Starting watchdog for lock: %s", l.key)
 		l.startWatchDog(ctx)
 	}
+}
 
-	return true, nil
+// readHolder fills holder from the holder table reported by the TryLock script
+func readHolder(holder *LockInfo, raw interface{}) {
+	fields, _ := raw.([]interface{})
+	if len(fields) < 4 {
+		return
+	}
+
+	holder.Held = true
+	holder.Owner, _ = fields[1].(string)
+	if ttl, _ := fields[2].(int64); ttl > 0 {
+		holder.TTL = time.Duration(ttl) * time.Millisecond
+	}
+	fence, _ := fields[3].(string)
+	holder.Fence, _ = strconv.ParseInt(fence, 10, 64)
 }
 
 func (l *lockImpl) Unlock(ctx context.Context) error {
+	// The lease stays valid while the goroutines of its groups finish
+	l.waitGroups()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if l.watchDogCancel != nil {
-		l.watchDogCancel()
-		<-l.watchDogDone
+	// The lock re-entered through the context stays held by its outer holder
+	if l.leave() {
+		return nil
 	}
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+
+	if l.handOff(ctx) {
+		return nil
+	}
+	defer l.leaveHandoff()
+
+	l.stopWatchDog()
 
-	ok, err := l.redis.Eval(ctx, lua.Unlock, []string{l.name}, l.value).Bool()
+	ok, err := l.store.Release(ctx, l.key, l.value)
 	if err != nil {
-		l.logger.Error(ctx, "Error releasing lock: %s", l.name)
+		l.logger.Error(ctx, "Error releasing lock: %s", l.key)
 		return err
 	}
+	l.held.Store(false)
 	if !ok {
 		return ErrLockNotHeld
 	}
+	l.client.buryTombstone(ctx, l.key, l.value, l.fence, ReasonReleased)
 
-	l.logger.Info(ctx, "Released lock: %s", l.name)
+	l.logger.Info(ctx, "Released lock: %s", l.key)
 	return nil
 }
 
+func (l *lockImpl) Fence() int64 {
+	if outer := l.reentered(); outer != nil {
+		return outer.Fence()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.held.Load() {
+		return 0
+	}
+	return l.fence
+}
+
 func (l *lockImpl) Refresh(ctx context.Context) error {
+	if outer := l.reentered(); outer != nil {
+		return outer.Refresh(ctx)
+	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	leaseTime := l.options.LeaseTime
-	if l.options.EnableWatchDog {
-		leaseTime = l.options.WatchDogTimeout
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+
+	held := l.held.Load()
+	err := l.refresh(ctx)
+	if err == ErrLockNotHeld && l.options.AutoReacquire {
+		err = l.reacquire(ctx)
 	}
+	if held && (err == ErrLockNotHeld || err == ErrLockReacquired) {
+		l.notifyLost(ctx, err)
+	}
+	if err == ErrLockNotHeld {
+		l.fence = 0
+	}
+	return err
+}
 
-	ok, err := l.redis.Eval(ctx, lua.Refresh, []string{l.name}, l.value, leaseTime.Milliseconds()).Bool()
+// reacquire takes a lapsed lease again. It returns ErrLockReacquired if another
+// owner held the lock in between, detected by a skipped fencing token.
+func (l *lockImpl) reacquire(ctx context.Context) error {
+	previous := l.fence
+	acquired, err := l.tryLock(ctx)
 	if err != nil {
-		l.logger.Error(ctx, "Error refreshing lock: %s", l.name)
 		return err
 	}
+	if !acquired {
+		l.held.Store(false)
+		return ErrLockNotHeld
+	}
+
+	if l.fence != previous+1 {
+		l.logger.Warn(ctx, "Reacquired lapsed lock after other owners: %s, fence %d -> %d", l.key, previous, l.fence)
+		return ErrLockReacquired
+	}
+	l.logger.Debug(ctx, "Reacquired lapsed lock: %s", l.key)
+	return nil
+}
+
+// refresh extends the lease, or the heartbeat of a permanent lock, without taking l.mu
+// so the watchdog can run while Unlock waits for it to stop
+func (l *lockImpl) refresh(ctx context.Context) error {
+	return l.extend(ctx, l.leaseTime())
+}
+
+// extend sets the remaining lease to lease, refreshing the heartbeat of a permanent lock
+func (l *lockImpl) extend(ctx context.Context, lease time.Duration) error {
+	now := time.Now()
+	start := time.Now()
+	ok, err := l.store.Extend(ctx, LeaseRequest{
+		Key:       l.key,
+		Name:      l.name,
+		Owner:     l.value,
+		Lease:     lease,
+		Heartbeat: l.options.HeartbeatTimeout,
+		Now:       now,
+		Expires:   time.UnixMilli(l.leaseExpiry(now, lease)),
+	})
+	if err != nil {
+		l.logger.Error(ctx, "Error refreshing lock: %s", l.key)
+		return err
+	}
+	l.client.observe("refresh", start)
+	l.lease.Store(int64(lease))
 	if !ok {
 		return ErrLockNotHeld
 	}
+	l.expires.Store(l.leaseExpiry(now, lease))
+	l.holdTombstone(ctx, 0, now)
 
+	if l.options.OnRefresh != nil {
+		l.options.OnRefresh(ctx, lease)
+	}
 	return nil
 }
 
+// leaseTime returns the key TTL to set, 0 for permanent locks
+func (l *lockImpl) leaseTime() time.Duration {
+	switch {
+	case l.options.Permanent:
+		return 0
+	case l.options.AutoLeaseMax > 0:
+		return min(max(l.client.RecommendedLease(), l.options.AutoLeaseMin), l.options.AutoLeaseMax)
+	case l.options.EnableWatchDog:
+		return l.client.brownoutLease(l.options.WatchDogTimeout)
+	default:
+		return l.client.brownoutLease(l.options.LeaseTime)
+	}
+}
+
+// checkLease refuses leases the scripts would store without expiry by accident: a
+// lease rounding to zero milliseconds, or no expiry without a heartbeat to reap by
+func (l *lockImpl) checkLease(ctx context.Context, lease time.Duration) error {
+	var err error
+	switch {
+	case l.options.Permanent && l.options.HeartbeatTimeout < time.Millisecond:
+		err = fmt.Errorf("%w: no expiry without a heartbeat, set WithHeartbeat", ErrInvalidLease)
+	case !l.options.Permanent && lease < time.Millisecond:
+		err = fmt.Errorf("%w: lease %v rounds to no expiry, set WithNoExpiry to opt in", ErrInvalidLease, lease)
+	}
+	if err != nil {
+		l.logger.Error(ctx, "Refusing to acquire lock: %s, error: %v", l.key, err)
+	}
+	return err
+}
+
+// leaseExpiry returns when a lease taken at now lapses in Unix milliseconds
+func (l *lockImpl) leaseExpiry(now time.Time, lease time.Duration) int64 {
+	if l.options.Permanent {
+		return now.Add(l.options.HeartbeatTimeout).UnixMilli()
+	}
+	return now.Add(lease).UnixMilli()
+}
+
+// watchDogInterval returns how often the watchdog refreshes the lock
+func (l *lockImpl) watchDogInterval() time.Duration {
+	if l.options.Permanent {
+		return l.options.HeartbeatTimeout / 3
+	}
+	return time.Duration(l.lease.Load()) / 3
+}
+
+// startWatchDog starts the watchdog unless it is already running, l.mu must be held
 func (l *lockImpl) startWatchDog(ctx context.Context) {
-	l.watchDogOnce.Do(func() {
-		l.watchDogCtx, l.watchDogCancel = context.WithCancel(context.Background())
+	if l.watchDogDone != nil {
+		select {
+		case <-l.watchDogDone:
+		default:
+			return
+		}
+	}
+
+	watchDogCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	l.watchDogCancel, l.watchDogDone = cancel, done
 
-		go func() {
-			defer close(l.watchDogDone)
+	fence := l.fence
+	go func() {
+		defer close(done)
 
-			ticker := time.NewTicker(l.options.WatchDogTimeout / 3)
-			defer ticker.Stop()
+		// The interval follows the lease of the last refresh, which may change under brownout
+		interval := l.watchDogInterval()
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		refreshed := time.Now()
 
-			for {
-				select {
-				case <-ticker.C:
-					if err := l.Refresh(ctx); err != nil {
-						l.logger.Error(ctx, "Watchdog failed to refresh lock: %s", l.name)
+		for {
+			select {
+			case <-timer.C:
+				// A timer firing an interval late means the process stalled, e.g. in a long
+				// GC pause, and the lease may have lapsed meanwhile
+				var err error
+				if stall := time.Since(refreshed) - interval; stall > interval {
+					err = l.resync(ctx, fence, stall)
+				}
+				refreshed = time.Now()
+				if err == nil {
+					err = l.refresh(ctx)
+				}
+				if err != nil {
+					l.notifyLost(ctx, err)
+					if ctx.Err() != nil {
 						return
 					}
-				case <-l.watchDogCtx.Done():
-					return
-				case <-ctx.Done():
+					l.logger.Error(ctx, "Watchdog failed to refresh lock: %s", l.key)
+					l.client.metrics.IncCounter(MetricWatchdogFailures, 1, LabelLock, l.label)
+					l.client.emit(ctx, Event{Type: EventLockLost, Name: l.name, Owner: l.value, Detail: err.Error()})
 					return
 				}
+				interval = l.watchDogInterval()
+				timer.Reset(interval)
+			case <-watchDogCtx.Done():
+				return
+			case <-ctx.Done():
+				// The lease lapses without the watchdog
+				l.notifyLost(ctx, ctx.Err())
+				return
 			}
-		}()
-	})
+		}
+	}()
+}
+
+// resync verifies the lock before the watchdog refreshes it after a stall. The owner
+// value alone does not tell a lease that lapsed and was taken again under the same
+// pinned owner token, e.g. by another instance, so the fencing token of the
+// acquisition must still match too. A local handoff passes value and fence
+// alike and stops the watchdog of the releaser, so it never reaches resync.
+func (l *lockImpl) resync(ctx context.Context, fence int64, stall time.Duration) error {
+	l.logger.Warn(ctx, "Watchdog stalled for %v, verifying lock: %s", stall, l.key)
+	l.client.emit(ctx, Event{Type: EventWatchdogStall, Name: l.name, Owner: l.value, Detail: stall.String()})
+
+	// Without Redis the refresh that follows verifies the owner through the store
+	if l.client.executor == nil {
+		return nil
+	}
+	state, err := replyStrings(l.client.do(ctx, "HMGET", l.key, ownerField, fenceField))
+	if err != nil {
+		return err
+	}
+	if len(state) != 2 {
+		return fmt.Errorf("unexpected reply: %v", state)
+	}
+	owner, current := state[0], state[1]
+	if owner != l.value || current != strconv.FormatInt(fence, 10) {
+		l.logger.Warn(ctx, "Lock changed hands during watchdog stall: %s", l.key)
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// stopWatchDog stops the watchdog and waits for it to exit, l.mu must be held
+func (l *lockImpl) stopWatchDog() {
+	if l.watchDogCancel == nil {
+		return
+	}
+
+	l.watchDogCancel()
+	<-l.watchDogDone
+	l.watchDogCancel, l.watchDogDone = nil, nil
 }
@@ -0,0 +1,151 @@
+package arbiter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ownerField is the lock hash field holding the owner value
+	ownerField = "owner"
+
+	// fenceField is the lock hash field holding the fencing token of the acquisition
+	fenceField = "fence"
+
+	// annotationFieldPrefix starts the lock hash fields holding operator annotations
+	annotationFieldPrefix = "annotation:"
+)
+
+// LockInfo describes the state of a lock at the time it was inspected
+type LockInfo struct {
+	// Name is the lock name as passed to NewLock
+	Name string
+
+	// Held reports whether the lock was held
+	Held bool
+
+	// Owner is the owner value of the holder
+	Owner string
+
+	// TTL is the remaining lease time, 0 when the lock is not held or does not expire
+	TTL time.Duration
+
+	// Fence is the fencing token of the current acquisition
+	Fence int64
+
+	// Readers is the number of readers holding the read side of a read-write lock
+	Readers int
+
+	// Annotations holds the operator annotations attached with Admin.Annotate
+	Annotations map[string]string
+
+	// Metadata holds the remaining fields stored with the lock
+	Metadata map[string]string
+}
+
+// InspectLocks fetches the state of many locks in one pipelined round trip.
+// The result is in the same order as names.
+func (c *Client) InspectLocks(ctx context.Context, names []string) ([]LockInfo, error) {
+	if len(c.routes) == 0 {
+		keys := make([]string, len(names))
+		for i, name := range names {
+			keys[i] = c.lockKey(name)
+		}
+		return c.inspectKeys(ctx, names, keys)
+	}
+
+	// Inspect the locks of every backend in one round trip each
+	indexes := make(map[*Client][]int)
+	for i, name := range names {
+		routed := c.route(name)
+		indexes[routed] = append(indexes[routed], i)
+	}
+
+	infos := make([]LockInfo, len(names))
+	for routed, idx := range indexes {
+		routedNames := make([]string, len(idx))
+		keys := make([]string, len(idx))
+		for j, i := range idx {
+			routedNames[j] = names[i]
+			keys[j] = routed.lockKey(names[i])
+		}
+
+		routedInfos, err := routed.inspectKeys(ctx, routedNames, keys)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range idx {
+			infos[i] = routedInfos[j]
+		}
+	}
+	return infos, nil
+}
+
+// inspectKeys fetches the lock hash and PTTL of every key in one pipeline
+func (c *Client) inspectKeys(ctx context.Context, names, keys []string) ([]LockInfo, error) {
+	cmds := make([][]interface{}, 0, 2*len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, []interface{}{"HGETALL", key}, []interface{}{"PTTL", key})
+	}
+	replies, err := c.doMulti(ctx, cmds...)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]LockInfo, len(keys))
+	for i, name := range names {
+		// Keys of other types fail individually with WRONGTYPE and are reported as not held
+		if err, ok := replies[2*i].(error); ok && !isWrongType(err) {
+			return nil, err
+		}
+		values, _ := replyFields(replies[2*i], nil)
+		for field, value := range values {
+			values[field] = c.decodeValue(ctx, value)
+		}
+		ttl, _ := replyInt(replies[2*i+1], nil)
+		infos[i] = newLockInfo(name, values, time.Duration(ttl)*time.Millisecond)
+	}
+	return infos, nil
+}
+
+// isWrongType reports whether err is a Redis WRONGTYPE error
+func isWrongType(err error) bool {
+	return strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+// newLockInfo builds a LockInfo from the lock hash and its PTTL
+func newLockInfo(name string, fields map[string]string, ttl time.Duration) LockInfo {
+	info := LockInfo{Name: name}
+
+	owner, ok := fields[ownerField]
+	info.Readers = readers(fields)
+	if !ok && info.Readers == 0 {
+		return info
+	}
+
+	info.Held = true
+	info.Owner = owner
+	info.Fence, _ = strconv.ParseInt(fields[fenceField], 10, 64)
+	if ttl > 0 {
+		info.TTL = ttl
+	}
+	for field, value := range fields {
+		if field == ownerField || field == fenceField || strings.HasPrefix(field, readerFieldPrefix) || strings.HasPrefix(field, intentFieldPrefix) {
+			continue
+		}
+		if key, ok := strings.CutPrefix(field, annotationFieldPrefix); ok {
+			if info.Annotations == nil {
+				info.Annotations = make(map[string]string)
+			}
+			info.Annotations[key] = value
+			continue
+		}
+		if info.Metadata == nil {
+			info.Metadata = make(map[string]string)
+		}
+		info.Metadata[field] = value
+	}
+	return info
+}
@@ -0,0 +1,96 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInspectLocks(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-inspect:"))
+	ctx := context.Background()
+
+	held := client.NewLock("test-held", WithLeaseTime(10*time.Second))
+	if err := held.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer held.Unlock(ctx)
+
+	permanent := client.NewLock("test-permanent", WithPermanent(time.Hour))
+	if err := permanent.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer permanent.Unlock(ctx)
+
+	infos, err := client.InspectLocks(ctx, []string{"test-held", "test-free", "test-permanent"})
+	if err != nil {
+		t.Fatalf("Failed to inspect locks: %v", err)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(infos))
+	}
+
+	if info := infos[0]; !info.Held || info.Owner == "" || info.TTL <= 0 || info.TTL > 10*time.Second {
+		t.Errorf("Unexpected info for held lock: %+v", info)
+	}
+	if info := infos[1]; info.Held || info.Name != "test-free" {
+		t.Errorf("Unexpected info for free lock: %+v", info)
+	}
+	if info := infos[2]; !info.Held || info.TTL != 0 {
+		t.Errorf("Unexpected info for permanent lock: %+v", info)
+	}
+}
+
+func TestTryLockInfo(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-trylock-info:"))
+	ctx := context.Background()
+
+	holder := client.NewLock("test-held", WithLeaseTime(time.Minute))
+	acquired, info, err := holder.TryLockInfo(ctx)
+	if err != nil || !acquired || info.Held {
+		t.Fatalf("Free lock should be acquired without holder, got: %v, %+v, %v", acquired, info, err)
+	}
+	defer holder.Unlock(ctx)
+
+	acquired, info, err = client.NewLock("test-held").TryLockInfo(ctx)
+	if err != nil || acquired {
+		t.Fatalf("Held lock should not be acquired, got: %v, %v", acquired, err)
+	}
+	infos, _ := client.InspectLocks(ctx, []string{"test-held"})
+	if !info.Held || info.Name != "test-held" || info.Owner != infos[0].Owner || info.Fence != holder.Fence() {
+		t.Errorf("Unexpected holder: %+v, want owner %s", info, infos[0].Owner)
+	}
+	if info.TTL <= 50*time.Second || info.TTL > time.Minute {
+		t.Errorf("Unexpected holder TTL: %v", info.TTL)
+	}
+
+	// The holder of a multi lock is the first member that was not acquired
+	acquired, info, err = client.NewMultiLock([]string{"test-free", "test-held"}).TryLockInfo(ctx)
+	if err != nil || acquired || info.Name != "test-held" || !info.Held {
+		t.Fatalf("Unexpected multi lock result: %v, %+v, %v", acquired, info, err)
+	}
+	if locked, _ := client.IsLocked(ctx, "test-free"); locked {
+		t.Fatal("Multi lock should roll back acquired members")
+	}
+}
+
+func TestHeldFor(t *testing.T) {
+	for _, tt := range []struct {
+		holder LockInfo
+		want   string
+	}{
+		{LockInfo{}, ""},
+		{LockInfo{Held: true, Owner: "a"}, ", held by a without expiry"},
+		{LockInfo{Held: true, Owner: "a", TTL: 1500 * time.Millisecond}, ", held by a for another 1.5s"},
+	} {
+		if got := heldFor(tt.holder); got != tt.want {
+			t.Errorf("heldFor(%+v) = %q, want %q", tt.holder, got, tt.want)
+		}
+	}
+}
@@ -1,28 +1,914 @@
 package lua
 
+// Results returned by the TryLock script, acquisitions return the fencing token
+const (
+	QuotaRate   = -3
+	QuotaHeld   = -2
+	Frozen      = -1
+	NotAcquired = 0
+	Acquired    = 1
+)
+
+// Results returned by the RWUpgrade script besides NotAcquired and Acquired
+const (
+	UpgradeNotReader = -1
+	UpgradeDeadlock  = -2
+)
+
 // TryLock is the Lua script for trying to acquire a lock
+//
+// KEYS[1] is the lock key, KEYS[2] the set of exactly frozen lock names,
+// KEYS[3] the set of frozen name prefix patterns (each ending with "*"),
+// KEYS[4] the heartbeat key, KEYS[5] the set of permanent lock keys,
+// KEYS[6] the sorted set of held locks of the namespace, KEYS[7] the
+// acquisition counter of the current second and KEYS[8] the fencing counter.
+// ARGV[1] is the owner value, ARGV[2] the lease in milliseconds,
+// ARGV[3] the unprefixed lock name matched against the frozen sets,
+// ARGV[4] the heartbeat TTL in milliseconds, ARGV[5] the channel new
+// acquisitions are published on, ARGV[6] the maximum number of held locks,
+// ARGV[7] the maximum acquisitions per second, ARGV[8] the current time and
+// ARGV[9] the lease expiry, both in Unix milliseconds. Maximums of 0 are
+// unlimited. If ARGV[10] is 1, a lock held by another owner is reported as
+// a table of NotAcquired, the owner, its PTTL and its fencing token. A
+// lease of 0 stores the lock without expiry and registers it for reaping
+// once its heartbeat lapses.
+// Every new owner takes the next fencing token from KEYS[8], which is
+// stored in the lock and returned, re-entering owners get it back unchanged.
+// Current owners may re-enter a frozen lock, new owners are rejected.
+// Exact names are checked with a single SISMEMBER, while every prefix pattern
+// is scanned on each first-time acquisition, so keep the prefix set small.
 const TryLock = `
-if redis.call('exists', KEYS[1]) == 0 or redis.call('hget', KEYS[1], 'owner') == ARGV[1] then
-    redis.call('hset', KEYS[1], 'owner', ARGV[1])
-    redis.call('pexpire', KEYS[1], ARGV[2])
-    return 1
+local function grant(fence)
+    redis.call('hset', KEYS[1], 'owner', ARGV[1], 'fence', fence)
+    if tonumber(ARGV[2]) > 0 then
+        redis.call('pexpire', KEYS[1], ARGV[2])
+    else
+        redis.call('persist', KEYS[1])
+        redis.call('set', KEYS[4], ARGV[1], 'px', ARGV[4])
+        redis.call('sadd', KEYS[5], KEYS[1])
+    end
+    if tonumber(ARGV[6]) > 0 then
+        redis.call('zadd', KEYS[6], ARGV[9], KEYS[1])
+    end
+    return fence
 end
-return 0
+
+if redis.call('hget', KEYS[1], 'owner') == ARGV[1] then
+    return grant(tonumber(redis.call('hget', KEYS[1], 'fence') or '1'))
+end
+if redis.call('exists', KEYS[1]) == 1 then
+    if ARGV[10] == '1' then
+        local holder = redis.call('hmget', KEYS[1], 'owner', 'fence')
+        return {0, holder[1] or '', redis.call('pttl', KEYS[1]), holder[2] or '0'}
+    end
+    return 0
+end
+if redis.call('sismember', KEYS[2], ARGV[3]) == 1 then
+    return -1
+end
+for _, pattern in ipairs(redis.call('smembers', KEYS[3])) do
+    if string.sub(ARGV[3], 1, #pattern - 1) == string.sub(pattern, 1, -2) then
+        return -1
+    end
+end
+if tonumber(ARGV[6]) > 0 then
+    redis.call('zremrangebyscore', KEYS[6], '-inf', ARGV[8])
+    if redis.call('zcard', KEYS[6]) >= tonumber(ARGV[6]) then
+        return -2
+    end
+end
+if tonumber(ARGV[7]) > 0 then
+    if tonumber(redis.call('get', KEYS[7]) or '0') >= tonumber(ARGV[7]) then
+        return -3
+    end
+    redis.call('incr', KEYS[7])
+    redis.call('pexpire', KEYS[7], 2000)
+end
+local fence = grant(redis.call('incr', KEYS[8]))
+redis.call('publish', ARGV[5], KEYS[1])
+return fence
 `
 
 // Unlock is the Lua script for releasing a lock
+//
+// KEYS[1] is the lock key, KEYS[2] the heartbeat key, KEYS[3] the set of
+// permanent lock keys and KEYS[4] the sorted set of held locks of the
+// namespace. ARGV[1] is the owner value, ARGV[2] the channel lock state
+// changes are published on and ARGV[3] the channel waiters are woken on.
 const Unlock = `
 if redis.call('hget', KEYS[1], 'owner') == ARGV[1] then
-    return redis.call('del', KEYS[1])
+    redis.call('del', KEYS[2])
+    redis.call('srem', KEYS[3], KEYS[1])
+    redis.call('zrem', KEYS[4], KEYS[1])
+    redis.call('del', KEYS[1])
+    redis.call('publish', ARGV[2], KEYS[1])
+    redis.call('publish', ARGV[3], KEYS[1])
+    return 1
 else
     return 0
 end
 `
 
 // Refresh is the Lua script for refreshing a lock's expiration
+//
+// KEYS[1] is the lock key, KEYS[2] the heartbeat key and KEYS[3] the sorted
+// set of held locks of the namespace. ARGV[1] is the owner value, ARGV[2] the
+// lease in milliseconds, ARGV[3] the heartbeat TTL in milliseconds and ARGV[4]
+// the new lease expiry in Unix milliseconds, or 0 when held locks are not
+// tracked. A lease of 0 refreshes the heartbeat of a permanent lock.
 const Refresh = `
+if redis.call('hget', KEYS[1], 'owner') == ARGV[1] then
+    if tonumber(ARGV[4]) > 0 then
+        redis.call('zadd', KEYS[3], ARGV[4], KEYS[1])
+    end
+    if tonumber(ARGV[2]) > 0 then
+        return redis.call('pexpire', KEYS[1], ARGV[2])
+    end
+    redis.call('set', KEYS[2], ARGV[1], 'px', ARGV[3])
+    return 1
+end
+return 0
+`
+
+// Reap is the Lua script for removing a permanent lock whose heartbeat lapsed
+//
+// KEYS[1] is the lock key, KEYS[2] the heartbeat key and KEYS[3] the set of
+// permanent lock keys. ARGV[1] is the channel removals are published on and
+// ARGV[2] the channel waiters are woken on. It returns 1 if the lock was removed.
+const Reap = `
+if redis.call('exists', KEYS[1]) == 0 then
+    redis.call('srem', KEYS[3], KEYS[1])
+    return 0
+end
+if redis.call('exists', KEYS[2]) == 1 then
+    return 0
+end
+redis.call('del', KEYS[1])
+redis.call('srem', KEYS[3], KEYS[1])
+redis.call('publish', ARGV[1], KEYS[1])
+redis.call('publish', ARGV[2], KEYS[1])
+return 1
+`
+
+// Annotate is the Lua script for attaching a field to a held lock
+//
+// KEYS[1] is the lock key. ARGV[1] is the field and ARGV[2] its value.
+// It returns 0 without creating the key if the lock is not held.
+const Annotate = `
+if redis.call('hexists', KEYS[1], 'owner') == 0 then
+    return 0
+end
+redis.call('hset', KEYS[1], ARGV[1], ARGV[2])
+return 1
+`
+
+// Stamp is the Lua script for writing fields into a lock held by one owner
+//
+// KEYS[1] is the lock key. ARGV[1] is the owner and the remaining arguments
+// are field and value pairs. It returns 0 if the lock is not held by ARGV[1].
+const Stamp = `
+if redis.call('hget', KEYS[1], 'owner') ~= ARGV[1] then
+    return 0
+end
+for i = 2, #ARGV, 2 do
+    redis.call('hset', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+return 1
+`
+
+// CompleteOnce is the Lua script for recording the fencing token that completed a run
+//
+// KEYS[1] is the lock key and KEYS[2] the completion marker. ARGV[1] is the
+// owner value and ARGV[2] its fencing token. It returns 0 without recording
+// if ARGV[1] no longer holds the lock or a run already completed.
+const CompleteOnce = `
+if redis.call('hget', KEYS[1], 'owner') ~= ARGV[1] or redis.call('exists', KEYS[2]) == 1 then
+    return 0
+end
+redis.call('set', KEYS[2], ARGV[2])
+return 1
+`
+
+// ForceUnlock is the Lua script for releasing a lock regardless of its owner
+//
+// KEYS[1] is the lock key, KEYS[2] the heartbeat key, KEYS[3] the set of
+// permanent lock keys and KEYS[4] the sorted set of held locks of the
+// namespace. ARGV[1] is the channel releases are published on and ARGV[2]
+// the channel waiters are woken on. If ARGV[3] is given, the lock is only
+// released while field ARGV[3] equals ARGV[4].
+// It returns the previous owner, false if the lock was not held, or 0 if the
+// field did not match.
+const ForceUnlock = `
+local owner = redis.call('hget', KEYS[1], 'owner')
+if not owner then
+    return false
+end
+if ARGV[3] and redis.call('hget', KEYS[1], ARGV[3]) ~= ARGV[4] then
+    return 0
+end
+redis.call('del', KEYS[1], KEYS[2])
+redis.call('srem', KEYS[3], KEYS[1])
+redis.call('zrem', KEYS[4], KEYS[1])
+redis.call('publish', ARGV[1], KEYS[1])
+redis.call('publish', ARGV[2], KEYS[1])
+return owner
+`
+
+// HoldTombstone is the Lua script for recording the current holder in the
+// tombstone of a lock
+//
+// KEYS[1] is the tombstone key. ARGV[1] is the owner value, ARGV[2] its
+// fencing token, ARGV[3] the acquisition time and ARGV[4] the lease expiry,
+// both in Unix milliseconds, and ARGV[5] the TTL of the tombstone in
+// milliseconds. A recorded holder of another owner never buried its
+// acquisition, so it is buried as expired first. Holders re-recorded on
+// refresh keep their acquisition time.
+const HoldTombstone = `
+local holder = redis.call('hmget', KEYS[1], 'holder', 'holder_fence', 'holder_acquired', 'holder_expires')
+if holder[1] == ARGV[1] then
+    redis.call('hset', KEYS[1], 'holder_expires', ARGV[4])
+else
+    if holder[1] then
+        redis.call('hset', KEYS[1], 'owner', holder[1], 'fence', holder[2], 'acquired', holder[3],
+            'ended', holder[4], 'reason', 'expired')
+    end
+    redis.call('hset', KEYS[1], 'holder', ARGV[1], 'holder_fence', ARGV[2], 'holder_acquired', ARGV[3],
+        'holder_expires', ARGV[4])
+end
+redis.call('pexpire', KEYS[1], ARGV[5])
+`
+
+// BuryTombstone is the Lua script for recording how the acquisition of an
+// owner ended in the tombstone of a lock
+//
+// KEYS[1] is the tombstone key. ARGV[1] is the owner value, ARGV[2] its
+// fencing token, empty if unknown, ARGV[3] the end time in Unix milliseconds,
+// ARGV[4] the reason and ARGV[5] the retention in milliseconds. The fencing
+// token and acquisition time are taken from the recorded holder, or from an
+// earlier burial of the owner, e.g. as expired once a new holder took over.
+// The TTL is only raised, so a later holder stays recorded.
+const BuryTombstone = `
+local holder = redis.call('hmget', KEYS[1], 'holder', 'holder_fence', 'holder_acquired')
+local fence, acquired = ARGV[2], ''
+if holder[1] == ARGV[1] then
+    fence, acquired = holder[2], holder[3]
+    redis.call('hdel', KEYS[1], 'holder', 'holder_fence', 'holder_acquired', 'holder_expires')
+elseif redis.call('hget', KEYS[1], 'owner') == ARGV[1] then
+    local buried = redis.call('hmget', KEYS[1], 'fence', 'acquired')
+    fence, acquired = buried[1], buried[2]
+end
+redis.call('hset', KEYS[1], 'owner', ARGV[1], 'fence', fence, 'acquired', acquired,
+    'ended', ARGV[3], 'reason', ARGV[4])
+if redis.call('pttl', KEYS[1]) < tonumber(ARGV[5]) then
+    redis.call('pexpire', KEYS[1], ARGV[5])
+end
+`
+
+// Transfer is the Lua script for handing a held lock to another owner
+//
+// KEYS[1] is the lock key and KEYS[2] the heartbeat key. ARGV[1] is the
+// owner value and ARGV[2] the new owner value. The lease, fencing token and
+// annotations are kept, as is the heartbeat TTL of a permanent lock.
+// It returns 1 if the lock was handed over and 0 if ARGV[1] did not hold it.
+const Transfer = `
+if redis.call('hget', KEYS[1], 'owner') ~= ARGV[1] then
+    return 0
+end
+redis.call('hset', KEYS[1], 'owner', ARGV[2])
+local ttl = redis.call('pttl', KEYS[2])
+if ttl > 0 then
+    redis.call('set', KEYS[2], ARGV[2], 'px', ttl)
+end
+return 1
+`
+
+// NextAttempt is the Lua script for claiming the next retry slot of a lock
+//
+// KEYS[1] is the backoff key. ARGV[1] is the current time in Unix
+// milliseconds, ARGV[2] the spacing between retries and ARGV[3] the maximum
+// delay, both in milliseconds. Every failed attempt claims the slot one
+// spacing after the last claimed one, at most the maximum delay ahead, and
+// the key expires shortly after the last claimed slot.
+// It returns the delay until the claimed slot in milliseconds.
+const NextAttempt = `
+local now = tonumber(ARGV[1])
+local slot = math.max(tonumber(redis.call('get', KEYS[1]) or '0'), now) + tonumber(ARGV[2])
+slot = math.min(slot, now + tonumber(ARGV[3]))
+redis.call('set', KEYS[1], slot, 'px', slot - now + tonumber(ARGV[2]))
+return slot - now
+`
+
+// CondSignal is the Lua script for waking one waiter of a condition variable
+//
+// KEYS[1] is the sorted set of waiters scored by the expiry of their entry in
+// Unix milliseconds. ARGV[1] is the current time and ARGV[2] the channel
+// wakeups are published on. Expired waiters are dropped, the waiter closest
+// to expiry is removed and woken by publishing KEYS[1] .. '#' .. its token.
+// It returns 1 if a waiter was woken and 0 if none was waiting.
+const CondSignal = `
+redis.call('zremrangebyscore', KEYS[1], '-inf', ARGV[1])
+local waiter = redis.call('zpopmin', KEYS[1])
+if #waiter == 0 then
+    return 0
+end
+redis.call('publish', ARGV[2], KEYS[1] .. '#' .. waiter[1])
+return 1
+`
+
+// CondBroadcast is the Lua script for waking every waiter of a condition variable
+//
+// KEYS[1] is the sorted set of waiters scored by the expiry of their entry in
+// Unix milliseconds. ARGV[1] is the current time and ARGV[2] the channel
+// wakeups are published on. Every waiter is removed and woken at once by
+// publishing KEYS[1]. It returns the number of woken waiters.
+const CondBroadcast = `
+redis.call('zremrangebyscore', KEYS[1], '-inf', ARGV[1])
+local waiters = redis.call('zcard', KEYS[1])
+redis.call('del', KEYS[1])
+if waiters > 0 then
+    redis.call('publish', ARGV[2], KEYS[1])
+end
+return waiters
+`
+
+// CondEnter is the Lua script for registering or renewing a waiter of a
+// condition variable
+//
+// KEYS[1] is the sorted set of waiters scored by the expiry of their entry in
+// Unix milliseconds. ARGV[1] is the waiter token, ARGV[2] the expiry of its
+// entry and ARGV[3] the TTL of the set in milliseconds. It returns 1.
+const CondEnter = `
+redis.call('zadd', KEYS[1], ARGV[2], ARGV[1])
+redis.call('pexpire', KEYS[1], ARGV[3])
+return 1
+`
+
+// Steal is the Lua script for taking a lock over regardless of its owner
+//
+// KEYS[1] is the lock key, KEYS[2] the heartbeat key, KEYS[3] the set of
+// permanent lock keys, KEYS[4] the sorted set of held locks of the namespace
+// and KEYS[5] the fencing counter. ARGV[1] is the new owner value, ARGV[2]
+// the lease in milliseconds, ARGV[3] the heartbeat TTL in milliseconds,
+// ARGV[4] the channel acquisitions are published on, ARGV[5] the lease expiry
+// in Unix milliseconds and ARGV[6] the maximum number of held locks.
+// The previous lock hash, annotations included, is replaced and the new owner
+// takes the next fencing token. Freezes and quotas do not apply.
+// It returns a table of the fencing token and the previous owner, empty if
+// the lock was not held.
+const Steal = `
+local previous = redis.call('hget', KEYS[1], 'owner') or ''
+redis.call('del', KEYS[1], KEYS[2])
+redis.call('srem', KEYS[3], KEYS[1])
+local fence = redis.call('incr', KEYS[5])
+redis.call('hset', KEYS[1], 'owner', ARGV[1], 'fence', fence)
+if tonumber(ARGV[2]) > 0 then
+    redis.call('pexpire', KEYS[1], ARGV[2])
+else
+    redis.call('set', KEYS[2], ARGV[1], 'px', ARGV[3])
+    redis.call('sadd', KEYS[3], KEYS[1])
+end
+if tonumber(ARGV[6]) > 0 then
+    redis.call('zadd', KEYS[4], ARGV[5], KEYS[1])
+end
+redis.call('publish', ARGV[4], KEYS[1])
+return {fence, previous}
+`
+
+// EnterWait is the Lua script for registering a waiter under a waiter quota
+//
+// KEYS[1] is the sorted set of waiters scored by expiry. ARGV[1] is the
+// waiter, ARGV[2] the maximum number of waiters, ARGV[3] the current time and
+// ARGV[4] the waiter expiry, both in Unix milliseconds. It returns 0 if the
+// quota is exhausted and renews the expiry of registered waiters.
+const EnterWait = `
+redis.call('zremrangebyscore', KEYS[1], '-inf', ARGV[3])
+if not redis.call('zscore', KEYS[1], ARGV[1]) and redis.call('zcard', KEYS[1]) >= tonumber(ARGV[2]) then
+    return 0
+end
+redis.call('zadd', KEYS[1], ARGV[4], ARGV[1])
+redis.call('pexpire', KEYS[1], ARGV[4] - ARGV[3])
+return 1
+`
+
+// CollectOrphan is the Lua script for removing an auxiliary key of a free lock
+//
+// KEYS[1] is the auxiliary key and KEYS[2] the lock key it belongs to. ARGV[1]
+// is the minimum idle time of the auxiliary key in seconds, 0 skips the check.
+// It returns 1 if the auxiliary key was removed.
+const CollectOrphan = `
+if redis.call('exists', KEYS[2]) == 1 then
+    return 0
+end
+if tonumber(ARGV[1]) > 0 then
+    local idle = redis.call('object', 'idletime', KEYS[1])
+    if not idle or idle < tonumber(ARGV[1]) then
+        return 0
+    end
+end
+return redis.call('del', KEYS[1])
+`
+
+// CollectPermanent is the Lua script for removing lock keys that no longer
+// exist from the set of permanent lock keys in KEYS[1]. It returns how many
+// members were removed.
+const CollectPermanent = `
+local removed = 0
+for _, key in ipairs(redis.call('smembers', KEYS[1])) do
+    if redis.call('exists', key) == 0 then
+        redis.call('srem', KEYS[1], key)
+        removed = removed + 1
+    end
+end
+return removed
+`
+
+// frozenCheck returns -1 when ARGV[3] is frozen in KEYS[2] or KEYS[3]
+const frozenCheck = `
+if redis.call('sismember', KEYS[2], ARGV[3]) == 1 then
+    return -1
+end
+for _, pattern in ipairs(redis.call('smembers', KEYS[3])) do
+    if string.sub(ARGV[3], 1, #pattern - 1) == string.sub(pattern, 1, -2) then
+        return -1
+    end
+end
+`
+
+// RWLock is the Lua script for trying to acquire the write side of a read-write lock
+//
+// KEYS[1] is the lock key, KEYS[2] and KEYS[3] the frozen sets as for TryLock.
+// ARGV[1] is the owner value, ARGV[2] the lease in milliseconds, ARGV[3] the
+// unprefixed lock name, ARGV[4] the current time in Unix milliseconds,
+// ARGV[5] the expiry of the write intent in Unix milliseconds, 0 to not
+// announce one, and ARGV[6] the channel acquisitions are published on.
+// Readers are stored as "reader:<owner>" fields holding their lease expiry,
+// lapsed readers are dropped. While live readers remain, a waiting writer
+// stores its intent as an "intent:<owner>" field so that no new readers are
+// admitted.
+const RWLock = `
+local owner = redis.call('hget', KEYS[1], 'owner')
+if owner == ARGV[1] then
+    redis.call('pexpire', KEYS[1], ARGV[2])
+    return 1
+end
+if owner then
+    return 0
+end
+local live = 0
+local fields = redis.call('hgetall', KEYS[1])
+for i = 1, #fields, 2 do
+    if string.sub(fields[i], 1, 7) == 'reader:' then
+        if tonumber(fields[i + 1]) <= tonumber(ARGV[4]) then
+            redis.call('hdel', KEYS[1], fields[i])
+        else
+            live = live + 1
+        end
+    end
+end
+if live > 0 then
+    if tonumber(ARGV[5]) > 0 then
+        redis.call('hset', KEYS[1], 'intent:' .. ARGV[1], ARGV[5])
+    end
+    return 0
+end
+` + frozenCheck + `
+redis.call('del', KEYS[1])
+redis.call('hset', KEYS[1], 'owner', ARGV[1])
+redis.call('pexpire', KEYS[1], ARGV[2])
+redis.call('publish', ARGV[6], KEYS[1])
+return 1
+`
+
+// RWRLock is the Lua script for trying to acquire the read side of a read-write lock
+//
+// KEYS and ARGV[1] to ARGV[4] are as for RWLock, ARGV[5] is the channel
+// acquisitions are published on. New readers are refused while a writer holds
+// the lock or announced an unexpired intent, current readers renew their lease.
+const RWRLock = `
+if redis.call('hexists', KEYS[1], 'owner') == 1 then
+    return 0
+end
+local field = 'reader:' .. ARGV[1]
+local reentry = redis.call('hexists', KEYS[1], field) == 1
+if not reentry then
+    local fields = redis.call('hgetall', KEYS[1])
+    for i = 1, #fields, 2 do
+        if string.sub(fields[i], 1, 7) == 'intent:' and tonumber(fields[i + 1]) > tonumber(ARGV[4]) then
+            return 0
+        end
+    end
+` + frozenCheck + `
+end
+redis.call('hset', KEYS[1], field, tonumber(ARGV[4]) + tonumber(ARGV[2]))
+if redis.call('pttl', KEYS[1]) < tonumber(ARGV[2]) then
+    redis.call('pexpire', KEYS[1], ARGV[2])
+end
+if not reentry then
+    redis.call('publish', ARGV[5], KEYS[1])
+end
+return 1
+`
+
+// RWUnlock is the Lua script for releasing either side of a read-write lock
+//
+// KEYS[1] is the lock key. ARGV[1] is the owner value and ARGV[2] the channel
+// releases are published on. The lock key is removed with its last reader.
+// It returns 0 if the owner held neither side.
+const RWUnlock = `
+if redis.call('hget', KEYS[1], 'owner') == ARGV[1] then
+    redis.call('del', KEYS[1])
+    redis.call('publish', ARGV[2], KEYS[1])
+    return 1
+end
+if redis.call('hdel', KEYS[1], 'reader:' .. ARGV[1]) == 0 then
+    return 0
+end
+for _, field in ipairs(redis.call('hkeys', KEYS[1])) do
+    if string.sub(field, 1, 7) == 'reader:' then
+        return 1
+    end
+end
+redis.call('del', KEYS[1])
+redis.call('publish', ARGV[2], KEYS[1])
+return 1
+`
+
+// RWRefresh is the Lua script for extending either side of a read-write lock
+//
+// KEYS[1] is the lock key. ARGV[1] is the owner value, ARGV[2] the lease in
+// milliseconds and ARGV[3] the current time in Unix milliseconds.
+const RWRefresh = `
 if redis.call('hget', KEYS[1], 'owner') == ARGV[1] then
     return redis.call('pexpire', KEYS[1], ARGV[2])
 end
+local field = 'reader:' .. ARGV[1]
+if redis.call('hexists', KEYS[1], field) == 0 then
+    return 0
+end
+redis.call('hset', KEYS[1], field, tonumber(ARGV[3]) + tonumber(ARGV[2]))
+if redis.call('pttl', KEYS[1]) < tonumber(ARGV[2]) then
+    redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 1
+`
+
+// RWUpgrade is the Lua script for trying to upgrade a reader to the write side
+//
+// KEYS[1] is the lock key. ARGV[1] is the owner value, ARGV[2] the lease in
+// milliseconds, ARGV[3] the current time and ARGV[4] the expiry of the write
+// intent, both in Unix milliseconds, 0 to not announce one. The upgrade
+// succeeds once the caller is the last live reader. It returns UpgradeNotReader
+// if the caller holds no read side and UpgradeDeadlock if another reader
+// already announced an upgrade, since neither could proceed while both wait.
+const RWUpgrade = `
+local now = tonumber(ARGV[3])
+local expiry = redis.call('hget', KEYS[1], 'reader:' .. ARGV[1])
+if not expiry or tonumber(expiry) <= now then
+    return -1
+end
+local live = 0
+local fields = redis.call('hgetall', KEYS[1])
+for i = 1, #fields, 2 do
+    local field = fields[i]
+    if string.sub(field, 1, 7) == 'reader:' and string.sub(field, 8) ~= ARGV[1] then
+        if tonumber(fields[i + 1]) <= now then
+            redis.call('hdel', KEYS[1], field)
+        else
+            live = live + 1
+        end
+    elseif string.sub(field, 1, 7) == 'intent:' and string.sub(field, 8) ~= ARGV[1] and tonumber(fields[i + 1]) > now then
+        local other = redis.call('hget', KEYS[1], 'reader:' .. string.sub(field, 8))
+        if other and tonumber(other) > now then
+            return -2
+        end
+    end
+end
+if live > 0 then
+    if tonumber(ARGV[4]) > 0 then
+        redis.call('hset', KEYS[1], 'intent:' .. ARGV[1], ARGV[4])
+    end
+    return 0
+end
+redis.call('del', KEYS[1])
+redis.call('hset', KEYS[1], 'owner', ARGV[1])
+redis.call('pexpire', KEYS[1], ARGV[2])
+return 1
+`
+
+// RWDowngrade is the Lua script for turning the write side into the read side
+//
+// KEYS[1] is the lock key. ARGV[1] is the owner value, ARGV[2] the lease in
+// milliseconds, ARGV[3] the current time in Unix milliseconds and ARGV[4] the
+// channel waiting readers are woken on. It returns 0 if the owner does not
+// hold the write side.
+const RWDowngrade = `
+if redis.call('hget', KEYS[1], 'owner') ~= ARGV[1] then
+    return 0
+end
+redis.call('hdel', KEYS[1], 'owner')
+redis.call('hset', KEYS[1], 'reader:' .. ARGV[1], tonumber(ARGV[3]) + tonumber(ARGV[2]))
+redis.call('pexpire', KEYS[1], ARGV[2])
+redis.call('publish', ARGV[4], KEYS[1])
+return 1
+`
+
+// LeaveWait is the Lua script for removing a cancelled or finished waiter
+//
+// KEYS[1] is the sorted set of waiters of the namespace, KEYS[2] the queue of
+// the lock and KEYS[3] the lock key. ARGV[1] is the waiter. It removes the
+// waiter from both sets and withdraws its write intent on a read-write lock.
+const LeaveWait = `
+redis.call('zrem', KEYS[1], ARGV[1])
+redis.call('zrem', KEYS[2], ARGV[1])
+redis.call('hdel', KEYS[3], 'intent:' .. ARGV[1])
+return 1
+`
+
+// SemAcquire is the Lua script for trying to acquire a permit of a fair semaphore
+//
+// KEYS[1] is the sorted set of holders and KEYS[3] the sorted set of live
+// waiters, both scored by expiry, KEYS[2] the list of waiters in arrival order.
+// ARGV[1] is the holder token, ARGV[2] the number of permits, ARGV[3] the lease
+// in milliseconds, ARGV[4] the current time and ARGV[5] the waiter expiry, both
+// in Unix milliseconds, and ARGV[6] is 1 to enqueue the caller if no permit is
+// granted. Permits go to the live waiters in arrival order, lapsed holders and
+// waiters are dropped. Callers that did not enqueue are ranked behind every waiter.
+const SemAcquire = `
+local now = tonumber(ARGV[4])
+redis.call('zremrangebyscore', KEYS[1], '-inf', now)
+if redis.call('zscore', KEYS[1], ARGV[1]) then
+    redis.call('zadd', KEYS[1], now + tonumber(ARGV[3]), ARGV[1])
+    if redis.call('pttl', KEYS[1]) < tonumber(ARGV[3]) then
+        redis.call('pexpire', KEYS[1], ARGV[3])
+    end
+    return 1
+end
+local free = tonumber(ARGV[2]) - redis.call('zcard', KEYS[1])
+local position = 0
+local found = false
+for _, waiter in ipairs(redis.call('lrange', KEYS[2], 0, -1)) do
+    if waiter == ARGV[1] then
+        found = true
+        break
+    end
+    local expiry = redis.call('zscore', KEYS[3], waiter)
+    if not expiry or tonumber(expiry) <= now then
+        redis.call('lrem', KEYS[2], 0, waiter)
+        redis.call('zrem', KEYS[3], waiter)
+    else
+        position = position + 1
+    end
+end
+if position < free then
+    redis.call('zadd', KEYS[1], now + tonumber(ARGV[3]), ARGV[1])
+    if redis.call('pttl', KEYS[1]) < tonumber(ARGV[3]) then
+        redis.call('pexpire', KEYS[1], ARGV[3])
+    end
+    redis.call('lrem', KEYS[2], 0, ARGV[1])
+    redis.call('zrem', KEYS[3], ARGV[1])
+    return 1
+end
+if ARGV[6] == '1' then
+    if not found then
+        redis.call('rpush', KEYS[2], ARGV[1])
+    end
+    redis.call('zadd', KEYS[3], ARGV[5], ARGV[1])
+    local ttl = tonumber(ARGV[5]) - now
+    redis.call('pexpire', KEYS[2], ttl)
+    redis.call('pexpire', KEYS[3], ttl)
+end
 return 0
 `
+
+// SemRelease is the Lua script for releasing a permit of a fair semaphore
+//
+// KEYS are as for SemAcquire. ARGV[1] is the holder token. It also withdraws the
+// token from the waiters and returns 0 if it held no permit.
+const SemRelease = `
+redis.call('lrem', KEYS[2], 0, ARGV[1])
+redis.call('zrem', KEYS[3], ARGV[1])
+return redis.call('zrem', KEYS[1], ARGV[1])
+`
+
+// SemRefresh is the Lua script for extending the lease of a semaphore permit
+//
+// KEYS[1] is the sorted set of holders. ARGV[1] is the holder token, ARGV[2]
+// the lease in milliseconds and ARGV[3] the current time in Unix milliseconds.
+const SemRefresh = `
+local expiry = redis.call('zscore', KEYS[1], ARGV[1])
+if not expiry or tonumber(expiry) <= tonumber(ARGV[3]) then
+    return 0
+end
+redis.call('zadd', KEYS[1], tonumber(ARGV[3]) + tonumber(ARGV[2]), ARGV[1])
+if redis.call('pttl', KEYS[1]) < tonumber(ARGV[2]) then
+    redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 1
+`
+
+// SemLeave is the Lua script for withdrawing a waiter of a fair semaphore
+//
+// KEYS[1] is the list of waiters and KEYS[2] the sorted set of live waiters.
+// ARGV[1] is the waiter token.
+const SemLeave = `
+redis.call('lrem', KEYS[1], 0, ARGV[1])
+redis.call('zrem', KEYS[2], ARGV[1])
+return 1
+`
+
+// LatchCountDown is the Lua script for counting down a latch
+//
+// KEYS[1] is the latch key. ARGV[1] is the initial count, set if the latch does
+// not exist, ARGV[2] the channel the opening is published on and ARGV[3] how
+// long an opened latch is kept in milliseconds. It returns the remaining count.
+const LatchCountDown = `
+redis.call('set', KEYS[1], ARGV[1], 'nx')
+local count = tonumber(redis.call('get', KEYS[1]))
+if count <= 0 then
+    return 0
+end
+count = redis.call('decr', KEYS[1])
+if count == 0 then
+    redis.call('pexpire', KEYS[1], ARGV[3])
+    redis.call('publish', ARGV[2], KEYS[1])
+end
+return count
+`
+
+// BarrierEnter is the Lua script for entering a distributed barrier
+//
+// KEYS[1] is the barrier hash holding the current "round" and, once a double
+// barrier is full, the "open" round. KEYS[2] is the set of participants of the
+// round. ARGV[1] is the participant, ARGV[2] the number of parties, ARGV[3] 1
+// for a cyclic barrier that starts the next round as soon as it is full,
+// ARGV[4] the channel openings are published on and ARGV[5] how long an idle
+// barrier is kept in milliseconds. It returns the round entered, or -1 while
+// the participants of a full double barrier have not all left.
+const BarrierEnter = `
+local round = tonumber(redis.call('hget', KEYS[1], 'round') or '0')
+if redis.call('sismember', KEYS[2], ARGV[1]) == 0 then
+    if redis.call('hexists', KEYS[1], 'open') == 1 then
+        return -1
+    end
+    redis.call('sadd', KEYS[2], ARGV[1])
+    if redis.call('scard', KEYS[2]) >= tonumber(ARGV[2]) then
+        if ARGV[3] == '1' then
+            redis.call('del', KEYS[2])
+            redis.call('hset', KEYS[1], 'round', round + 1)
+        else
+            redis.call('hset', KEYS[1], 'round', round, 'open', round)
+        end
+        redis.call('publish', ARGV[4], KEYS[1])
+    end
+end
+redis.call('pexpire', KEYS[1], ARGV[5])
+redis.call('pexpire', KEYS[2], ARGV[5])
+return round
+`
+
+// BarrierLeave is the Lua script for leaving a double barrier
+//
+// KEYS are as for BarrierEnter. ARGV[1] is the participant, ARGV[2] the channel
+// the end of the round is published on and ARGV[3] how long an idle barrier is
+// kept in milliseconds. The round ends with the last participant leaving. It
+// returns the round left, or -1 if the participant had not entered.
+const BarrierLeave = `
+if redis.call('srem', KEYS[2], ARGV[1]) == 0 then
+    return -1
+end
+local round = tonumber(redis.call('hget', KEYS[1], 'round') or '0')
+if redis.call('scard', KEYS[2]) == 0 then
+    redis.call('hset', KEYS[1], 'round', round + 1)
+    redis.call('hdel', KEYS[1], 'open')
+    redis.call('publish', ARGV[2], KEYS[1])
+end
+redis.call('pexpire', KEYS[1], ARGV[3])
+redis.call('pexpire', KEYS[2], ARGV[3])
+return round
+`
+
+// TokenBucket is the Lua script for taking tokens from a token bucket rate limiter
+//
+// KEYS[1] is the bucket hash holding the tokens left and the time they were
+// counted. ARGV[1] is the refill rate in tokens per second, ARGV[2] the burst,
+// ARGV[3] the current time in Unix milliseconds and ARGV[4] the tokens to take.
+// The bucket starts full and is dropped once it would be full again. It returns
+// 0 if the tokens were taken, otherwise how many milliseconds until enough
+// tokens have accumulated, without taking any.
+const TokenBucket = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local state = redis.call('hmget', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+    tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+    ts = now
+end
+if tokens < n then
+    return math.max(1, math.ceil((n - tokens) * 1000 / rate))
+end
+redis.call('hset', KEYS[1], 'tokens', tokens - n, 'ts', ts)
+redis.call('pexpire', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return 0
+`
+
+// GCRA is the Lua script for a decision of a generic cell rate algorithm limiter
+//
+// KEYS[1] holds the theoretical arrival time of the next event in Unix
+// milliseconds. ARGV[1] is the burst, ARGV[2] the rate in events per second,
+// ARGV[3] the current time in Unix milliseconds and ARGV[4] the events to admit.
+// It returns whether the events were admitted, the events that could still be
+// admitted right away, the milliseconds until the events would be admitted and
+// the milliseconds until the limiter is back to a full burst.
+const GCRA = `
+local burst = tonumber(ARGV[1])
+local interval = 1000 / tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local offset = interval * burst
+local tat = math.max(tonumber(redis.call('get', KEYS[1]) or now), now)
+local next_tat = tat + interval * n
+local diff = now - (next_tat - offset)
+if diff < 0 then
+    local remaining = math.max(0, math.floor((offset - (tat - now)) / interval))
+    return {0, remaining, math.ceil(-diff), math.ceil(tat - now)}
+end
+local reset = math.ceil(next_tat - now)
+redis.call('set', KEYS[1], next_tat, 'px', reset)
+return {1, math.floor(diff / interval), 0, reset}
+`
+
+// ConcurrencyAcquire is the Lua script for taking a slot of a concurrency limiter
+//
+// KEYS[1] is the sorted set of slots scored by their expiry in Unix
+// milliseconds. ARGV[1] is the slot token, ARGV[2] the limit, ARGV[3] the
+// current time and ARGV[4] the slot TTL in milliseconds. Expired slots are
+// dropped first. It returns 1 if the slot was taken and 0 if all are in use.
+const ConcurrencyAcquire = `
+redis.call('zremrangebyscore', KEYS[1], '-inf', ARGV[3])
+if redis.call('zcard', KEYS[1]) >= tonumber(ARGV[2]) then
+    return 0
+end
+redis.call('zadd', KEYS[1], tonumber(ARGV[3]) + tonumber(ARGV[4]), ARGV[1])
+redis.call('pexpire', KEYS[1], ARGV[4])
+return 1
+`
+
+// ConcurrencyRefresh is the Lua script for extending a slot of a concurrency limiter
+//
+// KEYS[1] and ARGV are as for ConcurrencyAcquire. It returns 1 if the slot was
+// extended and 0 if it had expired or was released.
+const ConcurrencyRefresh = `
+local expiry = redis.call('zscore', KEYS[1], ARGV[1])
+if not expiry or tonumber(expiry) <= tonumber(ARGV[3]) then
+    return 0
+end
+redis.call('zadd', KEYS[1], tonumber(ARGV[3]) + tonumber(ARGV[4]), ARGV[1])
+redis.call('pexpire', KEYS[1], ARGV[4])
+return 1
+`
+
+// CounterCAS is the Lua script for compare-and-swap of a counter
+//
+// KEYS[1] is the counter, a missing counter reads as 0. ARGV[1] is the
+// expected value and ARGV[2] the new one. It returns 1 if the counter held the
+// expected value and was set, 0 otherwise.
+const CounterCAS = `
+if tonumber(redis.call('get', KEYS[1]) or '0') ~= tonumber(ARGV[1]) then
+    return 0
+end
+redis.call('set', KEYS[1], ARGV[2])
+return 1
+`
+
+// QuotaConsume is the Lua script for consuming a budget of a quota
+//
+// KEYS[1] is the quota hash holding the start of its window and the budget
+// used in it. ARGV[1] is the current time in Unix milliseconds, ARGV[2] the
+// window in milliseconds, ARGV[3] the limit and ARGV[4] the budget to consume,
+// 0 to only read the usage. Windows are aligned to multiples of their length,
+// a new window starts with nothing used. It returns 1 if the budget was
+// consumed and 0 if it exceeded the rest, the budget used and the end of the
+// window in Unix milliseconds.
+const QuotaConsume = `
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local start = now - now % window
+local used = 0
+if tonumber(redis.call('hget', KEYS[1], 'window') or '-1') == start then
+    used = tonumber(redis.call('hget', KEYS[1], 'used') or '0')
+end
+local n = tonumber(ARGV[4])
+if n == 0 then
+    return {1, used, start + window}
+end
+if used + n > tonumber(ARGV[3]) then
+    return {0, used, start + window}
+end
+redis.call('hset', KEYS[1], 'window', start, 'used', used + n)
+redis.call('pexpireat', KEYS[1], start + window)
+return {1, used + n, start + window}
+`
@@ -0,0 +1,122 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// latchRecheck is how often Await re-reads the count in case an opening was published
+// while the subscription was reconnecting
+const latchRecheck = 5 * time.Second
+
+// CountDownLatch is a distributed latch that opens once a number of participants
+// counted down, letting services wait until all of them signalled completion
+type CountDownLatch interface {
+	// CountDown decrements the count, opening the latch when it reaches zero
+	CountDown(ctx context.Context) error
+
+	// Await blocks until the latch is open or ctx is done
+	Await(ctx context.Context) error
+
+	// Count returns the remaining count
+	Count(ctx context.Context) (int, error)
+}
+
+type countDownLatch struct {
+	client *Client
+	name   string
+	key    string
+	count  int
+	keep   time.Duration
+	logger Logger
+}
+
+// NewCountDownLatch creates a latch that opens after count calls of CountDown across
+// all processes. The count is set by the first CountDown. Awaiting processes are woken
+// over pub/sub rather than by polling. An opened latch is kept for the lease time of
+// opts so late awaiters return at once; afterwards the next CountDown starts a new round.
+func (c *Client) NewCountDownLatch(name string, count int, opts ...Option) CountDownLatch {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	c = c.route(name)
+	return &countDownLatch{
+		client: c,
+		name:   name,
+		key:    c.internalKey("latch:" + name),
+		count:  count,
+		keep:   options.LeaseTime,
+		logger: c.logger,
+	}
+}
+
+func (l *countDownLatch) CountDown(ctx context.Context) error {
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+	remaining, err := replyInt(l.client.runScript(ctx, lua.LatchCountDown, []string{l.key},
+		l.count, l.client.eventsChannel(), l.keep.Milliseconds()))
+	if err != nil {
+		l.logger.Error(ctx, "Error counting down latch: %s", l.name)
+		return err
+	}
+	if remaining == 0 {
+		l.logger.Info(ctx, "Opened latch: %s", l.name)
+	}
+	return nil
+}
+
+func (l *countDownLatch) Await(ctx context.Context) error {
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+
+	opened := make(chan struct{}, 1)
+	stopListening, err := l.client.notifier.listen(ctx, func(key string) {
+		if key != l.key {
+			return
+		}
+		select {
+		case opened <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer stopListening()
+
+	recheck := time.NewTicker(latchRecheck)
+	defer recheck.Stop()
+
+	// Checking after subscribing cannot miss an opening in between
+	for {
+		count, err := l.Count(ctx)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-opened:
+		case <-recheck.C:
+		}
+	}
+}
+
+func (l *countDownLatch) Count(ctx context.Context) (int, error) {
+	reply, err := l.client.do(ctx, "GET", l.key)
+	if err != nil || reply == nil {
+		return l.count, err
+	}
+	count, err := replyInt(reply, nil)
+	return int(count), err
+}
@@ -0,0 +1,78 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCountDownLatch(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-latch:"))
+	defer client.Close()
+	ctx := context.Background()
+
+	t.Run("awaiters wake when the count reaches zero", func(t *testing.T) {
+		latch := client.NewCountDownLatch("test-open", 3)
+
+		done := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() { done <- client.NewCountDownLatch("test-open", 3).Await(ctx) }()
+		}
+
+		for want := 2; want >= 0; want-- {
+			if err := latch.CountDown(ctx); err != nil {
+				t.Fatalf("Failed to count down: %v", err)
+			}
+			if count, err := latch.Count(ctx); err != nil || count != want {
+				t.Fatalf("Expected count %d, got: %d, %v", want, count, err)
+			}
+			if want > 0 {
+				select {
+				case err := <-done:
+					t.Fatalf("Await returned before the latch opened: %v", err)
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+		}
+
+		// Woken by pub/sub long before the safety re-check
+		for i := 0; i < 2; i++ {
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Await failed: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Await should return once the latch opened")
+			}
+		}
+
+		// An open latch stays open
+		if err := latch.CountDown(ctx); err != nil {
+			t.Fatalf("Failed to count down: %v", err)
+		}
+		if count, _ := latch.Count(ctx); count != 0 {
+			t.Fatalf("Open latch should stay at zero, got: %d", count)
+		}
+		if err := client.NewCountDownLatch("test-open", 3).Await(ctx); err != nil {
+			t.Fatalf("Await on an open latch should return at once: %v", err)
+		}
+	})
+
+	t.Run("unstarted latch reports its count", func(t *testing.T) {
+		if count, err := client.NewCountDownLatch("test-unstarted", 5).Count(ctx); err != nil || count != 5 {
+			t.Fatalf("Expected initial count, got: %d, %v", count, err)
+		}
+	})
+
+	t.Run("await honours the context", func(t *testing.T) {
+		waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		if err := client.NewCountDownLatch("test-closed", 1).Await(waitCtx); err != context.DeadlineExceeded {
+			t.Fatalf("Expected deadline error, got: %v", err)
+		}
+	})
+}
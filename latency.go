@@ -0,0 +1,94 @@
+package arbiter
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencySamples is how many recent Redis round trips the latency stats keep
+	latencySamples = 1024
+
+	// leaseLatencyFactor is how many times tail latency a recommended lease spans
+	leaseLatencyFactor = 10
+
+	// minRecommendedLease is the shortest lease RecommendedLease suggests
+	minRecommendedLease = time.Second
+)
+
+// latencyStats keeps the latencies of recent acquisitions and refreshes
+type latencyStats struct {
+	mu      sync.Mutex
+	samples [latencySamples]time.Duration
+	next    int
+	count   int
+}
+
+func (s *latencyStats) add(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.next] = d
+	s.next = (s.next + 1) % latencySamples
+	if s.count < latencySamples {
+		s.count++
+	}
+}
+
+// percentile returns the p-th percentile of the recorded latencies, 0 without samples
+func (s *latencyStats) percentile(p float64) time.Duration {
+	return s.recentPercentile(p, latencySamples)
+}
+
+// recentPercentile returns the p-th percentile of the latest n recorded latencies
+func (s *latencyStats) recentPercentile(p float64, n int) time.Duration {
+	s.mu.Lock()
+	n = min(n, s.count)
+	sorted := make([]time.Duration, 0, n)
+	for i := 1; i <= n; i++ {
+		sorted = append(sorted, s.samples[(s.next-i+latencySamples)%latencySamples])
+	}
+	s.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// observe records the latency of a Redis round trip of a lock operation
+func (c *Client) observe(op string, start time.Time) {
+	d := time.Since(start)
+	c.latency.add(d)
+	c.metrics.Observe(MetricOperationLatency, d.Seconds(), "op", op)
+	c.updateBrownout()
+}
+
+// LatencyP99 returns the 99th percentile latency of the recent acquisitions and
+// refreshes of the client, 0 before any was made
+func (c *Client) LatencyP99() time.Duration {
+	var p99 time.Duration
+	for _, backend := range c.backends() {
+		p99 = max(p99, backend.latency.percentile(0.99))
+	}
+	return p99
+}
+
+// RecommendedLease suggests a lease for the locks of the client from the latency it
+// observed: ten times the 99th percentile of recent acquisitions and refreshes, and at
+// least one second. Leases close to tail latency expire while their holders still
+// believe they own them, which is the most common lease misconfiguration.
+func (c *Client) RecommendedLease() time.Duration {
+	return max(minRecommendedLease, leaseLatencyFactor*c.LatencyP99())
+}
+
+// WithAutoLease sizes the lease, or the watchdog timeout, from RecommendedLease at every
+// acquisition and refresh, bounded by minLease and maxLease
+func WithAutoLease(minLease, maxLease time.Duration) Option {
+	return func(o *LockOptions) {
+		o.AutoLeaseMin = minLease
+		o.AutoLeaseMax = maxLease
+	}
+}
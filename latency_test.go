@@ -0,0 +1,67 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatencyStats(t *testing.T) {
+	stats := &latencyStats{}
+	if p := stats.percentile(0.99); p != 0 {
+		t.Fatalf("Expected no latency without samples, got: %v", p)
+	}
+
+	for i := 1; i <= 2*latencySamples; i++ {
+		stats.add(time.Duration(i) * time.Millisecond)
+	}
+	// Only the most recent samples are kept
+	if p := stats.percentile(0); p != (latencySamples+1)*time.Millisecond {
+		t.Errorf("Unexpected minimum: %v", p)
+	}
+	if p := stats.percentile(1); p != 2*latencySamples*time.Millisecond {
+		t.Errorf("Unexpected maximum: %v", p)
+	}
+}
+
+func TestRecommendedLease(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	metrics := newRecordingMetrics()
+	client := NewClient(redisClient, WithKeyPrefix("test-latency:"), WithMetrics(metrics))
+	ctx := context.Background()
+
+	if lease := client.RecommendedLease(); lease != minRecommendedLease {
+		t.Fatalf("Expected minimum lease without samples, got: %v", lease)
+	}
+
+	lock := client.NewLock("test-lease", WithAutoLease(2*time.Second, 5*time.Second))
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer lock.Unlock(ctx)
+	if err := lock.Refresh(ctx); err != nil {
+		t.Fatalf("Failed to refresh lock: %v", err)
+	}
+
+	if client.LatencyP99() <= 0 {
+		t.Fatal("Latency should be recorded")
+	}
+	if n := metrics.observations(MetricOperationLatency); n != 2 {
+		t.Errorf("Expected 2 latency observations, got: %d", n)
+	}
+
+	// The lease is clamped to the lower bound on a fast Redis
+	ttl := redisClient.PTTL(ctx, client.lockKey("test-lease")).Val()
+	if ttl <= time.Second || ttl > 2*time.Second {
+		t.Errorf("Expected auto lease of 2s, got: %v", ttl)
+	}
+
+	for i := 0; i < latencySamples; i++ {
+		client.latency.add(time.Second)
+	}
+	if lease := client.RecommendedLease(); lease != 10*time.Second {
+		t.Errorf("Expected ten times the tail latency, got: %v", lease)
+	}
+}
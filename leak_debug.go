@@ -0,0 +1,27 @@
+//go:build arbiterdebug
+
+package arbiter
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+)
+
+// trackLeaks attaches a finalizer that reports locks garbage collected while still
+// held, i.e. acquired without a matching Unlock. Locks with a running watchdog stay
+// reachable from it and are never collected, so only plain leases are reported.
+func trackLeaks(l *lockImpl) {
+	runtime.SetFinalizer(l, reportLeak)
+}
+
+func reportLeak(l *lockImpl) {
+	if l.held.Load() {
+		l.logger.Error(context.Background(), "Lock garbage collected without Unlock: %s, acquired at:\n%s", l.key, l.acquiredAt)
+	}
+}
+
+// acquireSite returns the stack of the acquiring goroutine
+func acquireSite() string {
+	return string(debug.Stack())
+}
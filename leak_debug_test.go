@@ -0,0 +1,57 @@
+//go:build arbiterdebug
+
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingLogger struct {
+	NoopLogger
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *recordingLogger) Error(_ context.Context, msg string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errors = append(l.errors, fmt.Sprintf(msg, args...))
+}
+
+func TestLeakDetection(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	logger := &recordingLogger{}
+	client := NewClient(redisClient, WithKeyPrefix("test-leak:"), WithLogger(logger))
+	ctx := context.Background()
+
+	released := client.NewLock("test-released").(*lockImpl)
+	if err := released.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if err := released.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	reportLeak(released)
+	if len(logger.errors) != 0 {
+		t.Fatalf("Released lock reported as leaked: %v", logger.errors)
+	}
+
+	leaked := client.NewLock("test-leaked").(*lockImpl)
+	if err := leaked.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer leaked.Unlock(ctx)
+
+	reportLeak(leaked)
+	if len(logger.errors) != 1 || !strings.Contains(logger.errors[0], "TestLeakDetection") {
+		t.Fatalf("Expected leak report with acquisition stack, got: %v", logger.errors)
+	}
+}
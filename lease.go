@@ -0,0 +1,213 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Lease is one acquisition of a lock, returned by Lock.Acquire. Every lease holds
+// the lock under an owner token of its own, so leases taken from a shared Lock
+// value by different goroutines are independent owners.
+type Lease interface {
+	// Release releases the lock. It returns ErrLockNotHeld if the lease was lost.
+	Release(ctx context.Context) error
+
+	// Extend sets the remaining lease time to d, or refreshes the heartbeat of a permanent lock
+	Extend(ctx context.Context, d time.Duration) error
+
+	// Done returns a channel closed once the lease ends: when it is released, when the
+	// watchdog fails to keep it, or, without a watchdog, when it expires unextended.
+	Done() <-chan struct{}
+
+	// Expires returns when the lease lapses unless it is extended or kept by the watchdog
+	Expires() time.Time
+
+	// Fence returns the fencing token of the acquisition, 0 once the lease was released
+	Fence() int64
+}
+
+// lease is the Lease of one lockImpl acquisition
+type lease struct {
+	lock  Lock
+	inner *lockImpl
+	ctx   context.Context
+
+	mu      sync.Mutex
+	expired *time.Timer
+}
+
+// clone returns a lock with the name and options of l but a new owner token
+func (l *lockImpl) clone() *lockImpl {
+	options := *l.options
+	options.Owner = ""
+	return newLock(l.client, l.name, &options).(*lockImpl)
+}
+
+func (l *lockImpl) Acquire(ctx context.Context) (Lease, error) {
+	fresh := l.clone()
+	if err := fresh.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return newLease(fresh, fresh), nil
+}
+
+// newLease wraps the held lock inner, released through lock
+func newLease(lock Lock, inner *lockImpl) *lease {
+	g, ctx := inner.Group(context.Background())
+	le := &lease{lock: lock, inner: inner, ctx: ctx}
+
+	// Without a watchdog the lease ends when it expires unextended
+	if !inner.options.EnableWatchDog && !inner.options.Permanent {
+		le.expired = time.AfterFunc(time.Until(le.Expires()), func() { g.cancel(ErrLockLost) })
+	}
+	return le
+}
+
+func (le *lease) Release(ctx context.Context) error {
+	le.mu.Lock()
+	if le.expired != nil {
+		le.expired.Stop()
+	}
+	le.mu.Unlock()
+
+	return le.lock.Unlock(ctx)
+}
+
+func (le *lease) Extend(ctx context.Context, d time.Duration) error {
+	l := le.inner
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+	if l.options.Permanent {
+		d = 0
+	}
+
+	err := l.extend(ctx, d)
+	if err == ErrLockNotHeld && l.held.Load() {
+		l.notifyLost(ctx, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	le.mu.Lock()
+	if le.expired != nil {
+		le.expired.Reset(time.Until(le.Expires()))
+	}
+	le.mu.Unlock()
+	return nil
+}
+
+func (le *lease) Done() <-chan struct{} {
+	return le.ctx.Done()
+}
+
+func (le *lease) Expires() time.Time {
+	return time.UnixMilli(le.inner.expires.Load())
+}
+
+func (le *lease) Fence() int64 {
+	return le.inner.Fence()
+}
+
+// multiLease is the Lease of a multiLock acquisition, made of the leases of its locks
+type multiLease struct {
+	lock   *multiLock
+	leases []*lease
+	done   chan struct{}
+}
+
+func (m *multiLock) Acquire(ctx context.Context) (Lease, error) {
+	fresh := &multiLock{options: m.options, logger: m.logger}
+	for _, lock := range m.locks {
+		fresh.locks = append(fresh.locks, lock.(*lockImpl).clone())
+	}
+	if err := fresh.Lock(ctx); err != nil {
+		return nil, err
+	}
+
+	ml := &multiLease{lock: fresh, done: make(chan struct{})}
+	cases := make([]<-chan struct{}, len(fresh.locks))
+	for i, lock := range fresh.locks {
+		ml.leases = append(ml.leases, newLease(lock, lock.(*lockImpl)))
+		cases[i] = ml.leases[i].Done()
+	}
+
+	// The set ends with the first lease that ends
+	go func() {
+		defer close(ml.done)
+		waitAny(cases)
+	}()
+	return ml, nil
+}
+
+// waitAny blocks until one of channels is closed
+func waitAny(channels []<-chan struct{}) {
+	first := make(chan struct{})
+	var once sync.Once
+	for _, ch := range channels {
+		go func(ch <-chan struct{}) {
+			select {
+			case <-ch:
+				once.Do(func() { close(first) })
+			case <-first:
+			}
+		}(ch)
+	}
+	<-first
+}
+
+// Release releases every lock in reverse acquisition order and returns the first error
+func (ml *multiLease) Release(ctx context.Context) error {
+	for _, le := range ml.leases {
+		le.mu.Lock()
+		if le.expired != nil {
+			le.expired.Stop()
+		}
+		le.mu.Unlock()
+	}
+	return ml.lock.release(ctx, ml.lock.locks)
+}
+
+// Extend extends every lease and returns the first error
+func (ml *multiLease) Extend(ctx context.Context, d time.Duration) error {
+	var first error
+	for _, le := range ml.leases {
+		if err := le.Extend(ctx, d); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (ml *multiLease) Done() <-chan struct{} {
+	return ml.done
+}
+
+// Expires returns the earliest expiry of the leases
+func (ml *multiLease) Expires() time.Time {
+	expires := ml.leases[0].Expires()
+	for _, le := range ml.leases[1:] {
+		if e := le.Expires(); e.Before(expires) {
+			expires = e
+		}
+	}
+	return expires
+}
+
+func (ml *multiLease) Fence() int64 {
+	return ml.lock.Fence()
+}
+
+func (l *scopedLock) Acquire(ctx context.Context) (Lease, error) {
+	fresh := l.lock.(*lockImpl).clone()
+	tracked := &scopedLock{lock: fresh, scope: l.scope, name: l.name}
+	if err := tracked.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return newLease(tracked, fresh), nil
+}
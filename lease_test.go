@@ -0,0 +1,124 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLease(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-lease:"))
+	defer client.Close()
+	ctx := context.Background()
+
+	t.Run("leases of a shared lock are separate owners", func(t *testing.T) {
+		shared := client.NewLock("test-shared", WithWaitTimeout(5*time.Second))
+
+		var mu sync.Mutex
+		inside := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				lease, err := shared.Acquire(ctx)
+				if err != nil {
+					t.Errorf("Failed to acquire lease: %v", err)
+					return
+				}
+
+				mu.Lock()
+				inside++
+				if inside > 1 {
+					t.Errorf("Leases of a shared lock overlapped")
+				}
+				mu.Unlock()
+				time.Sleep(50 * time.Millisecond)
+				mu.Lock()
+				inside--
+				mu.Unlock()
+
+				if err := lease.Release(ctx); err != nil {
+					t.Errorf("Failed to release lease: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("extend moves the expiry", func(t *testing.T) {
+		lease, err := client.NewLock("test-extend", WithLeaseTime(time.Second)).Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Failed to acquire lease: %v", err)
+		}
+		defer lease.Release(ctx)
+
+		if until := time.Until(lease.Expires()); until <= 0 || until > time.Second {
+			t.Fatalf("Unexpected expiry in %v", until)
+		}
+		if lease.Fence() == 0 {
+			t.Fatal("Lease should have a fencing token")
+		}
+
+		if err := lease.Extend(ctx, time.Minute); err != nil {
+			t.Fatalf("Failed to extend lease: %v", err)
+		}
+		if until := time.Until(lease.Expires()); until < 50*time.Second {
+			t.Fatalf("Expiry should move with the extension, got %v", until)
+		}
+		if ttl := redisClient.PTTL(ctx, client.lockKey("test-extend")).Val(); ttl < 50*time.Second {
+			t.Fatalf("Key TTL should move with the extension, got %v", ttl)
+		}
+	})
+
+	t.Run("done is closed on release and expiry", func(t *testing.T) {
+		lease, err := client.NewLock("test-done").Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Failed to acquire lease: %v", err)
+		}
+		select {
+		case <-lease.Done():
+			t.Fatal("Done should not be closed while held")
+		default:
+		}
+		if err := lease.Release(ctx); err != nil {
+			t.Fatalf("Failed to release lease: %v", err)
+		}
+		<-lease.Done()
+		if lease.Fence() != 0 {
+			t.Error("Released lease should have no fencing token")
+		}
+
+		short, err := client.NewLock("test-expiry", WithLeaseTime(100*time.Millisecond)).Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Failed to acquire lease: %v", err)
+		}
+		select {
+		case <-short.Done():
+		case <-time.After(time.Second):
+			t.Fatal("Done should be closed once the lease expired")
+		}
+		short.Release(ctx)
+	})
+
+	t.Run("multi lock lease", func(t *testing.T) {
+		lease, err := client.NewMultiLock([]string{"test-a", "test-b"}).Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Failed to acquire lease: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-b"); !locked {
+			t.Fatal("Every lock should be held")
+		}
+		if err := lease.Release(ctx); err != nil {
+			t.Fatalf("Failed to release lease: %v", err)
+		}
+		<-lease.Done()
+		if locked, _ := client.IsLocked(ctx, "test-a"); locked {
+			t.Fatal("Every lock should be released")
+		}
+	})
+}
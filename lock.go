@@ -1,6 +1,9 @@
 package arbiter
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Lock represents a distributed lock interface
 type Lock interface {
@@ -14,9 +17,53 @@ type Lock interface {
 	// until unlock or ctx is done.
 	TryLock(ctx context.Context) (bool, error)
 
+	// TryLockInfo attempts to acquire the lock like TryLock. If another owner holds the
+	// lock, it also returns who holds it and the remaining TTL, read in the same round
+	// trip, for actionable log messages and smarter retry timing.
+	TryLockInfo(ctx context.Context) (bool, LockInfo, error)
+
+	// TryLockFor attempts to acquire the lock like Lock, but waits at most wait instead
+	// of the wait timeout of the lock, so one Lock value serves call sites of different
+	// patience. It reports false without error if the lock was not acquired in time. A
+	// wait of 0 attempts once like TryLock.
+	TryLockFor(ctx context.Context, wait time.Duration) (bool, error)
+
+	// LockAsync starts acquiring the lock like Lock in the background and returns a
+	// channel receiving the result once, so event loops can select on it instead of
+	// blocking a goroutine of their own. The lock is held once nil is received.
+	LockAsync(ctx context.Context) <-chan error
+
 	// Unlock releases the lock
 	Unlock(ctx context.Context) error
 
 	// Refresh manually extends the lock's lease time
 	Refresh(ctx context.Context) error
+
+	// Transfer hands the held lock to the owner token, keeping its lease and fencing
+	// token, so a draining process can pass it to its replacement without a gap. The
+	// replacement takes it over with a lock created WithOwnerToken(token). Transfer
+	// returns ErrLockNotHeld if the lock was not held.
+	Transfer(ctx context.Context, token Token) error
+
+	// Acquire acquires the lock like Lock, but under a new owner token of its own, and
+	// returns the acquisition as a Lease. Goroutines sharing a Lock value should take
+	// leases, since Lock and Unlock of the shared value act for one shared owner.
+	Acquire(ctx context.Context) (Lease, error)
+
+	// Fence returns the fencing token of the current acquisition, 0 if the lock is not held.
+	// Tokens of a lock increase with every new owner, so storage systems can reject
+	// writes carrying a token lower than one they have already seen.
+	Fence() int64
+
+	// Group returns a goroutine group tied to the lease of the held lock, and its context.
+	// The context is cancelled if the lock is lost, and Unlock waits for the group.
+	Group(ctx context.Context) (*Group, context.Context)
+
+	// Do acquires the lock, runs fn and releases the lock. fn calls checkpoint between
+	// the steps of a long job and aborts when it returns an error. Unless the watchdog
+	// keeps the lease, the context of fn is cancelled with cause ErrLockLost when the
+	// lease lapses before the next checkpoint. Do returns the error of fn, or ErrLockLost
+	// if the lock was lost while fn returned nil. If fn panics, the lock is released and
+	// the panic is handled as set by WithPanicPolicy or WithPanicHandler.
+	Do(ctx context.Context, fn func(ctx context.Context, checkpoint Checkpoint) error) error
 }
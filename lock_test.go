@@ -330,3 +330,140 @@ func TestCustomKeyPrefix(t *testing.T) {
 		}
 	})
 }
+
+func TestTryLockFor(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	holder := client.NewLock("test-trylockfor", WithLeaseTime(time.Minute))
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	waiter := client.NewLock("test-trylockfor", WithRetryInterval(10*time.Millisecond))
+	if acquired, err := waiter.TryLockFor(ctx, 0); err != nil || acquired {
+		t.Errorf("Expected single attempt to fail, got: %v, %v", acquired, err)
+	}
+	start := time.Now()
+	if acquired, err := waiter.TryLockFor(ctx, 50*time.Millisecond); err != nil || acquired {
+		t.Errorf("Expected to give up without error, got: %v, %v", acquired, err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("Expected to wait for about 50ms, waited %v", elapsed)
+	}
+	if timeout := waiter.(*lockImpl).options.WaitTimeout; timeout != 0 {
+		t.Errorf("Options of the lock should stay unchanged, wait timeout: %v", timeout)
+	}
+
+	time.AfterFunc(30*time.Millisecond, func() { holder.Unlock(ctx) })
+	if acquired, err := waiter.TryLockFor(ctx, time.Second); err != nil || !acquired {
+		t.Fatalf("Expected to acquire released lock, got: %v, %v", acquired, err)
+	}
+
+	// A multi lock shares the wait among its locks and keeps none on failure
+	multi := client.NewMultiLock([]string{"test-trylockfor-free", "test-trylockfor"}, WithRetryInterval(10*time.Millisecond))
+	if acquired, err := multi.TryLockFor(ctx, 50*time.Millisecond); err != nil || acquired {
+		t.Errorf("Expected multi lock to give up, got: %v, %v", acquired, err)
+	}
+	if acquired, err := client.NewLock("test-trylockfor-free").TryLock(ctx); err != nil || !acquired {
+		t.Errorf("Expected partially acquired locks to be released, got: %v, %v", acquired, err)
+	}
+}
+
+func TestOwnerRenewedPerAcquisition(t *testing.T) {
+	store := newMemoryStore()
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(store))
+	ctx := context.Background()
+
+	lock := client.NewLock("test-renew")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	first := lock.(*lockImpl).value
+	if err := lock.Lock(ctx); err != nil || lock.(*lockImpl).value != first {
+		t.Fatalf("Re-entering the held lock should keep the owner, got: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock again: %v", err)
+	}
+	if lock.(*lockImpl).value == first {
+		t.Fatal("Expected a new owner for the second acquisition")
+	}
+	// A late release of the first acquisition leaves the second one alone
+	if released, err := store.Release(ctx, lock.(*lockImpl).key, first); err != nil || released {
+		t.Errorf("Stale release should fail, got: %v, %v", released, err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Expected the second acquisition to be held, got: %v", err)
+	}
+
+	// Owner tokens set by the caller are kept
+	token := NewToken()
+	pinned := client.NewLock("test-renew", WithOwnerToken(token))
+	for i := 0; i < 2; i++ {
+		if err := pinned.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire pinned lock: %v", err)
+		}
+		if owner := pinned.(*lockImpl).value; owner != token.String() {
+			t.Errorf("Expected owner %s, got %s", token, owner)
+		}
+		if err := pinned.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release pinned lock: %v", err)
+		}
+	}
+}
+
+func TestOwnerRenewedAfterLoss(t *testing.T) {
+	store := newMemoryStore()
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(store))
+	ctx := context.Background()
+
+	// A Refresh finding the lock lost ends the acquisition
+	lock := client.NewLock("test-renew-lost")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	impl := lock.(*lockImpl)
+	first := impl.value
+	store.Release(ctx, impl.key, first)
+	if err := lock.Refresh(ctx); err != ErrLockNotHeld {
+		t.Fatalf("Expected ErrLockNotHeld, got: %v", err)
+	}
+	if fence := lock.Fence(); fence != 0 {
+		t.Errorf("Expected no fence after the loss, got %d", fence)
+	}
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock again: %v", err)
+	}
+	if impl.value == first {
+		t.Error("Expected a new owner after the loss")
+	}
+	lock.Unlock(ctx)
+
+	// So does a watchdog failing to refresh it
+	watched := client.NewLock("test-renew-watchdog", WithWatchDog(true), WithWatchDogTimeout(60*time.Millisecond))
+	if err := watched.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	impl = watched.(*lockImpl)
+	first = impl.value
+	store.Release(ctx, impl.key, first)
+	deadline := time.Now().Add(2 * time.Second)
+	for watched.Fence() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the watchdog to end the lost acquisition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := watched.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock again: %v", err)
+	}
+	if impl.value == first {
+		t.Error("Expected a new owner after the watchdog lost the lock")
+	}
+	watched.Unlock(ctx)
+}
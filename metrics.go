@@ -0,0 +1,53 @@
+package arbiter
+
+// Metric names emitted by the client
+const (
+	// MetricDistinctLockNames is a gauge of the distinct lock names created by a client
+	MetricDistinctLockNames = "arbiter_distinct_lock_names"
+	// MetricLockCardinalityExceeded counts lock names created after the cardinality limit was crossed
+	MetricLockCardinalityExceeded = "arbiter_lock_cardinality_exceeded_total"
+	// MetricLockNamesCoalesced counts the distinct lock names mapped onto a coalescing
+	// bucket, up to 65536 names per client
+	MetricLockNamesCoalesced = "arbiter_lock_names_coalesced_total"
+	// MetricOperationLatency observes the Redis latency of acquisitions and refreshes in seconds
+	MetricOperationLatency = "arbiter_operation_latency_seconds"
+	// MetricBrownout is a gauge set to 1 while the client lengthens leases under Redis pressure
+	MetricBrownout = "arbiter_brownout"
+	// MetricLockAcquisitions counts locks acquired by Lock, labelled by lock name
+	MetricLockAcquisitions = "arbiter_lock_acquisitions_total"
+	// MetricLockWait observes how long Lock waited for an acquired lock in seconds, labelled by lock name
+	MetricLockWait = "arbiter_lock_wait_seconds"
+	// MetricLockLost counts held locks found lost, labelled by lock name
+	MetricLockLost = "arbiter_lock_lost_total"
+	// MetricWatchdogFailures counts watchdog refreshes that failed, labelled by lock name
+	MetricWatchdogFailures = "arbiter_watchdog_failures_total"
+)
+
+// LabelLock is the label carrying the lock name of per-lock metrics. Names coalesced by
+// WithNameCoalescing carry the name of their bucket, so the label stays bounded too.
+const LabelLock = "lock"
+
+// Metrics is the interface that receives measurements emitted by the client.
+// Labels are passed as alternating key and value strings.
+type Metrics interface {
+	// IncCounter increments a counter by delta.
+	IncCounter(name string, delta int64, labels ...string)
+	// SetGauge sets a gauge to value.
+	SetGauge(name string, value float64, labels ...string)
+	// Observe records a value, such as a latency in seconds, into a histogram.
+	Observe(name string, value float64, labels ...string)
+}
+
+// WithMetrics sets the metrics sink for the client
+func WithMetrics(metrics Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+// NoopMetrics is a metrics sink that does nothing.
+type NoopMetrics struct{}
+
+func (m *NoopMetrics) IncCounter(name string, delta int64, labels ...string) {}
+func (m *NoopMetrics) SetGauge(name string, value float64, labels ...string) {}
+func (m *NoopMetrics) Observe(name string, value float64, labels ...string)  {}
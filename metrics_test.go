@@ -0,0 +1,28 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLockMetrics(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	metrics := newRecordingMetrics()
+	client := NewClient(redisClient, WithKeyPrefix("test-metrics:"), WithMetrics(metrics))
+	ctx := context.Background()
+
+	lock := client.NewLock("test-lock")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer lock.Unlock(ctx)
+
+	if metrics.counters[MetricLockAcquisitions] != 1 {
+		t.Errorf("Expected one acquisition, got: %d", metrics.counters[MetricLockAcquisitions])
+	}
+	if metrics.observations(MetricLockWait) != 1 {
+		t.Errorf("Expected one wait observation, got: %d", metrics.observations(MetricLockWait))
+	}
+}
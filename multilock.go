@@ -0,0 +1,182 @@
+package arbiter
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// multiLock holds several locks as one unit
+type multiLock struct {
+	locks   []Lock
+	options *LockOptions
+	logger  Logger
+}
+
+// NewMultiLock creates a lock over all named locks that acquires all of them or none.
+// The locks are acquired in sorted name order, so multi locks sharing names never
+// deadlock, and locks acquired before a failure are released again. Unlock and
+// Refresh operate on every lock; Group and Do are tied to the lease of all of them.
+func (c *Client) NewMultiLock(names []string, opts ...Option) Lock {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+
+	m := &multiLock{options: options, logger: c.logger}
+	for i, name := range sorted {
+		if i > 0 && name == sorted[i-1] {
+			continue
+		}
+		m.locks = append(m.locks, c.NewLock(name, opts...))
+	}
+	return m
+}
+
+func (m *multiLock) Lock(ctx context.Context) error {
+	// The wait timeout bounds the acquisition of all locks together
+	waitCtx := ctx
+	if m.options.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, m.options.WaitTimeout)
+		defer cancel()
+	}
+
+	for i, lock := range m.locks {
+		if err := lock.Lock(waitCtx); err != nil {
+			m.release(ctx, m.locks[:i])
+			if waitCtx.Err() != nil && ctx.Err() == nil {
+				return ErrLockTimeout
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiLock) TryLock(ctx context.Context) (bool, error) {
+	for i, lock := range m.locks {
+		acquired, err := lock.TryLock(ctx)
+		if err != nil || !acquired {
+			m.release(ctx, m.locks[:i])
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// TryLockInfo reports the holder of the first lock that was not acquired
+func (m *multiLock) TryLockInfo(ctx context.Context) (bool, LockInfo, error) {
+	for i, lock := range m.locks {
+		acquired, holder, err := lock.TryLockInfo(ctx)
+		if err != nil || !acquired {
+			m.release(ctx, m.locks[:i])
+			return false, holder, err
+		}
+	}
+	return true, LockInfo{}, nil
+}
+
+// TryLockFor acquires the locks in order within wait in total, releasing those already
+// held if one was not acquired in time
+func (m *multiLock) TryLockFor(ctx context.Context, wait time.Duration) (bool, error) {
+	deadline := time.Now().Add(wait)
+	for i, lock := range m.locks {
+		acquired, err := lock.TryLockFor(ctx, max(time.Until(deadline), 0))
+		if err != nil || !acquired {
+			m.release(ctx, m.locks[:i])
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Unlock releases every lock and returns the first error
+func (m *multiLock) Unlock(ctx context.Context) error {
+	return m.release(ctx, m.locks)
+}
+
+// Refresh extends every lock and returns the first error
+func (m *multiLock) Refresh(ctx context.Context) error {
+	var first error
+	for _, lock := range m.locks {
+		if err := lock.Refresh(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Fence returns the sum of the fencing tokens of the locks. Every token only grows, so
+// the sum grows with every new owner of the set and fences the set as a whole.
+func (m *multiLock) Fence() int64 {
+	var fence int64
+	for _, lock := range m.locks {
+		f := lock.Fence()
+		if f == 0 {
+			return 0
+		}
+		fence += f
+	}
+	return fence
+}
+
+func (m *multiLock) Group(ctx context.Context) (*Group, context.Context) {
+	// Chaining the group contexts cancels the last one when any lock is lost
+	var g *Group
+	for _, lock := range m.locks {
+		g, ctx = lock.Group(ctx)
+	}
+	return g, ctx
+}
+
+func (m *multiLock) Do(ctx context.Context, fn func(ctx context.Context, checkpoint Checkpoint) error) error {
+	if err := m.Lock(ctx); err != nil {
+		return err
+	}
+
+	g, fnCtx := m.Group(ctx)
+	checkpoint := func(ctx context.Context) error {
+		if context.Cause(fnCtx) == ErrLockLost {
+			return ErrLockLost
+		}
+
+		err := m.Refresh(ctx)
+		if err == ErrLockNotHeld || err == ErrLockReacquired {
+			g.cancel(ErrLockLost)
+			return ErrLockLost
+		}
+		return err
+	}
+
+	panicked, fnErr := protect(func() error { return fn(fnCtx, checkpoint) })
+	lost := context.Cause(fnCtx) == ErrLockLost
+
+	err := m.Unlock(context.WithoutCancel(ctx))
+	switch {
+	case panicked != nil:
+		return m.options.handlePanic(ctx, panicked)
+	case fnErr != nil:
+		return fnErr
+	case lost:
+		return ErrLockLost
+	}
+	return err
+}
+
+// release unlocks locks in reverse acquisition order and returns the first error
+func (m *multiLock) release(ctx context.Context, locks []Lock) error {
+	var first error
+	for i := len(locks) - 1; i >= 0; i-- {
+		if err := locks[i].Unlock(context.WithoutCancel(ctx)); err != nil {
+			m.logger.Warn(ctx, "Failed to release lock of multi lock, error: %v", err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
@@ -0,0 +1,115 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMultiLock(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-multi:"))
+	ctx := context.Background()
+
+	locked := func(names ...string) []bool {
+		states := make([]bool, len(names))
+		for i, name := range names {
+			states[i], _ = client.IsLocked(ctx, name)
+		}
+		return states
+	}
+
+	t.Run("acquires and releases all", func(t *testing.T) {
+		lock := client.NewMultiLock([]string{"b", "a", "c", "a"})
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire multi lock: %v", err)
+		}
+		if states := locked("a", "b", "c"); !states[0] || !states[1] || !states[2] {
+			t.Fatalf("Every lock should be held: %v", states)
+		}
+
+		if err := lock.Refresh(ctx); err != nil {
+			t.Fatalf("Failed to refresh multi lock: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release multi lock: %v", err)
+		}
+		if states := locked("a", "b", "c"); states[0] || states[1] || states[2] {
+			t.Fatalf("Every lock should be released: %v", states)
+		}
+	})
+
+	t.Run("partial failure releases acquired locks", func(t *testing.T) {
+		holder := client.NewLock("y")
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		lock := client.NewMultiLock([]string{"x", "y", "z"})
+		acquired, err := lock.TryLock(ctx)
+		if err != nil || acquired {
+			t.Fatalf("Multi lock should not be acquired, got: %v, %v", acquired, err)
+		}
+		if states := locked("x", "z"); states[0] || states[1] {
+			t.Fatalf("No lock should be left held: %v", states)
+		}
+
+		if err := client.NewMultiLock([]string{"x", "y"}, WithWaitTimeout(200*time.Millisecond)).Lock(ctx); err != ErrLockTimeout {
+			t.Fatalf("Expected timeout error, got: %v", err)
+		}
+		if states := locked("x"); states[0] {
+			t.Fatal("Timed out multi lock should release acquired locks")
+		}
+
+		holder.Unlock(ctx)
+		acquired, err = lock.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Multi lock should be acquired once free, got: %v, %v", acquired, err)
+		}
+		lock.Unlock(ctx)
+	})
+
+	t.Run("overlapping multi locks do not deadlock", func(t *testing.T) {
+		done := make(chan error, 2)
+		for _, names := range [][]string{{"p", "q"}, {"q", "p"}} {
+			go func(names []string) {
+				lock := client.NewMultiLock(names, WithWaitTimeout(5*time.Second))
+				for i := 0; i < 5; i++ {
+					if err := lock.Lock(ctx); err != nil {
+						done <- err
+						return
+					}
+					lock.Unlock(ctx)
+				}
+				done <- nil
+			}(names)
+		}
+		for i := 0; i < 2; i++ {
+			if err := <-done; err != nil {
+				t.Fatalf("Multi lock failed: %v", err)
+			}
+		}
+	})
+
+	t.Run("checkpoint detects any lost lock", func(t *testing.T) {
+		lock := client.NewMultiLock([]string{"m", "n"})
+		err := lock.Do(ctx, func(ctx context.Context, checkpoint Checkpoint) error {
+			if err := checkpoint(ctx); err != nil {
+				t.Fatalf("Checkpoint failed: %v", err)
+			}
+			client.Admin().ForceUnlock(ctx, "n")
+			if err := checkpoint(ctx); err != ErrLockLost {
+				t.Fatalf("Expected lost error, got: %v", err)
+			}
+			return nil
+		})
+		if err != ErrLockLost {
+			t.Fatalf("Expected lost error, got: %v", err)
+		}
+		if states := locked("m"); states[0] {
+			t.Fatal("Remaining lock should be released")
+		}
+	})
+}
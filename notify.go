@@ -0,0 +1,87 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+)
+
+// notifier fans out lock state change notifications published by the Lua scripts.
+// Every client shares a single subscription, the payload of each message is the
+// Redis key of the lock that changed.
+type notifier struct {
+	client  *Client
+	channel string
+	logger  Logger
+
+	mu       sync.Mutex
+	stop     func()
+	nextID   int
+	handlers map[int]func(key string)
+}
+
+func newNotifier(client *Client, channel string, logger Logger) *notifier {
+	return &notifier{
+		client:   client,
+		channel:  channel,
+		logger:   logger,
+		handlers: make(map[int]func(string)),
+	}
+}
+
+// eventsChannel returns the pub/sub channel lock state changes are published on
+func (c *Client) eventsChannel() string {
+	return c.internalKey("events")
+}
+
+// listen registers fn to be called with the key of every changed lock.
+// The subscription is established on first use. fn must not block.
+func (n *notifier) listen(ctx context.Context, fn func(key string)) (func(), error) {
+	executor := n.client.executor
+	if executor == nil {
+		return nil, ErrStoreUnsupported
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.stop == nil {
+		stop, err := executor.Subscribe(ctx, n.channel, n.dispatch)
+		if err != nil {
+			n.logger.Error(ctx, "Failed to subscribe to lock events: %s, error: %v", n.channel, err)
+			return nil, err
+		}
+		n.stop = stop
+	}
+
+	id := n.nextID
+	n.nextID++
+	n.handlers[id] = fn
+
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.handlers, id)
+	}, nil
+}
+
+// dispatch delivers a message to the handlers
+func (n *notifier) dispatch(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, fn := range n.handlers {
+		fn(key)
+	}
+}
+
+// close drops the subscription
+func (n *notifier) close() error {
+	n.mu.Lock()
+	stop := n.stop
+	n.stop = nil
+	n.mu.Unlock()
+
+	// Not stopped under mu, the executor may wait for a message being dispatched
+	if stop != nil {
+		stop()
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// ErrStaleFence is returned by RunOnceWithFence when the run finished after its lock
+// was lost, or after another holder already completed the job
+var ErrStaleFence = errors.New("stale fencing token")
+
+// RunOnceWithFence runs fn at most once to completion across all holders of the named
+// lock. fn receives the fencing token of the acquisition to pass along with its side
+// effects. Once fn returns nil, the token is recorded as the completing generation in
+// the same round trip that verifies the lock is still held, and later calls skip fn.
+// It returns whether fn completed in this call, and ErrStaleFence if fn returned nil
+// but the completion could not be recorded because the lock was lost meanwhile.
+func (c *Client) RunOnceWithFence(ctx context.Context, name string, fn func(ctx context.Context, fence int64) error, opts ...Option) (bool, error) {
+	lock := c.NewLock(name, opts...).(*lockImpl)
+	marker := lock.client.onceKey(lock.key)
+
+	completed := false
+	err := lock.Do(ctx, func(ctx context.Context, checkpoint Checkpoint) error {
+		fence, done, err := lock.client.completedFence(ctx, marker)
+		if err != nil {
+			return err
+		}
+		if done {
+			c.logger.Debug(ctx, "Skipping run completed by fence %d: %s", fence, name)
+			return nil
+		}
+
+		if err := fn(ctx, lock.Fence()); err != nil {
+			return err
+		}
+
+		ok, err := lock.client.runScriptBool(ctx, lua.CompleteOnce, []string{lock.key, marker}, lock.value, lock.Fence())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			c.logger.Warn(ctx, "Run finished with stale fence %d: %s", lock.Fence(), name)
+			return ErrStaleFence
+		}
+		completed = true
+		return nil
+	})
+	switch {
+	case completed && (err == ErrLockLost || err == ErrLockNotHeld):
+		// The completion is recorded, only the release came too late
+		return true, nil
+	case err == ErrLockLost:
+		return false, ErrStaleFence
+	}
+	return completed, err
+}
+
+// CompletedFence returns the fencing token of the run that completed the job of
+// RunOnceWithFence for the named lock, and whether the job completed
+func (c *Client) CompletedFence(ctx context.Context, name string) (int64, bool, error) {
+	r := c.route(name)
+	return r.completedFence(ctx, r.onceKey(r.lockKey(name)))
+}
+
+// completedFence reads a completion marker
+func (c *Client) completedFence(ctx context.Context, marker string) (int64, bool, error) {
+	reply, err := c.do(ctx, "GET", marker)
+	if err != nil || reply == nil {
+		return 0, false, err
+	}
+	fence, _ := replyInt(reply, nil)
+	return fence, true, nil
+}
+
+// onceKey returns the Redis key of the completion marker of a lock key.
+// Markers have no TTL so completed jobs stay completed.
+func (c *Client) onceKey(lockKey string) string {
+	return c.internalKey("once:" + strings.TrimPrefix(lockKey, c.prefix))
+}
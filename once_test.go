@@ -0,0 +1,82 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunOnceWithFence(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-once:"))
+	defer client.Close()
+	ctx := context.Background()
+
+	t.Run("completed jobs are skipped", func(t *testing.T) {
+		runs := 0
+		var fences []int64
+		job := func(ctx context.Context, fence int64) error {
+			runs++
+			fences = append(fences, fence)
+			return nil
+		}
+
+		ran, err := client.RunOnceWithFence(ctx, "test-job", job)
+		if err != nil || !ran {
+			t.Fatalf("First run should complete, got: %v, %v", ran, err)
+		}
+		ran, err = client.RunOnceWithFence(ctx, "test-job", job)
+		if err != nil || ran {
+			t.Fatalf("Second run should be skipped, got: %v, %v", ran, err)
+		}
+		if runs != 1 {
+			t.Fatalf("Job should run once, ran %d times", runs)
+		}
+
+		fence, done, err := client.CompletedFence(ctx, "test-job")
+		if err != nil || !done || fence != fences[0] {
+			t.Fatalf("Unexpected completed fence: %d, %v, %v, want %d", fence, done, err, fences[0])
+		}
+	})
+
+	t.Run("failed runs are retried", func(t *testing.T) {
+		failure := errors.New("failed")
+		if _, err := client.RunOnceWithFence(ctx, "test-retry", func(ctx context.Context, fence int64) error {
+			return failure
+		}); err != failure {
+			t.Fatalf("Expected job error, got: %v", err)
+		}
+
+		ran, err := client.RunOnceWithFence(ctx, "test-retry", func(ctx context.Context, fence int64) error {
+			return nil
+		})
+		if err != nil || !ran {
+			t.Fatalf("Retry should complete, got: %v, %v", ran, err)
+		}
+	})
+
+	t.Run("stale holders cannot complete", func(t *testing.T) {
+		ran, err := client.RunOnceWithFence(ctx, "test-stale", func(ctx context.Context, fence int64) error {
+			// The lock is taken over while the job runs
+			if err := client.Admin().ForceUnlock(ctx, "test-stale"); err != nil {
+				return err
+			}
+			other, err := client.RunOnceWithFence(ctx, "test-stale", func(ctx context.Context, newer int64) error {
+				if newer <= fence {
+					t.Errorf("New holder should have a higher fence: %d <= %d", newer, fence)
+				}
+				return nil
+			})
+			if err != nil || !other {
+				t.Errorf("New holder should complete, got: %v, %v", other, err)
+			}
+			return nil
+		}, WithLeaseTime(time.Minute))
+		if err != ErrStaleFence || ran {
+			t.Fatalf("Expected stale fence error, got: %v, %v", ran, err)
+		}
+	})
+}
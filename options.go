@@ -1,6 +1,9 @@
 package arbiter
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // LockOptions defines the options for lock configuration
 type LockOptions struct {
@@ -15,6 +18,60 @@ type LockOptions struct {
 
 	// WatchDogTimeout specifies the watchdog timeout (only valid when EnableWatchDog is true)
 	WatchDogTimeout time.Duration
+
+	// Permanent stores the lock without a key TTL, liveness is tracked by a heartbeat key.
+	// It is only set explicitly, by WithNoExpiry or WithPermanent.
+	Permanent bool
+
+	// HeartbeatTimeout specifies the heartbeat key TTL (only valid when Permanent is true)
+	HeartbeatTimeout time.Duration
+
+	// AutoReacquire makes Refresh take a lapsed lease again instead of failing
+	AutoReacquire bool
+
+	// AutoLeaseMin and AutoLeaseMax bound the lease sized from observed latency, unset when 0
+	AutoLeaseMin time.Duration
+	AutoLeaseMax time.Duration
+
+	// OnRefresh is called after every successful refresh of the lease
+	OnRefresh func(ctx context.Context, lease time.Duration)
+
+	// OnLost is called once per acquisition when the lock is found lost
+	OnLost func(ctx context.Context, err error)
+
+	// PanicPolicy selects what Do does when fn panics, OnPanic takes precedence if set
+	PanicPolicy PanicPolicy
+	OnPanic     PanicHandler
+
+	// Owner is the owner token of the lock, a random token when empty
+	Owner string
+
+	// RetryInterval is how long waiting Lock calls sleep between attempts while polling,
+	// 100ms when 0
+	RetryInterval time.Duration
+
+	// RetryStrategy replaces the retry interval of waiting calls, unset when nil
+	RetryStrategy RetryStrategy
+
+	// MaxRetries is how often waiting calls retry before giving up, unlimited when 0
+	MaxRetries int
+
+	// ContextWaitTimeout makes the deadline of the ctx of a Lock call its wait timeout
+	// when WaitTimeout is 0
+	ContextWaitTimeout bool
+
+	// BackoffSpacing and BackoffMax spread the retries of waiting Lock calls, unset when 0
+	BackoffSpacing time.Duration
+	BackoffMax     time.Duration
+
+	// AttemptTimeout bounds the round trip of a single acquisition attempt, derived from
+	// the lease when 0
+	AttemptTimeout time.Duration
+
+	// ReplicationReplicas is the number of replicas an acquisition must reach within
+	// ReplicationTimeout before it counts, unset when 0
+	ReplicationReplicas int
+	ReplicationTimeout  time.Duration
 }
 
 // Option is a function type for setting lock options
@@ -48,6 +105,180 @@ func WithWatchDogTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithPermanent stores the lock without expiry and keeps a separate heartbeat key alive
+// every heartbeat/3 until unlock. A holder that stops heartbeating keeps the lock until
+// it is removed by Client.Reap, for resources where expiry is worse than manual cleanup.
+// It is shorthand for WithNoExpiry and WithHeartbeat(heartbeat).
+func WithPermanent(heartbeat time.Duration) Option {
+	return func(o *LockOptions) {
+		o.Permanent = true
+		o.HeartbeatTimeout = heartbeat
+	}
+}
+
+// WithNoExpiry opts into storing the lock without expiry. A lease that rounds to zero is
+// refused with ErrInvalidLease instead, so no lock outlives its holder by accident. The
+// holder must heartbeat, set with WithHeartbeat, and a Client.RunReaper loop must remove
+// locks whose heartbeat lapsed; acquisitions without a heartbeat fail with ErrInvalidLease.
+func WithNoExpiry() Option {
+	return func(o *LockOptions) {
+		o.Permanent = true
+	}
+}
+
+// WithHeartbeat sets the TTL of the heartbeat key of a lock stored without expiry,
+// refreshed every heartbeat/3 until unlock
+func WithHeartbeat(heartbeat time.Duration) Option {
+	return func(o *LockOptions) {
+		o.HeartbeatTimeout = heartbeat
+	}
+}
+
+// WithAutoReacquire sets a short lease without watchdog that Refresh re-acquires
+// transparently if it lapsed mid-operation, trading strictness for less Redis traffic.
+// Call Refresh at checkpoints of the work. If another owner held the lock in between,
+// Refresh still re-acquires it but returns ErrLockReacquired, so only use this mode
+// for idempotent work.
+func WithAutoReacquire(lease time.Duration) Option {
+	return func(o *LockOptions) {
+		o.AutoReacquire = true
+		o.EnableWatchDog = false
+		o.LeaseTime = lease
+	}
+}
+
+// WithRefreshCallback calls fn after every successful lease refresh, by the watchdog
+// or Refresh, with the context the lock was acquired or refreshed with and the new lease,
+// 0 for permanent locks.
+// It bridges lock leases to activity heartbeats of workflow engines such as Temporal
+// or Cadence, so activity timeouts and leases stay in lockstep:
+//
+//	arbiter.WithRefreshCallback(func(ctx context.Context, lease time.Duration) {
+//		activity.RecordHeartbeat(ctx)
+//	})
+//
+// fn runs on the refreshing goroutine and delays the next refresh, so keep it short.
+func WithRefreshCallback(fn func(ctx context.Context, lease time.Duration)) Option {
+	return func(o *LockOptions) {
+		o.OnRefresh = fn
+	}
+}
+
+// WithLostCallback sets a function called when the held lock is found lost: the
+// watchdog failed to refresh it, its context ended with the watchdog running, or a
+// Refresh found another owner. err tells why. It is called at most once per
+// acquisition, so the holder can abort its critical section instead of continuing
+// with an expired lease. fn must not call Unlock of the lock.
+func WithLostCallback(fn func(ctx context.Context, err error)) Option {
+	return func(o *LockOptions) {
+		o.OnLost = fn
+	}
+}
+
+// WithPanicPolicy sets what Do does when fn panics after releasing the lock: panic
+// again, the default, or return the panic as an error wrapping ErrPanicked
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(o *LockOptions) {
+		o.PanicPolicy = policy
+	}
+}
+
+// WithPanicHandler sets a handler called when fn of Do panics, after the lock was
+// released, e.g. to report the crash. Do returns the error of the handler, which may
+// also panic again to keep crashing.
+func WithPanicHandler(handler PanicHandler) Option {
+	return func(o *LockOptions) {
+		o.OnPanic = handler
+	}
+}
+
+// WithOwnerToken sets the owner token of the lock instead of a random one drawn for
+// every acquisition. With the token passed to Transfer, the receiving process takes
+// over the held lock by calling Lock, which re-enters it at once and keeps its fencing
+// token.
+func WithOwnerToken(token Token) Option {
+	return func(o *LockOptions) {
+		o.Owner = token.String()
+	}
+}
+
+// WithRetryInterval sets how long Lock calls waiting for the lock sleep between
+// attempts, 100ms by default. A shorter interval acquires sooner after a lease lapsed
+// at the price of more round trips, a longer one suits batch jobs. While releases wake
+// waiting Lock calls, the interval also bounds how long they wait for a wakeup, which
+// is up to a second otherwise. Read-write locks and semaphores poll at the interval too.
+func WithRetryInterval(d time.Duration) Option {
+	return func(o *LockOptions) {
+		o.RetryInterval = d
+	}
+}
+
+// WithMaxRetries makes Lock calls give up with ErrMaxRetries once they retried n times
+// after their first attempt, even before the wait timeout passed, for request paths
+// that must fail fast. Immediate retries after a release woke the call count too, and
+// read-write locks and semaphores give up the same way.
+func WithMaxRetries(n int) Option {
+	return func(o *LockOptions) {
+		o.MaxRetries = n
+	}
+}
+
+// retriesExhausted reports whether a waiting call retried retries times may not retry again
+func (o *LockOptions) retriesExhausted(retries int) bool {
+	return o.MaxRetries > 0 && retries >= o.MaxRetries
+}
+
+// retryInterval returns the interval waiting calls poll at
+func (o *LockOptions) retryInterval() time.Duration {
+	if o.RetryInterval <= 0 {
+		return defaultRetryInterval
+	}
+	return o.RetryInterval
+}
+
+// WithCoordinatedBackoff spreads the retries of Lock calls waiting for the lock across
+// processes. Instead of retrying when the lock is released, each failed attempt claims
+// the next retry slot from a hint shared in Redis, spacing after the last claimed one
+// and at most maxDelay ahead, so a fleet of waiters retries one after another rather
+// than all at once. It costs one more round trip per retry.
+func WithCoordinatedBackoff(spacing, maxDelay time.Duration) Option {
+	return func(o *LockOptions) {
+		o.BackoffSpacing = spacing
+		o.BackoffMax = maxDelay
+	}
+}
+
+// WithAttemptTimeout bounds the round trip of every acquisition attempt. An attempt
+// still waiting for Redis after timeout is abandoned with ErrAttemptTimeout, so a hung
+// backend cannot pin the caller. By default attempts time out after a second, or ten
+// delays of WithCoordinatedBackoff if longer, but never later than the lease lapses.
+// The retry interval does not shorten it. Attempts had no timeout of their own before
+// the default was introduced; set a longer timeout if Redis round trips legitimately
+// take longer, e.g. across regions.
+func WithAttemptTimeout(timeout time.Duration) Option {
+	return func(o *LockOptions) {
+		o.AttemptTimeout = timeout
+	}
+}
+
+// WithReplicationWait makes every acquisition wait with WAIT until the lock reached
+// replicas replicas, for at most timeout, before it counts. An acquisition they did
+// not acknowledge in time is released again and fails with ErrNotReplicated, so a
+// failover right after Lock returned is unlikely to promote a replica that never saw
+// the lock. Replication stays asynchronous: this narrows the window, it does not close
+// it. A timeout of 0 waits for as long as the attempt timeout. Stores that do not
+// implement ReplicatedStore fail such acquisitions with ErrStoreUnsupported, and so does
+// a client on a redis.Ring, which cannot tell the shard of a key to wait on.
+func WithReplicationWait(replicas int, timeout time.Duration) Option {
+	return func(o *LockOptions) {
+		o.ReplicationReplicas = replicas
+		o.ReplicationTimeout = timeout
+	}
+}
+
+// defaultRetryInterval is how long waiting calls sleep between attempts by default
+const defaultRetryInterval = 100 * time.Millisecond
+
 // defaultOptions returns the default lock options
 func defaultOptions() *LockOptions {
 	return &LockOptions{
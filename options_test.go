@@ -1,6 +1,8 @@
 package arbiter
 
 import (
+	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -97,3 +99,153 @@ func TestLockOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshCallback(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-refresh-callback:"))
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "activity")
+
+	var heartbeats atomic.Int32
+	heartbeat := WithRefreshCallback(func(ctx context.Context, lease time.Duration) {
+		if ctx.Value(key{}) != "activity" {
+			t.Error("Callback should receive the context of the lock")
+		}
+		if lease != 300*time.Millisecond {
+			t.Errorf("Unexpected lease: %v", lease)
+		}
+		heartbeats.Add(1)
+	})
+
+	lock := client.NewLock("test-heartbeat", WithWatchDog(true), WithWatchDogTimeout(300*time.Millisecond), heartbeat)
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if heartbeats.Load() != 0 {
+		t.Fatal("Acquisition should not count as a refresh")
+	}
+
+	// The watchdog refreshes every 100ms
+	waitFor(t, func() bool { return heartbeats.Load() >= 2 })
+
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	after := heartbeats.Load()
+	if err := lock.Refresh(ctx); err != ErrLockNotHeld {
+		t.Fatalf("Expected not held error, got: %v", err)
+	}
+	if heartbeats.Load() != after {
+		t.Fatal("Failed refresh should not call the callback")
+	}
+}
+
+func TestLostCallback(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-lost-callback:"))
+	ctx := context.Background()
+
+	t.Run("watchdog failure", func(t *testing.T) {
+		lost := make(chan error, 2)
+		lock := client.NewLock("test-watchdog", WithWatchDog(true), WithWatchDogTimeout(300*time.Millisecond),
+			WithLostCallback(func(ctx context.Context, err error) { lost <- err }))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		if err := client.Admin().ForceUnlock(ctx, "test-watchdog"); err != nil {
+			t.Fatalf("Failed to force unlock: %v", err)
+		}
+		select {
+		case err := <-lost:
+			if err != ErrLockNotHeld {
+				t.Errorf("Unexpected lost error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Lost callback should be called")
+		}
+
+		// Later failures of the same acquisition are not reported again
+		lock.Refresh(ctx)
+		if len(lost) != 0 {
+			t.Error("Lost callback should be called once per acquisition")
+		}
+	})
+
+	t.Run("refresh after unlock", func(t *testing.T) {
+		var calls atomic.Int32
+		lock := client.NewLock("test-unlocked", WithLostCallback(func(ctx context.Context, err error) { calls.Add(1) }))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+		if err := lock.Refresh(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+		if calls.Load() != 0 {
+			t.Fatal("A released lock should not be reported lost")
+		}
+	})
+}
+
+func TestWatchdogStall(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	sink := &recordingSink{}
+	client := NewClient(redisClient, WithKeyPrefix("test-watchdog-stall:"), WithEventSink(sink))
+	ctx := context.Background()
+
+	// A slow refresh callback delays the next refresh like a long GC pause
+	var stalls atomic.Int32
+	stall := WithRefreshCallback(func(ctx context.Context, lease time.Duration) {
+		if stalls.Add(1) == 1 {
+			time.Sleep(250 * time.Millisecond)
+		}
+	})
+
+	t.Run("lock still held", func(t *testing.T) {
+		lock := client.NewLock("test-held", WithWatchDog(true), WithWatchDogTimeout(300*time.Millisecond), stall)
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		waitFor(t, func() bool { return stalls.Load() >= 3 })
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Lock should survive a stall while held: %v", err)
+		}
+		if types := sink.types(); len(types) != 1 || types[0] != EventWatchdogStall {
+			t.Fatalf("Unexpected events: %v", types)
+		}
+	})
+
+	t.Run("lock changed hands", func(t *testing.T) {
+		stalls.Store(0)
+		sink.mu.Lock()
+		sink.events = nil
+		sink.mu.Unlock()
+
+		lock := client.NewLock("test-taken", WithWatchDog(true), WithWatchDogTimeout(300*time.Millisecond), stall)
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		// A new acquisition under the same owner value, as by a local handoff, during the
+		// stall passes the refresh but not the fencing check
+		waitFor(t, func() bool { return stalls.Load() >= 1 })
+		redisClient.HSet(ctx, client.lockKey("test-taken"), "fence", 99)
+
+		waitFor(t, func() bool { return len(sink.types()) == 2 })
+		if types := sink.types(); types[0] != EventWatchdogStall || types[1] != EventLockLost {
+			t.Fatalf("Unexpected events: %v", types)
+		}
+		redisClient.Del(ctx, client.lockKey("test-taken"))
+	})
+}
@@ -0,0 +1,53 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPanicked is wrapped by the error Do returns for a panic of fn under PanicError
+var ErrPanicked = errors.New("locked function panicked")
+
+// PanicPolicy selects what Do does when fn panics. The lock is released first under
+// every policy, so a crashing critical section never leaves it held until the lease lapses.
+type PanicPolicy int
+
+const (
+	// PanicRepanic panics again with the recovered value, the default
+	PanicRepanic PanicPolicy = iota
+	// PanicError returns an error wrapping ErrPanicked with the value and stack
+	PanicError
+)
+
+// PanicHandler handles a panic of fn in Do after the lock was released. value is the
+// recovered value and stack the stack of the panicking goroutine. Do returns its error.
+type PanicHandler func(ctx context.Context, value interface{}, stack []byte) error
+
+// recovered is a panic recovered from fn
+type recovered struct {
+	value interface{}
+	stack []byte
+}
+
+// protect runs fn and recovers a panic in it
+func protect(fn func() error) (r *recovered, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			r = &recovered{value: value, stack: debug.Stack()}
+		}
+	}()
+	return nil, fn()
+}
+
+// handlePanic applies the panic handling of o to a panic recovered from fn
+func (o *LockOptions) handlePanic(ctx context.Context, r *recovered) error {
+	if o.OnPanic != nil {
+		return o.OnPanic(ctx, r.value, r.stack)
+	}
+	if o.PanicPolicy == PanicError {
+		return fmt.Errorf("%w: %v\n%s", ErrPanicked, r.value, r.stack)
+	}
+	panic(r.value)
+}
@@ -0,0 +1,78 @@
+package arbiter
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// heartbeatKey returns the heartbeat key of a lock key
+func (c *Client) heartbeatKey(lockKey string) string {
+	return c.internalKey("heartbeat:" + strings.TrimPrefix(lockKey, c.prefix))
+}
+
+// permanentKey returns the Redis key of the set of permanent lock keys holding lockKey
+func (c *Client) permanentKey(lockKey string) string {
+	return c.slotKey(lockKey, "permanent")
+}
+
+// Reap removes permanent locks whose holders stopped heartbeating and returns how many were removed
+func (c *Client) Reap(ctx context.Context) (int, error) {
+	reaped := 0
+	for _, backend := range c.backends() {
+		n, err := backend.reap(ctx)
+		reaped += n
+		if err != nil {
+			return reaped, err
+		}
+	}
+	return reaped, nil
+}
+
+func (c *Client) reap(ctx context.Context) (int, error) {
+	shards, err := c.shardKeys(ctx, "permanent")
+	if err != nil {
+		return 0, err
+	}
+	var keys []string
+	for _, shard := range shards {
+		members, err := replyStrings(c.do(ctx, "SMEMBERS", shard))
+		if err != nil {
+			return 0, err
+		}
+		keys = append(keys, members...)
+	}
+
+	reaped := 0
+	for _, key := range keys {
+		ok, err := c.runScriptBool(ctx, lua.Reap, []string{key, c.heartbeatKey(key), c.permanentKey(key)}, c.eventsChannel(), c.releasesChannel())
+		if err != nil {
+			c.logger.Error(ctx, "Failed to reap lock: %s, error: %v", key, err)
+			return reaped, err
+		}
+		if ok {
+			c.logger.Warn(ctx, "Reaped lock with lapsed heartbeat: %s", key)
+			reaped++
+		}
+	}
+
+	return reaped, nil
+}
+
+// RunReaper calls Reap every interval until ctx is done
+func (c *Client) RunReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Errors are logged by Reap, the next tick retries
+			c.Reap(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
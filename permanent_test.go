@@ -0,0 +1,114 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPermanentLock(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-permanent:"))
+	ctx := context.Background()
+
+	t.Run("no key ttl and heartbeat kept alive", func(t *testing.T) {
+		lock := client.NewLock("test-heartbeat", WithPermanent(300*time.Millisecond))
+		key := client.lockKey("test-heartbeat")
+		heartbeat := client.heartbeatKey(key)
+
+		// Acquire twice so the heartbeat must restart after the first unlock
+		for i := 0; i < 2; i++ {
+			if err := lock.Lock(ctx); err != nil {
+				t.Fatalf("Failed to acquire lock: %v", err)
+			}
+
+			ttl, err := redisClient.PTTL(ctx, key).Result()
+			if err != nil {
+				t.Fatalf("Failed to read ttl: %v", err)
+			}
+			if ttl != -1 {
+				t.Fatalf("Permanent lock should not expire, got ttl %v", ttl)
+			}
+
+			redisClient.Del(ctx, heartbeat)
+			time.Sleep(250 * time.Millisecond)
+			if exists, _ := redisClient.Exists(ctx, heartbeat).Result(); exists != 1 {
+				t.Fatalf("Heartbeat should be refreshed on acquisition %d", i+1)
+			}
+
+			if err := lock.Unlock(ctx); err != nil {
+				t.Fatalf("Failed to release lock: %v", err)
+			}
+			if exists, _ := redisClient.Exists(ctx, heartbeat).Result(); exists != 0 {
+				t.Fatal("Heartbeat should be removed on unlock")
+			}
+		}
+	})
+
+	t.Run("reap dead holder", func(t *testing.T) {
+		holder := client.NewLock("test-reap", WithPermanent(time.Hour))
+		other := client.NewLock("test-reap")
+		key := client.lockKey("test-reap")
+
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		reaped, err := client.Reap(ctx)
+		if err != nil || reaped != 0 {
+			t.Fatalf("Live holder should not be reaped, got: %d, %v", reaped, err)
+		}
+
+		// Simulate a holder that died without unlocking
+		redisClient.Del(ctx, client.heartbeatKey(key))
+
+		reaped, err = client.Reap(ctx)
+		if err != nil || reaped != 1 {
+			t.Fatalf("Dead holder should be reaped, got: %d, %v", reaped, err)
+		}
+
+		acquired, err := other.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Should acquire reaped lock, got: %v, %v", acquired, err)
+		}
+		if err := other.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+		if err := holder.Unlock(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+	})
+}
+
+func TestNoExpiry(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}))
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		valid bool
+	}{
+		{"default lease", nil, true},
+		{"lease rounding to zero", []Option{WithLeaseTime(500 * time.Microsecond)}, false},
+		{"zero lease", []Option{WithLeaseTime(0)}, false},
+		{"no expiry without heartbeat", []Option{WithNoExpiry()}, false},
+		{"no expiry with heartbeat", []Option{WithNoExpiry(), WithHeartbeat(time.Second)}, true},
+		{"permanent", []Option{WithPermanent(time.Second)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := client.NewLock("test-expiry", tt.opts...).(*lockImpl)
+			err := l.checkLease(ctx, l.leaseTime())
+			if tt.valid && err != nil {
+				t.Errorf("Expected a valid lease, got: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidLease) {
+				t.Errorf("Expected ErrInvalidLease, got: %v", err)
+			}
+		})
+	}
+}
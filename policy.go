@@ -0,0 +1,70 @@
+package arbiter
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// ErrLockNameRejected is returned when a lock name is refused by the client name policy
+var ErrLockNameRejected = errors.New("lock name rejected by policy")
+
+// namePolicy decides which lock names a client may acquire
+type namePolicy struct {
+	allow []string
+	deny  []string
+}
+
+// WithAllowedNames restricts the client to lock names matching at least one pattern.
+// Patterns use path.Match syntax, e.g. "orders:*" or "job:[0-9]*".
+// Calling it several times adds to the allow-list.
+func WithAllowedNames(patterns ...string) ClientOption {
+	return func(c *Client) {
+		c.policy.allow = append(c.policy.allow, patterns...)
+	}
+}
+
+// WithDeniedNames rejects lock names matching any pattern, even if they are allowed.
+// Patterns use path.Match syntax. Calling it several times adds to the deny-list.
+func WithDeniedNames(patterns ...string) ClientOption {
+	return func(c *Client) {
+		c.policy.deny = append(c.policy.deny, patterns...)
+	}
+}
+
+// check returns an error if name may not be acquired
+func (p *namePolicy) check(name string) error {
+	// Names containing the reserved prefix could reach the internal keys of a namespace
+	if strings.Contains(name, reservedPrefix) {
+		return ErrReservedLockName
+	}
+	if matchAny(p.deny, name) {
+		return ErrLockNameRejected
+	}
+	if len(p.allow) > 0 && !matchAny(p.allow, name) {
+		return ErrLockNameRejected
+	}
+	return nil
+}
+
+// invalid returns the patterns that path.Match cannot parse
+func (p *namePolicy) invalid() []string {
+	var bad []string
+	for _, pattern := range append(append([]string{}, p.allow...), p.deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			bad = append(bad, pattern)
+		}
+	}
+	return bad
+}
+
+// matchAny reports whether name matches one of the patterns.
+// Malformed patterns never match.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package arbiter
+
+import "testing"
+
+func TestNamePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   namePolicy
+		lock     string
+		expected error
+	}{
+		{
+			name:     "no policy",
+			lock:     "anything",
+			expected: nil,
+		},
+		{
+			name:     "reserved name",
+			lock:     reservedPrefix + "frozen",
+			expected: ErrReservedLockName,
+		},
+		{
+			name:     "allowed name",
+			policy:   namePolicy{allow: []string{"orders:*"}},
+			lock:     "orders:42",
+			expected: nil,
+		},
+		{
+			name:     "not allowed name",
+			policy:   namePolicy{allow: []string{"orders:*"}},
+			lock:     "users:42",
+			expected: ErrLockNameRejected,
+		},
+		{
+			name:     "deny wins over allow",
+			policy:   namePolicy{allow: []string{"orders:*"}, deny: []string{"orders:tmp-*"}},
+			lock:     "orders:tmp-1",
+			expected: ErrLockNameRejected,
+		},
+		{
+			name:     "malformed pattern never matches",
+			policy:   namePolicy{deny: []string{"orders:["}},
+			lock:     "orders:[",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.check(tt.lock); err != tt.expected {
+				t.Errorf("check(%q) = %v, want %v", tt.lock, err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		policy := namePolicy{allow: []string{"ok:*", "bad:["}}
+		if bad := policy.invalid(); len(bad) != 1 || bad[0] != "bad:[" {
+			t.Errorf("invalid() = %v, want [bad:[]", bad)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+)
+
+// eventQueue delivers events in the background for asynchronous sinks
+type eventQueue struct {
+	name    string
+	logger  Logger
+	deliver func(ctx context.Context, event Event) error
+
+	queue     chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// start begins delivering queued events
+func (q *eventQueue) start() {
+	q.done = make(chan struct{})
+	go q.run()
+}
+
+// push queues the event, dropping it if the queue is full
+func (q *eventQueue) push(ctx context.Context, event Event) {
+	select {
+	case q.queue <- event:
+	default:
+		q.logger.Warn(ctx, "%s queue full, dropping event: %s %s", q.name, event.Type, event.Name)
+	}
+}
+
+// close stops accepting events and waits until the queued ones are delivered
+func (q *eventQueue) close() {
+	q.closeOnce.Do(func() {
+		close(q.queue)
+		<-q.done
+	})
+}
+
+func (q *eventQueue) run() {
+	defer close(q.done)
+
+	for event := range q.queue {
+		ctx := context.Background()
+		if err := q.deliver(ctx, event); err != nil {
+			q.logger.Error(ctx, "Failed to deliver %s event: %s %s, error: %v", q.name, event.Type, event.Name, err)
+		}
+	}
+}
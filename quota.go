@@ -0,0 +1,117 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// ErrQuotaExceeded is returned when an acquisition would exceed the quota of its namespace
+var ErrQuotaExceeded = errors.New("namespace quota exceeded")
+
+// waiterTTL is how long a waiter entry survives without being renewed by its retry loop
+const waiterTTL = 5 * time.Second
+
+// NamespaceQuota limits the lock usage of a tenant namespace, zero fields are unlimited
+type NamespaceQuota struct {
+	// MaxHeld is the maximum number of locks held at once in the namespace
+	MaxHeld int
+
+	// MaxWaiters is the maximum number of Lock calls waiting at once in the namespace
+	MaxWaiters int
+
+	// MaxAcquireRate is the maximum number of acquisitions per second in the namespace
+	MaxAcquireRate int
+}
+
+// WithNamespaceQuota enforces quota on the locks of the namespace returned by Client.Namespace.
+// Quotas are counted in Redis, so every client of the namespace shares them.
+func WithNamespaceQuota(namespace string, quota NamespaceQuota) ClientOption {
+	return func(c *Client) {
+		if c.quotas == nil {
+			c.quotas = make(map[string]NamespaceQuota)
+		}
+		c.quotas[namespace] = quota
+	}
+}
+
+// withNamespace marks the client as serving namespace
+func withNamespace(namespace string) ClientOption {
+	return func(c *Client) {
+		c.namespace = namespace
+	}
+}
+
+// Namespace returns a client whose locks live in the tenant namespace ns below the key
+// prefix, sharing the configuration and Redis client of c and subject to its quota.
+func (c *Client) Namespace(ns string) *Client {
+	full := ns
+	if c.namespace != "" {
+		full = c.namespace + ":" + ns
+	}
+
+	opts := append(append([]ClientOption{}, c.opts...), WithKeyPrefix(c.prefix+ns+":"), withNamespace(full))
+	return NewClient(c.redis, opts...)
+}
+
+// quota returns the quota of the client namespace. Hash-tagged keys split the counters
+// of a namespace across slots, so no quota is enforced.
+func (c *Client) quota() NamespaceQuota {
+	if c.namespace == "" || c.hashTag {
+		return NamespaceQuota{}
+	}
+	return c.quotas[c.namespace]
+}
+
+// heldKey returns the Redis key of the sorted set of held locks, scored by lease expiry
+func (c *Client) heldKey(lockKey string) string {
+	return c.slotKey(lockKey, "held")
+}
+
+// waitersKey returns the Redis key of the sorted set of waiters, scored by expiry
+func (c *Client) waitersKey(lockKey string) string {
+	return c.slotKey(lockKey, "waiters")
+}
+
+// rateKey returns the Redis key counting the acquisitions during the second of now
+func (c *Client) rateKey(lockKey string, now time.Time) string {
+	return c.slotKey(lockKey, "rate:"+strconv.FormatInt(now.Unix(), 10))
+}
+
+// quotaError describes which limit of the namespace quota was hit
+func (c *Client) quotaError(limit string, max int) error {
+	return fmt.Errorf("%w: namespace %s allows %d %s", ErrQuotaExceeded, c.namespace, max, limit)
+}
+
+// enterWait registers waiter of the lock at lockKey in the namespace, failing once
+// MaxWaiters are waiting. Calling it again renews the registration. It is a no-op
+// without a waiter quota.
+func (c *Client) enterWait(ctx context.Context, lockKey, waiter string) error {
+	quota := c.quota()
+	if quota.MaxWaiters <= 0 {
+		return nil
+	}
+	now := time.Now()
+	ok, err := c.runScriptBool(ctx, lua.EnterWait, []string{c.waitersKey(lockKey)},
+		waiter, quota.MaxWaiters, now.UnixMilli(), now.Add(waiterTTL).UnixMilli())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.quotaError("waiters", quota.MaxWaiters)
+	}
+	return nil
+}
+
+// leaveWait removes waiter from the namespace and from every wait structure of the lock
+// at lockKey, so a cancelled waiter never holds up others until its entries expire
+func (c *Client) leaveWait(ctx context.Context, lockKey, waiter string) {
+	keys := []string{c.waitersKey(lockKey), c.queueKey(lockKey), lockKey}
+	if _, err := c.runScript(ctx, lua.LeaveWait, keys, waiter); err != nil {
+		c.logger.Warn(ctx, "Failed to remove waiter of lock: %s, error: %v", lockKey, err)
+	}
+}
@@ -0,0 +1,146 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNamespaceQuota(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	ctx := context.Background()
+
+	t.Run("namespace prefix", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-quota:"))
+		tenant := client.Namespace("tenant-a")
+
+		if key := tenant.lockKey("job"); key != "test-quota:tenant-a:job" {
+			t.Errorf("Unexpected key: %s", key)
+		}
+		if key := tenant.Namespace("team").lockKey("job"); key != "test-quota:tenant-a:team:job" {
+			t.Errorf("Unexpected nested key: %s", key)
+		}
+	})
+
+	t.Run("max held", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-quota:"),
+			WithNamespaceQuota("tenant-held", NamespaceQuota{MaxHeld: 2}))
+		tenant := client.Namespace("tenant-held")
+
+		first := tenant.NewLock("a")
+		second := tenant.NewLock("b")
+		for _, lock := range []Lock{first, second} {
+			if err := lock.Lock(ctx); err != nil {
+				t.Fatalf("Failed to acquire lock: %v", err)
+			}
+		}
+
+		// Re-entering a held lock does not count against the quota
+		if acquired, err := first.TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Holder should re-enter, got: %v, %v", acquired, err)
+		}
+
+		third := tenant.NewLock("c")
+		if _, err := third.TryLock(ctx); !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("Expected quota error, got: %v", err)
+		}
+
+		// Other namespaces are not affected
+		other := client.Namespace("tenant-other").NewLock("c")
+		if acquired, err := other.TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Other namespace should acquire, got: %v, %v", acquired, err)
+		}
+		other.Unlock(ctx)
+
+		if err := first.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+		if acquired, err := third.TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Should acquire after release, got: %v, %v", acquired, err)
+		}
+		second.Unlock(ctx)
+		third.Unlock(ctx)
+	})
+
+	t.Run("max acquire rate", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-quota:"),
+			WithNamespaceQuota("tenant-rate", NamespaceQuota{MaxAcquireRate: 1000}))
+		tenant := client.Namespace("tenant-rate")
+
+		// Fill the counter of the current and next second
+		now := time.Now()
+		redisClient.Set(ctx, tenant.rateKey(tenant.lockKey("rated"), now), 1000, time.Minute)
+		redisClient.Set(ctx, tenant.rateKey(tenant.lockKey("rated"), now.Add(time.Second)), 1000, time.Minute)
+
+		if _, err := tenant.NewLock("rated").TryLock(ctx); !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("Expected quota error, got: %v", err)
+		}
+	})
+
+	t.Run("max waiters", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-quota:"),
+			WithNamespaceQuota("tenant-wait", NamespaceQuota{MaxWaiters: 1}))
+		tenant := client.Namespace("tenant-wait")
+
+		holder := tenant.NewLock("busy")
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer holder.Unlock(ctx)
+
+		waiting := make(chan error, 1)
+		go func() {
+			waiting <- tenant.NewLock("busy", WithWaitTimeout(time.Second)).Lock(ctx)
+		}()
+
+		waitFor(t, func() bool {
+			n, _ := redisClient.ZCard(ctx, tenant.waitersKey(tenant.lockKey("busy"))).Result()
+			return n == 1
+		})
+
+		if err := tenant.NewLock("busy").Lock(ctx); !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("Expected quota error, got: %v", err)
+		}
+		if err := <-waiting; err != ErrLockTimeout {
+			t.Fatalf("Expected timeout error, got: %v", err)
+		}
+		if n, _ := redisClient.ZCard(ctx, tenant.waitersKey(tenant.lockKey("busy"))).Result(); n != 0 {
+			t.Fatalf("Waiter should be removed, got %d", n)
+		}
+	})
+
+	t.Run("cancelled waiter frees its slot", func(t *testing.T) {
+		client := NewClient(redisClient, WithKeyPrefix("test-quota:"),
+			WithNamespaceQuota("tenant-cancel", NamespaceQuota{MaxWaiters: 1}))
+		tenant := client.Namespace("tenant-cancel")
+
+		holder := tenant.NewLock("busy")
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer holder.Unlock(ctx)
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		waiting := make(chan error, 1)
+		go func() {
+			waiting <- tenant.NewLock("busy").Lock(waitCtx)
+		}()
+
+		waitFor(t, func() bool {
+			n, _ := redisClient.ZCard(ctx, tenant.waitersKey(tenant.lockKey("busy"))).Result()
+			return n == 1
+		})
+		cancel()
+		if err := <-waiting; err != context.Canceled {
+			t.Fatalf("Expected cancellation, got: %v", err)
+		}
+
+		// The slot is free right away rather than after the waiter entry expires
+		if err := tenant.NewLock("busy", WithWaitTimeout(200*time.Millisecond)).Lock(ctx); err != ErrLockTimeout {
+			t.Fatalf("Expected timeout error, got: %v", err)
+		}
+	})
+}
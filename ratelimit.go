@@ -0,0 +1,89 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// ErrBurstExceeded is returned by rate limiters asked for more tokens than their burst,
+// which can never be granted
+var ErrBurstExceeded = errors.New("tokens exceed rate limiter burst")
+
+// RateLimiter is a distributed token bucket shared by every process using its name, so
+// services enforce cluster-wide request rates instead of per-pod ones. The bucket holds
+// up to burst tokens and refills at rate tokens per second.
+type RateLimiter struct {
+	client *Client
+	name   string
+	key    string
+	rate   float64
+	burst  int
+	logger Logger
+}
+
+// NewRateLimiter creates the token bucket rate limiter name allowing rate events per
+// second with bursts of up to burst events. Every process must use the same rate and burst.
+func (c *Client) NewRateLimiter(name string, rate float64, burst int) *RateLimiter {
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	return &RateLimiter{
+		client: c,
+		name:   name,
+		key:    c.internalKey("ratelimit:" + name),
+		rate:   rate,
+		burst:  burst,
+		logger: c.logger,
+	}
+}
+
+// Allow reports whether one event may happen now and takes its token if so
+func (r *RateLimiter) Allow(ctx context.Context) (bool, error) {
+	return r.AllowN(ctx, 1)
+}
+
+// AllowN reports whether n events may happen now and takes their tokens if so.
+// It returns ErrBurstExceeded if n exceeds the burst.
+func (r *RateLimiter) AllowN(ctx context.Context, n int) (bool, error) {
+	delay, err := r.take(ctx, n)
+	return delay == 0 && err == nil, err
+}
+
+// Wait blocks until one event may happen and takes its token, or until ctx is done.
+// Waiters do not reserve tokens, so under contention they compete for each refill.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay, err := r.take(ctx, 1)
+		if err != nil || delay == 0 {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take takes n tokens and returns 0, or how long until n tokens are available
+func (r *RateLimiter) take(ctx context.Context, n int) (time.Duration, error) {
+	if err := r.client.policy.check(r.name); err != nil {
+		return 0, err
+	}
+	if n > r.burst || r.rate <= 0 {
+		return 0, ErrBurstExceeded
+	}
+
+	delay, err := replyInt(r.client.runScript(ctx, lua.TokenBucket, []string{r.key},
+		r.rate, r.burst, time.Now().UnixMilli(), n))
+	if err != nil {
+		r.logger.Error(ctx, "Failed to take tokens of rate limiter: %s, error: %v", r.name, err)
+		return 0, err
+	}
+	return time.Duration(delay) * time.Millisecond, nil
+}
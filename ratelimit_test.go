@@ -0,0 +1,53 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-ratelimit:"))
+	ctx := context.Background()
+
+	t.Run("burst is shared across instances", func(t *testing.T) {
+		first := client.NewRateLimiter("test-burst", 1, 3)
+		second := client.NewRateLimiter("test-burst", 1, 3)
+		redisClient.Del(ctx, first.key)
+
+		if ok, err := first.AllowN(ctx, 2); err != nil || !ok {
+			t.Fatalf("First two events should be allowed, got: %v, %v", ok, err)
+		}
+		if ok, err := second.Allow(ctx); err != nil || !ok {
+			t.Fatalf("Third event should be allowed, got: %v, %v", ok, err)
+		}
+		if ok, err := second.Allow(ctx); err != nil || ok {
+			t.Fatalf("Fourth event should be limited, got: %v, %v", ok, err)
+		}
+	})
+
+	t.Run("wait blocks until refilled", func(t *testing.T) {
+		limiter := client.NewRateLimiter("test-wait", 10, 1)
+		redisClient.Del(ctx, limiter.key)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			if err := limiter.Wait(ctx); err != nil {
+				t.Fatalf("Wait failed: %v", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Errorf("Three events at 10/s with burst 1 should take about 200ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("more than burst is rejected", func(t *testing.T) {
+		if _, err := client.NewRateLimiter("test-burst", 1, 3).AllowN(ctx, 4); !errors.Is(err, ErrBurstExceeded) {
+			t.Errorf("Expected ErrBurstExceeded, got: %v", err)
+		}
+	})
+}
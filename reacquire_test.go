@@ -0,0 +1,75 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAutoReacquire(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-reacquire:"))
+	ctx := context.Background()
+
+	t.Run("lapsed lease is taken again", func(t *testing.T) {
+		lock := client.NewLock("test-lapsed", WithAutoReacquire(time.Second))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer lock.Unlock(ctx)
+
+		// Simulate the lease lapsing mid-operation
+		redisClient.Del(ctx, client.lockKey("test-lapsed"))
+
+		if err := lock.Refresh(ctx); err != nil {
+			t.Fatalf("Expected transparent re-acquisition, got: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-lapsed"); !locked {
+			t.Fatal("Lock should be held again")
+		}
+	})
+
+	t.Run("other owner in between is detected", func(t *testing.T) {
+		lock := client.NewLock("test-stolen", WithAutoReacquire(time.Second))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer lock.Unlock(ctx)
+
+		redisClient.Del(ctx, client.lockKey("test-stolen"))
+		other := client.NewLock("test-stolen")
+		if err := other.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		if err := other.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+
+		if err := lock.Refresh(ctx); err != ErrLockReacquired {
+			t.Fatalf("Expected reacquired error, got: %v", err)
+		}
+		if err := lock.Refresh(ctx); err != nil {
+			t.Fatalf("Failed to refresh re-acquired lock: %v", err)
+		}
+	})
+
+	t.Run("held by another owner", func(t *testing.T) {
+		lock := client.NewLock("test-taken", WithAutoReacquire(time.Second))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		redisClient.Del(ctx, client.lockKey("test-taken"))
+		other := client.NewLock("test-taken")
+		if err := other.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer other.Unlock(ctx)
+
+		if err := lock.Refresh(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+	})
+}
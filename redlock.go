@@ -0,0 +1,397 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNoQuorum is returned by RedLock operations that failed on too many instances to reach a quorum
+var ErrNoQuorum = errors.New("redlock quorum not reached")
+
+const (
+	// redLockDriftFactor and redLockDriftMin estimate the clock drift between instances
+	redLockDriftFactor = 0.01
+	redLockDriftMin    = 2 * time.Millisecond
+
+	// redLockInstanceTimeout bounds each instance call so an unreachable instance does
+	// not eat up the validity of the lock
+	redLockInstanceTimeout = 100 * time.Millisecond
+)
+
+// WithRedLockInstances adds independent Redis instances for NewRedLock. Together with
+// the Redis of the client they form the set of instances a RedLock needs a quorum of.
+func WithRedLockInstances(instances ...redis.UniversalClient) ClientOption {
+	return func(c *Client) {
+		c.redLock = append(c.redLock, instances...)
+	}
+}
+
+// redLockInstance builds the client of one RedLock instance, which acquires in Redis only
+func redLockInstance() ClientOption {
+	return func(c *Client) {
+		c.routes = nil
+		c.redLock = nil
+		c.handoff = nil
+	}
+}
+
+// initRedLock creates a client per RedLock instance sharing the configuration of c
+func (c *Client) initRedLock() {
+	if len(c.redLock) == 0 {
+		return
+	}
+
+	opts := append(append([]ClientOption{}, c.opts...), redLockInstance())
+	c.redLockClients = []*Client{NewClient(c.redis, opts...)}
+
+	// The other instances run on their own go-redis clients, not on the executor of c
+	opts = append(opts, func(c *Client) { c.executor = nil })
+	for _, rc := range c.redLock {
+		c.redLockClients = append(c.redLockClients, NewClient(rc, opts...))
+	}
+}
+
+// RedLock is a lock held on a quorum of independent Redis instances, following the
+// Redlock algorithm. It stays safe while a minority of the instances fails, but is
+// only held for its validity time unless refreshed; it has no watchdog.
+type RedLock struct {
+	name      string
+	instances []Lock
+	quorum    int
+	options   *LockOptions
+	logger    Logger
+
+	mu         sync.Mutex
+	validUntil time.Time
+
+	// used is set once the owner value shared by the instances reached a quorum, the
+	// next acquisition after the lock was released or lapsed draws a new value
+	used bool
+}
+
+// NewRedLock creates a lock acquired on a quorum (N/2+1) of the client Redis and the
+// instances added with WithRedLockInstances. Without such instances it degrades to a
+// lock on the client Redis alone.
+func (c *Client) NewRedLock(name string, opts ...Option) *RedLock {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// Leases are kept by RedLock as a whole, never by a single instance
+	instanceOptions := *options
+	instanceOptions.EnableWatchDog = false
+	instanceOptions.Permanent = false
+	instanceOptions.AutoReacquire = false
+	instanceOptions.AutoLeaseMax = 0
+
+	clients := c.redLockClients
+	if len(clients) == 0 {
+		clients = []*Client{c}
+	}
+
+	c.cardinality.track(context.Background(), c, name)
+	// The instances share one owner value pinned like WithOwnerToken, so they never draw
+	// values of their own and agree on the owner; RedLock renews it per acquisition
+	if instanceOptions.Owner == "" {
+		instanceOptions.Owner = generateValue()
+	}
+	l := &RedLock{name: name, quorum: len(clients)/2 + 1, options: options, logger: c.logger}
+	for _, client := range clients {
+		o := instanceOptions
+		l.instances = append(l.instances, newLock(client, name, &o))
+	}
+	return l
+}
+
+// renewOwner draws a new owner value for the instances if the current one reached a
+// quorum before and the lock is no longer valid. Values set WithOwnerToken are kept,
+// l.mu must be held.
+func (l *RedLock) renewOwner() {
+	if !l.used || time.Now().Before(l.validUntil) || l.options.Owner != "" {
+		return
+	}
+	value := generateValue()
+	for _, instance := range l.instances {
+		instance.(*lockImpl).pinOwner(value)
+	}
+	l.used = false
+}
+
+// Lock acquires the lock on a quorum of instances, retrying until ctx is done or
+// the wait timeout passes
+func (l *RedLock) Lock(ctx context.Context) error {
+	deadline, charge := waitDeadline(ctx, l.options.WaitTimeout)
+	defer charge()
+	for {
+		acquired, err := l.TryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		if waitExpired(deadline) {
+			l.logger.Warn(ctx, "Timeout waiting for redlock: %s", l.name)
+			return ErrLockTimeout
+		}
+
+		// Random delays keep competing clients from splitting the vote again
+		delay := 100*time.Millisecond + time.Duration(rand.Int63n(int64(50*time.Millisecond)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// TryLock attempts once to acquire the lock on a quorum of instances. The lock is
+// acquired only if the lease minus the time the attempt took and the clock drift
+// leaves a positive validity time, otherwise every instance is released again.
+// Errors of a minority of instances are tolerated.
+func (l *RedLock) TryLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.renewOwner()
+	start := time.Now()
+	acquired, errs := l.each(ctx, func(ctx context.Context, instance Lock) (bool, error) {
+		return instance.TryLock(ctx)
+	})
+
+	validity := l.validity(start)
+	if acquired >= l.quorum && validity > 0 {
+		l.validUntil = start.Add(validity)
+		l.used = true
+		l.logger.Info(ctx, "Acquired redlock: %s on %d of %d instances", l.name, acquired, len(l.instances))
+		return true, nil
+	}
+
+	// Instances that timed out may still have granted the lock
+	l.release(context.WithoutCancel(ctx))
+	if len(errs) > len(l.instances)-l.quorum {
+		return false, l.quorumError(errs)
+	}
+	return false, nil
+}
+
+// Unlock releases the lock on every instance. It returns ErrLockNotHeld if no instance
+// held the lock, and an error only if a majority of instances failed.
+func (l *RedLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.validUntil = time.Time{}
+	released, errs := l.release(ctx)
+	if len(errs) >= l.quorum {
+		return l.quorumError(errs)
+	}
+	if released == 0 && len(errs) == 0 {
+		return ErrLockNotHeld
+	}
+
+	l.logger.Info(ctx, "Released redlock: %s", l.name)
+	return nil
+}
+
+// Refresh extends the lease on every instance. The lock stays held if a quorum
+// was extended within a positive validity time, otherwise it returns ErrLockNotHeld,
+// or ErrNoQuorum if instance errors prevented the quorum.
+func (l *RedLock) Refresh(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := time.Now()
+	refreshed, errs := l.each(ctx, func(ctx context.Context, instance Lock) (bool, error) {
+		err := instance.Refresh(ctx)
+		if err == ErrLockNotHeld {
+			return false, nil
+		}
+		return err == nil, err
+	})
+
+	validity := l.validity(start)
+	if refreshed >= l.quorum && validity > 0 {
+		l.validUntil = start.Add(validity)
+		return nil
+	}
+
+	l.validUntil = time.Time{}
+	if len(errs) > len(l.instances)-l.quorum {
+		return l.quorumError(errs)
+	}
+	return ErrLockNotHeld
+}
+
+// Validity returns how much longer the lock is safely held, 0 if it is not held
+func (l *RedLock) Validity() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return max(time.Until(l.validUntil), 0)
+}
+
+// validity returns the validity time of a quorum reached in an attempt started at start
+func (l *RedLock) validity(start time.Time) time.Duration {
+	lease := l.options.LeaseTime
+	drift := time.Duration(float64(lease)*redLockDriftFactor) + redLockDriftMin
+	return lease - time.Since(start) - drift
+}
+
+// release unlocks every instance and returns how many held the lock
+func (l *RedLock) release(ctx context.Context) (int, []error) {
+	return l.each(ctx, func(ctx context.Context, instance Lock) (bool, error) {
+		err := instance.Unlock(ctx)
+		if err == ErrLockNotHeld {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+// each calls fn on every instance concurrently, bounded by the instance timeout, and
+// returns how many calls succeeded along with the errors of the failed ones
+func (l *RedLock) each(ctx context.Context, fn func(ctx context.Context, instance Lock) (bool, error)) (int, []error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	succeeded := 0
+	var errs []error
+
+	for _, instance := range l.instances {
+		wg.Add(1)
+		go func(instance Lock) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, redLockInstanceTimeout)
+			defer cancel()
+			ok, err := fn(ctx, instance)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				succeeded++
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(instance)
+	}
+	wg.Wait()
+	return succeeded, errs
+}
+
+// quorumError reports the instance errors that prevented the quorum
+func (l *RedLock) quorumError(errs []error) error {
+	return fmt.Errorf("%w: %d of %d instances failed: %w", ErrNoQuorum, len(errs), len(l.instances), errors.Join(errs...))
+}
+
+// QuorumInfo is the state of a lock as read from every RedLock instance
+type QuorumInfo struct {
+	Name string
+
+	// Instances holds the state read from each instance, in the order of WithRedLockInstances
+	// after the client Redis
+	Instances []InstanceInfo
+
+	// Quorum is the number of instances a RedLock must hold
+	Quorum int
+
+	// Owner is the owner holding a quorum of instances, empty if no owner does
+	Owner string
+
+	// Agreed reports whether every instance answered and reported the same owner,
+	// or every instance reported the lock free
+	Agreed bool
+}
+
+// InstanceInfo is the state of a lock on one RedLock instance
+type InstanceInfo struct {
+	// Addr is the address and database of the instance, e.g. "localhost:6379/0"
+	Addr string
+
+	// Info is the state of the lock, valid unless Err is set
+	Info LockInfo
+
+	// Err is the error reading the instance
+	Err error
+}
+
+// Held reports whether an owner holds the lock on a quorum of instances
+func (q QuorumInfo) Held() bool {
+	return q.Owner != ""
+}
+
+// InspectQuorum reads the state of a lock from every RedLock instance concurrently
+// and reports whether they agree, to diagnose suspected split-brain. Instances that
+// disagree, e.g. because an instance restarted without persistence, are listed with
+// their own owner. Without instances added by WithRedLockInstances only the client
+// Redis is read.
+func (c *Client) InspectQuorum(ctx context.Context, name string) (QuorumInfo, error) {
+	clients := c.redLockClients
+	if len(clients) == 0 {
+		clients = []*Client{c}
+	}
+
+	q := QuorumInfo{Name: name, Instances: make([]InstanceInfo, len(clients)), Quorum: len(clients)/2 + 1}
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client *Client) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, redLockInstanceTimeout)
+			defer cancel()
+			instance := InstanceInfo{Addr: redisAddr(client.redis)}
+			if client.redis == nil {
+				// A client running on an executor alone only knows its type
+				instance.Addr = fmt.Sprintf("%T", client.executor)
+			}
+			infos, err := client.inspectKeys(ctx, []string{name}, []string{client.lockKey(name)})
+			if err != nil {
+				instance.Err = err
+			} else {
+				instance.Info = infos[0]
+			}
+			q.Instances[i] = instance
+		}(i, client)
+	}
+	wg.Wait()
+
+	votes := make(map[string]int)
+	var errs []error
+	for _, instance := range q.Instances {
+		if errors.Is(instance.Err, ErrStoreUnsupported) {
+			// A client without Redis has no state to vote on
+			return q, instance.Err
+		}
+		if instance.Err != nil {
+			errs = append(errs, instance.Err)
+			continue
+		}
+		votes[instance.Info.Owner]++
+	}
+	q.Agreed = len(errs) == 0 && len(votes) == 1
+	for owner, count := range votes {
+		if owner != "" && count >= q.Quorum {
+			q.Owner = owner
+		}
+	}
+
+	// Without a quorum of answers the state of the lock cannot be told
+	if len(errs) > len(clients)-q.Quorum {
+		c.logger.Error(ctx, "Failed to inspect redlock on a quorum of instances: %s", name)
+		return q, fmt.Errorf("%w: %d of %d instances failed: %w", ErrNoQuorum, len(errs), len(clients), errors.Join(errs...))
+	}
+	if !q.Agreed {
+		c.logger.Warn(ctx, "Redlock instances disagree: %s, owners: %v", name, votes)
+	}
+	return q, nil
+}
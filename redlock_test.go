@@ -0,0 +1,222 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedLock(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	// Logical DBs stand in for independent instances
+	second := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 2})
+	defer second.Close()
+	third := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 3})
+	defer third.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-redlock:"), WithRedLockInstances(second, third))
+	ctx := context.Background()
+
+	// holdOn takes the named lock on a single instance as another owner
+	holdOn := func(t *testing.T, rc *redis.Client, name string) Lock {
+		lock := NewClient(rc, WithKeyPrefix("test-redlock:")).NewLock(name)
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock on instance: %v", err)
+		}
+		return lock
+	}
+
+	t.Run("acquires every instance", func(t *testing.T) {
+		lock := client.NewRedLock("test-all", WithLeaseTime(time.Second))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire redlock: %v", err)
+		}
+
+		for _, rc := range []*redis.Client{redisClient, second, third} {
+			if exists, _ := rc.Exists(ctx, client.lockKey("test-all")).Result(); exists != 1 {
+				t.Fatal("Lock should be stored on every instance")
+			}
+		}
+		if validity := lock.Validity(); validity <= 900*time.Millisecond || validity > time.Second {
+			t.Errorf("Unexpected validity: %v", validity)
+		}
+
+		if err := lock.Refresh(ctx); err != nil {
+			t.Fatalf("Failed to refresh redlock: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release redlock: %v", err)
+		}
+		if lock.Validity() != 0 {
+			t.Error("Released redlock should have no validity")
+		}
+		if err := lock.Unlock(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+	})
+
+	t.Run("minority held elsewhere", func(t *testing.T) {
+		other := holdOn(t, third, "test-minority")
+		defer other.Unlock(ctx)
+
+		lock := client.NewRedLock("test-minority")
+		acquired, err := lock.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Quorum should be reached, got: %v, %v", acquired, err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release redlock: %v", err)
+		}
+	})
+
+	t.Run("majority held elsewhere", func(t *testing.T) {
+		for _, rc := range []*redis.Client{second, third} {
+			other := holdOn(t, rc, "test-majority")
+			defer other.Unlock(ctx)
+		}
+
+		lock := client.NewRedLock("test-majority")
+		acquired, err := lock.TryLock(ctx)
+		if err != nil || acquired {
+			t.Fatalf("Quorum should not be reached, got: %v, %v", acquired, err)
+		}
+		if exists, _ := redisClient.Exists(ctx, client.lockKey("test-majority")).Result(); exists != 0 {
+			t.Fatal("Failed attempt should release the instances it acquired")
+		}
+		if err := client.NewRedLock("test-majority", WithWaitTimeout(200*time.Millisecond)).Lock(ctx); err != ErrLockTimeout {
+			t.Fatalf("Expected timeout error, got: %v", err)
+		}
+	})
+
+	t.Run("refresh detects lost quorum", func(t *testing.T) {
+		lock := client.NewRedLock("test-lost")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire redlock: %v", err)
+		}
+		defer lock.Unlock(ctx)
+
+		for _, rc := range []*redis.Client{second, third} {
+			rc.Del(ctx, client.lockKey("test-lost"))
+		}
+		if err := lock.Refresh(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+	})
+
+	t.Run("tolerates failing minority", func(t *testing.T) {
+		down := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1})
+		defer down.Close()
+
+		client := NewClient(redisClient, WithKeyPrefix("test-redlock:"), WithRedLockInstances(second, down))
+		lock := client.NewRedLock("test-down")
+		acquired, err := lock.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Quorum should be reached despite a failing instance, got: %v, %v", acquired, err)
+		}
+		if err := lock.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh should tolerate a failing instance: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Unlock should tolerate a failing instance: %v", err)
+		}
+	})
+
+	t.Run("failing majority", func(t *testing.T) {
+		down := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1})
+		defer down.Close()
+
+		client := NewClient(redisClient, WithKeyPrefix("test-redlock:"), WithRedLockInstances(down, down))
+		_, err := client.NewRedLock("test-down").TryLock(ctx)
+		if !errors.Is(err, ErrNoQuorum) {
+			t.Fatalf("Expected quorum error, got: %v", err)
+		}
+	})
+
+	t.Run("inspect quorum", func(t *testing.T) {
+		lock := client.NewRedLock("test-inspect")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire redlock: %v", err)
+		}
+		defer lock.Unlock(ctx)
+
+		q, err := client.InspectQuorum(ctx, "test-inspect")
+		if err != nil {
+			t.Fatalf("Failed to inspect quorum: %v", err)
+		}
+		if !q.Agreed || !q.Held() || q.Quorum != 2 || len(q.Instances) != 3 {
+			t.Fatalf("Instances should agree on the holder, got: %+v", q)
+		}
+		if q.Instances[2].Addr != "localhost:6379/3" {
+			t.Errorf("Unexpected instance address: %s", q.Instances[2].Addr)
+		}
+
+		// An instance losing the lock, e.g. restarted without persistence, disagrees
+		third.Del(ctx, client.lockKey("test-inspect"))
+		other := holdOn(t, third, "test-inspect")
+		defer other.Unlock(ctx)
+		q, err = client.InspectQuorum(ctx, "test-inspect")
+		if err != nil {
+			t.Fatalf("Failed to inspect quorum: %v", err)
+		}
+		if q.Agreed || q.Owner != q.Instances[0].Info.Owner || q.Instances[2].Info.Owner == q.Owner {
+			t.Errorf("Disagreement should be reported, got: %+v", q)
+		}
+	})
+
+	t.Run("inspect quorum without answers", func(t *testing.T) {
+		down := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1})
+		defer down.Close()
+
+		client := NewClient(redisClient, WithKeyPrefix("test-redlock:"), WithRedLockInstances(down, down))
+		q, err := client.InspectQuorum(ctx, "test-inspect")
+		if !errors.Is(err, ErrNoQuorum) || q.Agreed || q.Instances[1].Err == nil {
+			t.Fatalf("Expected quorum error, got: %+v, %v", q, err)
+		}
+	})
+}
+
+func TestRedLockOwnerRenewed(t *testing.T) {
+	// The instances share one store, where owners agreeing on the value re-enter
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()), WithRedLockInstances(nil, nil))
+	ctx := context.Background()
+
+	owners := func(l *RedLock) []string {
+		var values []string
+		for _, instance := range l.instances {
+			values = append(values, instance.(*lockImpl).value)
+		}
+		return values
+	}
+	agreed := func(values []string) bool {
+		for _, value := range values {
+			if value != values[0] {
+				return false
+			}
+		}
+		return true
+	}
+
+	lock := client.NewRedLock("test-redlock-owner", WithLeaseTime(time.Second))
+	var previous string
+	for i := 0; i < 2; i++ {
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire redlock: %v", err)
+		}
+		values := owners(lock)
+		if len(values) != 3 || !agreed(values) {
+			t.Fatalf("Expected the instances to agree on the owner, got: %v", values)
+		}
+		if values[0] == previous {
+			t.Errorf("Expected a new owner for acquisition %d", i+1)
+		}
+		previous = values[0]
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release redlock: %v", err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package arbiter
+
+import "context"
+
+// heldLockKey keys the lock holding a lock key in a context
+type heldLockKey struct {
+	key string
+}
+
+// ContextWithLock returns a context recording that lock is held by the request. Locks
+// of the same name created elsewhere, e.g. by nested functions, re-enter it when they
+// call Lock, TryLock or TryLockFor with the context instead of waiting for themselves.
+// Their Unlock only undoes their own re-entries, the lock stays held until lock is
+// released. Do records its lock in the context of its callback.
+//
+// Only locks created by NewLock, NewMultiLock and LockScope.NewLock are recorded, other
+// implementations of Lock leave ctx unchanged. Read-write locks, RedLocks and
+// semaphores do not re-enter through the context.
+func ContextWithLock(ctx context.Context, lock Lock) context.Context {
+	switch l := lock.(type) {
+	case *lockImpl:
+		return context.WithValue(ctx, heldLockKey{l.key}, l)
+	case *scopedLock:
+		return ContextWithLock(ctx, l.lock)
+	case *multiLock:
+		for _, member := range l.locks {
+			ctx = ContextWithLock(ctx, member)
+		}
+	}
+	return ctx
+}
+
+// reenter re-enters the lock of the same key recorded in ctx if it is held and counts
+// the re-entry. It reports false if the lock has to be acquired itself.
+func (l *lockImpl) reenter(ctx context.Context) bool {
+	outer, ok := ctx.Value(heldLockKey{l.key}).(*lockImpl)
+	if !ok || outer == l {
+		return false
+	}
+	// Read before taking l.mu, locks re-entering each other must not lock both mutexes
+	held := outer.isHeld()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.outer != outer {
+		if l.held.Load() {
+			return false
+		}
+		l.outer, l.reentries = outer, 0
+	}
+	if !held {
+		l.outer, l.reentries = nil, 0
+		return false
+	}
+	l.reentries++
+	l.logger.Debug(ctx, "Re-entered lock held by the context: %s, count: %d", l.key, l.reentries)
+	return true
+}
+
+// reentered returns the lock l re-entered, nil if l holds the lock itself or not at all
+func (l *lockImpl) reentered() *lockImpl {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.outer
+}
+
+// leave undoes one re-entry, l.mu must be held. It reports false if l did not re-enter.
+func (l *lockImpl) leave() bool {
+	if l.outer == nil {
+		return false
+	}
+	if l.reentries--; l.reentries == 0 {
+		l.outer = nil
+	}
+	return true
+}
+
+func (l *lockImpl) isHeld() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.held.Load()
+}
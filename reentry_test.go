@@ -0,0 +1,108 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestContextWithLock(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	outer := client.NewLock("test-reentry")
+	if err := outer.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	held := ContextWithLock(ctx, outer)
+
+	inner := client.NewLock("test-reentry", WithWaitTimeout(50*time.Millisecond))
+	if err := inner.Lock(ctx); err != ErrLockTimeout {
+		t.Fatalf("Expected a lock without the context to wait, got: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := inner.Lock(held); err != nil {
+			t.Fatalf("Expected to re-enter the held lock, got: %v", err)
+		}
+	}
+	if acquired, err := inner.TryLock(held); err != nil || !acquired {
+		t.Fatalf("Expected TryLock to re-enter, got: %v, %v", acquired, err)
+	}
+	if fence := inner.Fence(); fence == 0 || fence != outer.Fence() {
+		t.Errorf("Expected the fence of the outer lock %d, got %d", outer.Fence(), fence)
+	}
+
+	// Every re-entry is undone by one Unlock, the outer lock stays held
+	for i := 0; i < 3; i++ {
+		if err := inner.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to leave re-entered lock: %v", err)
+		}
+	}
+	if outer.Fence() == 0 {
+		t.Fatal("Expected the outer lock to stay held")
+	}
+	if err := inner.Unlock(ctx); err != ErrLockNotHeld {
+		t.Errorf("Expected ErrLockNotHeld after the last re-entry, got: %v", err)
+	}
+
+	// Once the outer lock is released the lock is acquired on its own
+	if err := outer.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	if err := inner.Lock(held); err != nil || inner.Fence() == 0 {
+		t.Fatalf("Expected to acquire the released lock, got: %v", err)
+	}
+	if err := inner.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+}
+
+func TestDoReentry(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	nested := false
+	err := client.NewLock("test-do-reentry").Do(ctx, func(ctx context.Context, checkpoint Checkpoint) error {
+		inner := client.NewLock("test-do-reentry", WithWaitTimeout(50*time.Millisecond))
+		return inner.Do(ctx, func(ctx context.Context, checkpoint Checkpoint) error {
+			nested = true
+			return checkpoint(ctx)
+		})
+	})
+	if err != nil || !nested {
+		t.Fatalf("Expected the nested Do to re-enter, got: %v, ran: %v", err, nested)
+	}
+	if locked, _ := client.NewLock("test-do-reentry").TryLock(ctx); !locked {
+		t.Error("Expected the lock to be released after Do")
+	}
+}
+
+func TestContextWithLockCrossed(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	// Two values of one lock carried in each other's context must not deadlock
+	a, b := client.NewLock("test-crossed"), client.NewLock("test-crossed")
+	if err := a.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	withA, withB := ContextWithLock(ctx, a), ContextWithLock(ctx, b)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			a.(*lockImpl).reenter(withB)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if !b.(*lockImpl).reenter(withA) {
+			t.Fatal("Expected to re-enter the held lock")
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Crossed re-entries deadlocked")
+	}
+}
@@ -0,0 +1,93 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// replicatingStore reports a fixed number of replicas acknowledging every acquisition
+type replicatingStore struct {
+	*memoryStore
+	replicas int
+	asked    int
+}
+
+func (s *replicatingStore) TryAcquireReplicated(ctx context.Context, req LeaseRequest, replicas int, timeout time.Duration) (AcquireResult, error) {
+	s.asked = replicas
+	res, err := s.memoryStore.TryAcquire(ctx, req)
+	res.Replicated = s.replicas
+	return res, err
+}
+
+func TestReplicationWait(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer redisClient.Close()
+	ctx := context.Background()
+
+	t.Run("enough replicas", func(t *testing.T) {
+		store := &replicatingStore{memoryStore: newMemoryStore(), replicas: 2}
+		client := NewClient(redisClient, WithLogger(&NoopLogger{}), WithoutReplicaCheck(), WithStore(store))
+
+		lock := client.NewLock("test-replicated", WithReplicationWait(2, 50*time.Millisecond))
+		if acquired, err := lock.TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+		}
+		if store.asked != 2 {
+			t.Errorf("Expected store to wait for 2 replicas, got: %d", store.asked)
+		}
+	})
+
+	t.Run("too few replicas", func(t *testing.T) {
+		store := &replicatingStore{memoryStore: newMemoryStore(), replicas: 1}
+		client := NewClient(redisClient, WithLogger(&NoopLogger{}), WithoutReplicaCheck(), WithStore(store))
+
+		_, err := client.NewLock("test-replicated", WithReplicationWait(2, 50*time.Millisecond)).TryLock(ctx)
+		if !errors.Is(err, ErrNotReplicated) || Code(err) != CodeBackendUnavailable {
+			t.Fatalf("Expected ErrNotReplicated, got: %v", err)
+		}
+		if _, held := store.owners[client.lockKey("test-replicated")]; held {
+			t.Error("Unreplicated lock should be released")
+		}
+	})
+
+	t.Run("store without replication", func(t *testing.T) {
+		client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+
+		_, err := client.NewLock("test-replicated", WithReplicationWait(2, 50*time.Millisecond)).TryLock(ctx)
+		if !errors.Is(err, ErrStoreUnsupported) {
+			t.Fatalf("Expected ErrStoreUnsupported, got: %v", err)
+		}
+	})
+
+	t.Run("ring", func(t *testing.T) {
+		ring := redis.NewRing(&redis.RingOptions{Addrs: map[string]string{"shard": "127.0.0.1:1"}, MaxRetries: -1})
+		defer ring.Close()
+		client := NewClient(ring, WithLogger(&NoopLogger{}), WithoutReplicaCheck())
+
+		_, err := client.NewLock("test-replicated", WithReplicationWait(2, 50*time.Millisecond)).TryLock(ctx)
+		if !errors.Is(err, ErrStoreUnsupported) {
+			t.Fatalf("Expected ErrStoreUnsupported, got: %v", err)
+		}
+	})
+}
+
+func TestReplicationWaitRedis(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-replication:"))
+	ctx := context.Background()
+
+	// A standalone server has no replica to acknowledge the lock
+	lock := client.NewLock("test-wait", WithReplicationWait(1, 50*time.Millisecond))
+	if _, err := lock.TryLock(ctx); !errors.Is(err, ErrNotReplicated) {
+		t.Fatalf("Expected ErrNotReplicated, got: %v", err)
+	}
+	if locked, err := client.IsLocked(ctx, "test-wait"); err != nil || locked {
+		t.Errorf("Expected lock to be released, got: %v, %v", locked, err)
+	}
+}
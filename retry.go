@@ -0,0 +1,161 @@
+package arbiter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// defaultAttemptTimeout is how long an acquisition attempt may wait for Redis by
+// default. It does not follow the retry interval, which only sets how often waiting
+// calls poll.
+const defaultAttemptTimeout = time.Second
+
+// attemptRetries is how many coordinated backoff delays an attempt may take
+const attemptRetries = 10
+
+// RetryAfter recommends how long to wait before retrying to acquire the named lock,
+// for callers that requeue work instead of blocking in Lock. The hint is the remaining
+// lease of the holder plus, for every Lock call already waiting, about as long again.
+// It returns 0 if the lock is free.
+func (c *Client) RetryAfter(ctx context.Context, name string) (time.Duration, error) {
+	c = c.route(name)
+	key := c.lockKey(name)
+	now := time.Now()
+
+	replies, err := replyInts(c.doMulti(ctx,
+		[]interface{}{"PTTL", key},
+		[]interface{}{"PTTL", c.heartbeatKey(key)},
+		[]interface{}{"ZCOUNT", c.queueKey(key), strconv.FormatInt(now.UnixMilli(), 10), "+inf"}))
+	if err != nil {
+		return 0, err
+	}
+
+	// PTTL reports -2 for missing keys and -1 for keys without expiry
+	remaining, waiters := replies[0], replies[2]
+	if remaining == -1 {
+		// Permanent locks live as long as their heartbeat
+		remaining = replies[1]
+	}
+	if remaining < 0 {
+		return 0, nil
+	}
+
+	return time.Duration(remaining*(1+waiters)) * time.Millisecond, nil
+}
+
+// queueKey returns the Redis key of the sorted set of Lock calls waiting for a lock
+func (c *Client) queueKey(lockKey string) string {
+	return c.internalKey("queue:" + strings.TrimPrefix(lockKey, c.prefix))
+}
+
+// enterQueue registers a waiting Lock call for RetryAfter. The entry expires unless renewed.
+func (l *lockImpl) enterQueue(ctx context.Context) {
+	key := l.client.queueKey(l.key)
+	now := time.Now()
+
+	_, err := l.client.doMulti(ctx,
+		[]interface{}{"ZADD", key, now.Add(waiterTTL).UnixMilli(), l.value},
+		[]interface{}{"ZREMRANGEBYSCORE", key, "-inf", strconv.FormatInt(now.UnixMilli(), 10)},
+		[]interface{}{"PEXPIRE", key, waiterTTL.Milliseconds()})
+	if err != nil {
+		l.logger.Warn(ctx, "Failed to register waiter of lock: %s, error: %v", l.key, err)
+	}
+}
+
+// backoffKey returns the Redis key of the retry slot hint of a lock
+func (c *Client) backoffKey(lockKey string) string {
+	return c.internalKey("backoff:" + strings.TrimPrefix(lockKey, c.prefix))
+}
+
+// retryDelay returns how long a waiting Lock call sleeps before its retry numbered
+// retry, claimed from the shared retry slots under coordinated backoff
+func (l *lockImpl) retryDelay(ctx context.Context, retry int) time.Duration {
+	if l.options.BackoffSpacing <= 0 {
+		return l.options.nextDelay(retry)
+	}
+	if l.client.executor == nil {
+		return l.options.BackoffSpacing
+	}
+
+	delay, err := replyInt(l.client.runScript(ctx, lua.NextAttempt, []string{l.client.backoffKey(l.key)}, time.Now().UnixMilli(),
+		l.options.BackoffSpacing.Milliseconds(), max(l.options.BackoffMax, l.options.BackoffSpacing).Milliseconds()))
+	if err != nil {
+		l.logger.Warn(ctx, "Failed to claim retry slot of lock: %s, error: %v", l.key, err)
+		return l.options.BackoffSpacing
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// attemptTimeout returns how long a single acquisition attempt may wait for Redis
+func (l *lockImpl) attemptTimeout(lease time.Duration) time.Duration {
+	if l.options.AttemptTimeout > 0 {
+		return l.options.AttemptTimeout
+	}
+
+	timeout := defaultAttemptTimeout
+	if l.options.BackoffSpacing > 0 {
+		timeout = max(timeout, attemptRetries*max(l.options.BackoffMax, l.options.BackoffSpacing))
+	}
+	if lease > 0 {
+		// A reply arriving after the lease lapsed acquired nothing worth waiting for
+		timeout = min(timeout, lease)
+	}
+	if l.options.ReplicationReplicas > 0 {
+		timeout += l.options.ReplicationTimeout
+	}
+	return timeout
+}
+
+// attempt runs one acquisition against the store, abandoning the round trip when ctx
+// is done or the attempt timed out. go-redis only gives up on a hung connection after
+// its read timeout, so the call runs on its own goroutine that exits once the store
+// replies or the connection fails. A late reply may still acquire the lock: a retry of
+// the same lock re-enters it, otherwise it lapses with its lease.
+func (l *lockImpl) attempt(ctx context.Context, req LeaseRequest) (AcquireResult, error) {
+	type reply struct {
+		res AcquireResult
+		err error
+	}
+	done := make(chan reply, 1)
+	go func() {
+		res, err := l.tryAcquire(ctx, req)
+		done <- reply{res, err}
+	}()
+
+	timeout := l.attemptTimeout(req.Lease)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		return AcquireResult{}, ctx.Err()
+	case <-timer.C:
+		l.logger.Warn(ctx, "Abandoned acquisition attempt after %v: %s", timeout, l.key)
+		return AcquireResult{}, ErrAttemptTimeout
+	}
+}
+
+// tryAcquire runs TryAcquire on the store, or TryAcquireReplicated with
+// WithReplicationWait, which fails with ErrStoreUnsupported on other stores
+func (l *lockImpl) tryAcquire(ctx context.Context, req LeaseRequest) (AcquireResult, error) {
+	replicas := l.options.ReplicationReplicas
+	if replicas <= 0 {
+		return l.store.TryAcquire(ctx, req)
+	}
+	store, ok := l.store.(ReplicatedStore)
+	if !ok {
+		return AcquireResult{}, ErrStoreUnsupported
+	}
+
+	timeout := l.options.ReplicationTimeout
+	if timeout <= 0 {
+		timeout = l.attemptTimeout(req.Lease)
+	}
+	return store.TryAcquireReplicated(ctx, req, replicas, timeout)
+}
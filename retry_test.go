@@ -0,0 +1,193 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRetryAfter(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-retry-after:"))
+	ctx := context.Background()
+
+	if hint, err := client.RetryAfter(ctx, "test-free"); err != nil || hint != 0 {
+		t.Fatalf("Expected no hint for a free lock, got: %v, %v", hint, err)
+	}
+
+	holder := client.NewLock("test-held", WithLeaseTime(10*time.Second))
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer holder.Unlock(ctx)
+
+	hint, err := client.RetryAfter(ctx, "test-held")
+	if err != nil {
+		t.Fatalf("Failed to get hint: %v", err)
+	}
+	if hint <= 9*time.Second || hint > 10*time.Second {
+		t.Fatalf("Expected hint near the remaining lease, got: %v", hint)
+	}
+
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	waiting := make(chan error, 1)
+	go func() {
+		waiting <- client.NewLock("test-held").Lock(waitCtx)
+	}()
+
+	waitFor(t, func() bool {
+		hint, _ := client.RetryAfter(ctx, "test-held")
+		return hint > 18*time.Second
+	})
+
+	// The waiter leaves the queue when it gives up
+	cancel()
+	<-waiting
+	if hint, _ := client.RetryAfter(ctx, "test-held"); hint > 10*time.Second {
+		t.Fatalf("Cancelled waiter should leave the queue, got: %v", hint)
+	}
+}
+
+func TestCoordinatedBackoff(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-backoff:"))
+	ctx := context.Background()
+	backoff := WithCoordinatedBackoff(100*time.Millisecond, 250*time.Millisecond)
+
+	t.Run("waiters claim spread slots", func(t *testing.T) {
+		var delays []time.Duration
+		for i := 0; i < 4; i++ {
+			delays = append(delays, client.NewLock("test-spread", backoff).(*lockImpl).retryDelay(ctx, 1))
+		}
+		for i, want := range []time.Duration{100, 200, 250, 250} {
+			if delay := delays[i]; delay > want*time.Millisecond || delay < (want-20)*time.Millisecond {
+				t.Errorf("Slot %d: expected about %dms, got: %v", i, want, delay)
+			}
+		}
+		if ttl := redisClient.PTTL(ctx, client.backoffKey(client.lockKey("test-spread"))).Val(); ttl <= 0 || ttl > 350*time.Millisecond {
+			t.Errorf("Hint should expire after the last slot, got TTL: %v", ttl)
+		}
+	})
+
+	t.Run("waiters acquire once the lock is free", func(t *testing.T) {
+		holder := client.NewLock("test-herd")
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		errs := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				lock := client.NewLock("test-herd", backoff, WithWaitTimeout(5*time.Second))
+				err := lock.Lock(ctx)
+				if err == nil {
+					err = lock.Unlock(ctx)
+				}
+				errs <- err
+			}()
+		}
+		time.Sleep(300 * time.Millisecond)
+		holder.Unlock(ctx)
+
+		for i := 0; i < 3; i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("Waiter failed: %v", err)
+			}
+		}
+	})
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	// A server that accepts connections but never replies, like a hung backend
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	redisClient := redis.NewClient(&redis.Options{Addr: listener.Addr().String(), MaxRetries: -1})
+	defer redisClient.Close()
+	client := NewClient(redisClient, WithLogger(&NoopLogger{}), WithoutReplicaCheck())
+
+	t.Run("hung attempt is abandoned", func(t *testing.T) {
+		start := time.Now()
+		_, err := client.NewLock("test-hung", WithAttemptTimeout(100*time.Millisecond)).TryLock(context.Background())
+		if !errors.Is(err, ErrAttemptTimeout) || Code(err) != CodeBackendUnavailable {
+			t.Fatalf("Expected ErrAttemptTimeout, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Attempt should be abandoned promptly, took %v", elapsed)
+		}
+	})
+
+	t.Run("cancelled attempt is abandoned", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := client.NewLock("test-hung").Lock(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("Expected deadline error, got: %v", err)
+		}
+	})
+
+	t.Run("default timeout follows the lease", func(t *testing.T) {
+		l := client.NewLock("test-hung", WithLeaseTime(300*time.Millisecond)).(*lockImpl)
+		if timeout := l.attemptTimeout(l.leaseTime()); timeout != 300*time.Millisecond {
+			t.Errorf("Expected the lease to bound the attempt, got: %v", timeout)
+		}
+		l = client.NewLock("test-hung").(*lockImpl)
+		if timeout := l.attemptTimeout(l.leaseTime()); timeout != defaultAttemptTimeout {
+			t.Errorf("Expected the default attempt timeout, got: %v", timeout)
+		}
+	})
+}
+
+func TestRetryInterval(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	l := client.NewLock("test-interval", WithRetryInterval(20*time.Millisecond)).(*lockImpl)
+	if delay := l.retryDelay(ctx, 1); delay != 20*time.Millisecond {
+		t.Errorf("Expected retry delay of 20ms, got: %v", delay)
+	}
+	// A short poll interval does not shorten the round trips
+	l = client.NewLock("test-interval", WithRetryInterval(time.Millisecond)).(*lockImpl)
+	if timeout := l.attemptTimeout(l.leaseTime()); timeout != defaultAttemptTimeout {
+		t.Errorf("Expected the default attempt timeout, got: %v", timeout)
+	}
+	if delay := client.NewLock("test-interval").(*lockImpl).retryDelay(ctx, 1); delay != defaultRetryInterval {
+		t.Errorf("Expected default retry delay, got: %v", delay)
+	}
+
+	// The memory store reports no release, waiters wake up at the interval
+	holder := client.NewLock("test-interval", WithLeaseTime(time.Minute))
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { holder.Unlock(ctx) })
+
+	start := time.Now()
+	waiter := client.NewLock("test-interval", WithRetryInterval(20*time.Millisecond), WithWaitTimeout(time.Second))
+	if err := waiter.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire released lock: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("Expected waiter to retry at the interval, acquired after %v", elapsed)
+	}
+}
@@ -0,0 +1,58 @@
+package arbiter
+
+import "github.com/redis/go-redis/v9"
+
+// lockRoute stores the locks matching patterns on a dedicated Redis
+type lockRoute struct {
+	patterns []string
+	redis    redis.UniversalClient
+	client   *Client
+}
+
+// WithLockRoute stores the locks whose names match one of patterns in rc instead of the
+// Redis of the client, e.g. to isolate especially critical locks on a dedicated instance
+// or logical DB. Patterns use path.Match syntax and the first matching route wins.
+// Routing is transparent: locks, lock state and operator controls all follow the route,
+// and freezes are applied to every instance. Namespace quotas are counted per instance.
+func WithLockRoute(rc redis.UniversalClient, patterns ...string) ClientOption {
+	return func(c *Client) {
+		c.routes = append(c.routes, &lockRoute{patterns: patterns, redis: rc})
+	}
+}
+
+// withoutRoutes drops the routes of the client, building the client of a route. The
+// route runs on its own Redis, so a store or executor set for the primary is dropped too.
+func withoutRoutes() ClientOption {
+	return func(c *Client) {
+		c.routes = nil
+		c.store = nil
+		c.executor = nil
+	}
+}
+
+// initRoutes creates a client per route sharing the configuration of c
+func (c *Client) initRoutes() {
+	for _, route := range c.routes {
+		opts := append(append([]ClientOption{}, c.opts...), withoutRoutes())
+		route.client = NewClient(route.redis, opts...)
+	}
+}
+
+// route returns the client serving the named lock
+func (c *Client) route(name string) *Client {
+	for _, route := range c.routes {
+		if matchAny(route.patterns, name) {
+			return route.client
+		}
+	}
+	return c
+}
+
+// backends returns c followed by the clients of its routes
+func (c *Client) backends() []*Client {
+	backends := []*Client{c}
+	for _, route := range c.routes {
+		backends = append(backends, route.client)
+	}
+	return backends
+}
@@ -0,0 +1,76 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLockRoute(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	critical := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 1})
+	defer critical.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-route:"), WithLockRoute(critical, "payments:*"))
+	defer client.Close()
+	ctx := context.Background()
+
+	lock := client.NewLock("payments:42")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer lock.Unlock(ctx)
+
+	if n, _ := critical.Exists(ctx, "test-route:payments:42").Result(); n != 1 {
+		t.Fatal("Routed lock should be stored on its dedicated Redis")
+	}
+	if n, _ := redisClient.Exists(ctx, "test-route:payments:42").Result(); n != 0 {
+		t.Fatal("Routed lock should not be stored on the default Redis")
+	}
+
+	t.Run("state follows the route", func(t *testing.T) {
+		if locked, err := client.IsLocked(ctx, "payments:42"); err != nil || !locked {
+			t.Fatalf("Routed lock should be reported held, got: %v, %v", locked, err)
+		}
+
+		infos, err := client.InspectLocks(ctx, []string{"orders:1", "payments:42"})
+		if err != nil {
+			t.Fatalf("Failed to inspect locks: %v", err)
+		}
+		if infos[0].Held || !infos[1].Held || infos[1].Name != "payments:42" {
+			t.Fatalf("Unexpected lock infos: %+v", infos)
+		}
+
+		listed, err := client.Admin().ListLocks(ctx)
+		if err != nil {
+			t.Fatalf("Failed to list locks: %v", err)
+		}
+		if len(listed) != 1 || listed[0].Name != "payments:42" {
+			t.Fatalf("Unexpected listed locks: %+v", listed)
+		}
+	})
+
+	t.Run("freezes apply to every instance", func(t *testing.T) {
+		admin := client.Admin()
+		if err := admin.Freeze(ctx, "payments:*"); err != nil {
+			t.Fatalf("Failed to freeze locks: %v", err)
+		}
+		defer admin.Unfreeze(ctx, "payments:*")
+
+		if _, err := client.NewLock("payments:43").TryLock(ctx); err != ErrLockFrozen {
+			t.Fatalf("Expected frozen error, got: %v", err)
+		}
+	})
+
+	t.Run("force unlock", func(t *testing.T) {
+		if err := client.Admin().ForceUnlock(ctx, "payments:42"); err != nil {
+			t.Fatalf("Failed to force unlock routed lock: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "payments:42"); locked {
+			t.Fatal("Routed lock should be released")
+		}
+	})
+}
@@ -0,0 +1,305 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// ErrUpgradeDeadlock is returned when upgrading a read-write lock while another reader is upgrading
+var ErrUpgradeDeadlock = errors.New("read-write lock upgrade would deadlock")
+
+// rwIntentTTL is how long the intent of a waiting writer keeps new readers out
+// without being renewed by its retry loop
+const rwIntentTTL = time.Second
+
+const (
+	// readerFieldPrefix starts the lock hash fields of the readers of a read-write lock
+	readerFieldPrefix = "reader:"
+
+	// intentFieldPrefix starts the lock hash fields holding the intent expiry of waiting writers
+	intentFieldPrefix = "intent:"
+)
+
+// RWLock is a distributed read-write lock. Any number of readers may hold it at once,
+// writers hold it exclusively. A waiting writer keeps new readers out, so writers are
+// not starved by a steady stream of readers. Both sides use the lease time of the lock
+// options and extend it with Refresh, the watchdog is not supported.
+type RWLock interface {
+	// RLock acquires the read side, blocking until it succeeds or ctx is done
+	RLock(ctx context.Context) error
+
+	// TryRLock attempts to acquire the read side and returns immediately
+	TryRLock(ctx context.Context) (bool, error)
+
+	// RUnlock releases the read side
+	RUnlock(ctx context.Context) error
+
+	// Lock acquires the write side, blocking until it succeeds or ctx is done
+	Lock(ctx context.Context) error
+
+	// TryLock attempts to acquire the write side and returns immediately
+	TryLock(ctx context.Context) (bool, error)
+
+	// Unlock releases the write side
+	Unlock(ctx context.Context) error
+
+	// Refresh extends the lease of the side currently held
+	Refresh(ctx context.Context) error
+
+	// Downgrade atomically turns the held write side into the read side, admitting
+	// waiting readers without letting another writer in between
+	Downgrade(ctx context.Context) error
+
+	// Upgrade turns the held read side into the write side, blocking until the other
+	// readers left or ctx is done. New readers are kept out meanwhile. It returns
+	// ErrUpgradeDeadlock if another reader is already upgrading, since both would wait
+	// for each other forever; the caller keeps the read side and should release it.
+	Upgrade(ctx context.Context) error
+
+	// TryUpgrade attempts Upgrade and returns immediately
+	TryUpgrade(ctx context.Context) (bool, error)
+}
+
+type rwLockImpl struct {
+	client  *Client
+	name    string
+	key     string
+	frozen  []string
+	value   string
+	options *LockOptions
+	logger  Logger
+
+	mu sync.Mutex
+}
+
+// NewRWLock creates a new distributed read-write lock instance. It shares the key of
+// a lock created by NewLock with the same name, which is then held by its writers.
+func (c *Client) NewRWLock(name string, opts ...Option) RWLock {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	return &rwLockImpl{
+		client:  c,
+		name:    name,
+		key:     c.lockKey(name),
+		frozen:  []string{c.frozenKey(c.lockKey(name)), c.frozenPrefixKey(c.lockKey(name))},
+		value:   generateValue(),
+		options: options,
+		logger:  c.logger,
+	}
+}
+
+func (l *rwLockImpl) RLock(ctx context.Context) error {
+	return l.wait(ctx, func() (bool, error) { return l.TryRLock(ctx) })
+}
+
+func (l *rwLockImpl) TryRLock(ctx context.Context) (bool, error) {
+	return l.try(ctx, lua.RWRLock, time.Now().UnixMilli(), l.client.eventsChannel())
+}
+
+func (l *rwLockImpl) RUnlock(ctx context.Context) error {
+	return l.Unlock(ctx)
+}
+
+func (l *rwLockImpl) Lock(ctx context.Context) error {
+	// A cancelled writer must not keep readers out until its intent expires
+	defer l.client.leaveWait(context.WithoutCancel(ctx), l.key, l.value)
+
+	return l.wait(ctx, func() (bool, error) {
+		// Announce the waiting writer so that no new readers are admitted
+		now := time.Now()
+		return l.try(ctx, lua.RWLock, now.UnixMilli(), now.Add(rwIntentTTL).UnixMilli(), l.client.eventsChannel())
+	})
+}
+
+func (l *rwLockImpl) TryLock(ctx context.Context) (bool, error) {
+	return l.try(ctx, lua.RWLock, time.Now().UnixMilli(), 0, l.client.eventsChannel())
+}
+
+func (l *rwLockImpl) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+
+	ok, err := l.client.runScriptBool(ctx, lua.RWUnlock, []string{l.key}, l.value, l.client.eventsChannel())
+	if err != nil {
+		l.logger.Error(ctx, "Error releasing read-write lock: %s", l.key)
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+
+	l.logger.Info(ctx, "Released read-write lock: %s", l.key)
+	return nil
+}
+
+func (l *rwLockImpl) Refresh(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+
+	ok, err := l.client.runScriptBool(ctx, lua.RWRefresh, []string{l.key}, l.value,
+		l.options.LeaseTime.Milliseconds(), time.Now().UnixMilli())
+	if err != nil {
+		l.logger.Error(ctx, "Error refreshing read-write lock: %s", l.key)
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *rwLockImpl) Downgrade(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+
+	ok, err := l.client.runScriptBool(ctx, lua.RWDowngrade, []string{l.key}, l.value,
+		l.options.LeaseTime.Milliseconds(), time.Now().UnixMilli(), l.client.eventsChannel())
+	if err != nil {
+		l.logger.Error(ctx, "Error downgrading read-write lock: %s", l.key)
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+
+	l.logger.Info(ctx, "Downgraded read-write lock: %s", l.key)
+	return nil
+}
+
+func (l *rwLockImpl) Upgrade(ctx context.Context) error {
+	// A cancelled upgrade must not keep readers out until its intent expires
+	defer l.client.leaveWait(context.WithoutCancel(ctx), l.key, l.value)
+
+	return l.wait(ctx, func() (bool, error) {
+		now := time.Now()
+		return l.upgrade(ctx, now, now.Add(rwIntentTTL))
+	})
+}
+
+func (l *rwLockImpl) TryUpgrade(ctx context.Context) (bool, error) {
+	return l.upgrade(ctx, time.Now(), time.Time{})
+}
+
+// upgrade runs one upgrade attempt, announcing a write intent until intent unless it is zero
+func (l *rwLockImpl) upgrade(ctx context.Context, now, intent time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.client.policy.check(l.name); err != nil {
+		return false, err
+	}
+
+	var expiry int64
+	if !intent.IsZero() {
+		expiry = intent.UnixMilli()
+	}
+	res, err := replyInt(l.client.runScript(ctx, lua.RWUpgrade, []string{l.key}, l.value,
+		l.options.LeaseTime.Milliseconds(), now.UnixMilli(), expiry))
+	if err != nil {
+		l.logger.Error(ctx, "Error upgrading read-write lock: %s", l.key)
+		return false, err
+	}
+	switch res {
+	case lua.UpgradeNotReader:
+		return false, ErrLockNotHeld
+	case lua.UpgradeDeadlock:
+		l.logger.Warn(ctx, "Refused upgrade of read-write lock with another upgrading reader: %s", l.key)
+		return false, ErrUpgradeDeadlock
+	case lua.NotAcquired:
+		return false, nil
+	}
+	return true, nil
+}
+
+// try runs one acquisition script, args follow the owner, lease and name arguments
+func (l *rwLockImpl) try(ctx context.Context, script string, args ...interface{}) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.client.policy.check(l.name); err != nil {
+		l.logger.Warn(ctx, "Rejected acquisition of lock: %s, error: %v", l.key, err)
+		return false, err
+	}
+	if err := l.client.checkRole(ctx); err != nil {
+		return false, err
+	}
+
+	keys := append([]string{l.key}, l.frozen...)
+	args = append([]interface{}{l.value, l.options.LeaseTime.Milliseconds(), l.name}, args...)
+	res, err := replyInt(l.client.runScript(ctx, script, keys, args...))
+	if err != nil {
+		l.logger.Error(ctx, "Error trying to acquire read-write lock: %s", l.key)
+		return false, err
+	}
+	switch res {
+	case lua.Frozen:
+		l.logger.Warn(ctx, "Rejected acquisition of frozen lock: %s", l.key)
+		return false, ErrLockFrozen
+	case lua.NotAcquired:
+		return false, nil
+	}
+	return true, nil
+}
+
+// wait retries try until it succeeds, fails, the wait timeout passes or ctx is done
+func (l *rwLockImpl) wait(ctx context.Context, try func() (bool, error)) error {
+	deadline, charge := waitDeadline(ctx, l.options.WaitTimeout)
+	defer charge()
+	for attempt := 1; ; attempt++ {
+		acquired, err := try()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			l.logger.Info(ctx, "Successfully acquired read-write lock: %s", l.key)
+			return nil
+		}
+
+		if waitExpired(deadline) {
+			l.logger.Warn(ctx, "Timeout waiting for read-write lock: %s", l.key)
+			return ErrLockTimeout
+		}
+		if l.options.retriesExhausted(attempt - 1) {
+			return ErrMaxRetries
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.options.nextDelay(attempt)):
+		}
+	}
+}
+
+// readers returns the number of readers stored in a lock hash
+func readers(fields map[string]string) int {
+	n := 0
+	for field := range fields {
+		if strings.HasPrefix(field, readerFieldPrefix) {
+			n++
+		}
+	}
+	return n
+}
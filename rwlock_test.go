@@ -0,0 +1,258 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRWLock(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-rwlock:"))
+	ctx := context.Background()
+
+	t.Run("readers share the lock", func(t *testing.T) {
+		first := client.NewRWLock("test-shared")
+		second := client.NewRWLock("test-shared")
+
+		if err := first.RLock(ctx); err != nil {
+			t.Fatalf("Failed to acquire read lock: %v", err)
+		}
+		if err := second.RLock(ctx); err != nil {
+			t.Fatalf("Failed to acquire second read lock: %v", err)
+		}
+
+		infos, err := client.InspectLocks(ctx, []string{"test-shared"})
+		if err != nil {
+			t.Fatalf("Failed to inspect lock: %v", err)
+		}
+		if !infos[0].Held || infos[0].Readers != 2 || len(infos[0].Metadata) != 0 {
+			t.Errorf("Unexpected lock info: %+v", infos[0])
+		}
+
+		if err := first.RUnlock(ctx); err != nil {
+			t.Fatalf("Failed to release read lock: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-shared"); !locked {
+			t.Fatal("Lock should stay held by the remaining reader")
+		}
+		if err := second.RUnlock(ctx); err != nil {
+			t.Fatalf("Failed to release read lock: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-shared"); locked {
+			t.Fatal("Lock should be released with its last reader")
+		}
+		if err := second.RUnlock(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+	})
+
+	t.Run("writer excludes readers and writers", func(t *testing.T) {
+		writer := client.NewRWLock("test-exclusive")
+		if err := writer.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire write lock: %v", err)
+		}
+
+		if acquired, err := client.NewRWLock("test-exclusive").TryRLock(ctx); err != nil || acquired {
+			t.Fatalf("Reader should be excluded, got: %v, %v", acquired, err)
+		}
+		if acquired, err := client.NewRWLock("test-exclusive").TryLock(ctx); err != nil || acquired {
+			t.Fatalf("Writer should be excluded, got: %v, %v", acquired, err)
+		}
+		if acquired, err := client.NewLock("test-exclusive").TryLock(ctx); err != nil || acquired {
+			t.Fatalf("Plain lock should be excluded, got: %v, %v", acquired, err)
+		}
+
+		if err := writer.Refresh(ctx); err != nil {
+			t.Fatalf("Failed to refresh write lock: %v", err)
+		}
+		if err := writer.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release write lock: %v", err)
+		}
+	})
+
+	t.Run("waiting writer blocks new readers", func(t *testing.T) {
+		reader := client.NewRWLock("test-intent")
+		if err := reader.RLock(ctx); err != nil {
+			t.Fatalf("Failed to acquire read lock: %v", err)
+		}
+
+		writer := client.NewRWLock("test-intent", WithWaitTimeout(5*time.Second))
+		done := make(chan error, 1)
+		go func() { done <- writer.Lock(ctx) }()
+
+		waitFor(t, func() bool {
+			intent, _ := redisClient.HExists(ctx, client.lockKey("test-intent"), intentFieldPrefix+writer.(*rwLockImpl).value).Result()
+			return intent
+		})
+		if acquired, err := client.NewRWLock("test-intent").TryRLock(ctx); err != nil || acquired {
+			t.Fatalf("New reader should wait for the writer, got: %v, %v", acquired, err)
+		}
+
+		// Current readers may still renew their lease
+		if err := reader.RLock(ctx); err != nil {
+			t.Fatalf("Reader should re-enter: %v", err)
+		}
+		if err := reader.Refresh(ctx); err != nil {
+			t.Fatalf("Failed to refresh read lock: %v", err)
+		}
+
+		if err := reader.RUnlock(ctx); err != nil {
+			t.Fatalf("Failed to release read lock: %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("Writer should acquire after the last reader: %v", err)
+		}
+		if err := writer.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release write lock: %v", err)
+		}
+	})
+
+	t.Run("cancelled writer withdraws its intent", func(t *testing.T) {
+		reader := client.NewRWLock("test-withdraw")
+		if err := reader.RLock(ctx); err != nil {
+			t.Fatalf("Failed to acquire read lock: %v", err)
+		}
+		defer reader.RUnlock(ctx)
+
+		writer := client.NewRWLock("test-withdraw")
+		waitCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- writer.Lock(waitCtx) }()
+
+		intent := intentFieldPrefix + writer.(*rwLockImpl).value
+		waitFor(t, func() bool {
+			exists, _ := redisClient.HExists(ctx, client.lockKey("test-withdraw"), intent).Result()
+			return exists
+		})
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Fatalf("Expected cancellation, got: %v", err)
+		}
+
+		other := client.NewRWLock("test-withdraw")
+		acquired, err := other.TryRLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Reader should be admitted after the writer gave up, got: %v, %v", acquired, err)
+		}
+		other.RUnlock(ctx)
+	})
+
+	t.Run("lapsed readers do not block writers", func(t *testing.T) {
+		reader := client.NewRWLock("test-lapsed", WithLeaseTime(100*time.Millisecond))
+		if err := reader.RLock(ctx); err != nil {
+			t.Fatalf("Failed to acquire read lock: %v", err)
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		writer := client.NewRWLock("test-lapsed")
+		acquired, err := writer.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Writer should acquire over a lapsed reader, got: %v, %v", acquired, err)
+		}
+		writer.Unlock(ctx)
+	})
+
+	t.Run("frozen lock rejects readers", func(t *testing.T) {
+		if err := client.Admin().Freeze(ctx, "test-frozen"); err != nil {
+			t.Fatalf("Failed to freeze lock: %v", err)
+		}
+		defer client.Admin().Unfreeze(ctx, "test-frozen")
+
+		if _, err := client.NewRWLock("test-frozen").TryRLock(ctx); err != ErrLockFrozen {
+			t.Fatalf("Expected frozen error, got: %v", err)
+		}
+		if err := client.NewRWLock("test-frozen").Lock(ctx); err != ErrLockFrozen {
+			t.Fatalf("Expected frozen error, got: %v", err)
+		}
+	})
+}
+
+func TestRWLockUpgrade(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-rwlock-upgrade:"))
+	ctx := context.Background()
+
+	t.Run("downgrade admits readers but not writers", func(t *testing.T) {
+		lock := client.NewRWLock("test-downgrade")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire write side: %v", err)
+		}
+		if err := lock.Downgrade(ctx); err != nil {
+			t.Fatalf("Failed to downgrade: %v", err)
+		}
+		if err := lock.Downgrade(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+
+		reader := client.NewRWLock("test-downgrade")
+		if acquired, err := reader.TryRLock(ctx); err != nil || !acquired {
+			t.Fatalf("Reader should join a downgraded lock, got: %v, %v", acquired, err)
+		}
+		if acquired, _ := client.NewRWLock("test-downgrade").TryLock(ctx); acquired {
+			t.Fatal("Writer should not acquire a downgraded lock")
+		}
+		reader.RUnlock(ctx)
+		if err := lock.RUnlock(ctx); err != nil {
+			t.Fatalf("Failed to release read side: %v", err)
+		}
+	})
+
+	t.Run("upgrade waits for other readers", func(t *testing.T) {
+		lock := client.NewRWLock("test-upgrade")
+		other := client.NewRWLock("test-upgrade")
+		if _, err := lock.TryUpgrade(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+		if err := lock.RLock(ctx); err != nil {
+			t.Fatalf("Failed to acquire read side: %v", err)
+		}
+		if err := other.RLock(ctx); err != nil {
+			t.Fatalf("Failed to acquire read side: %v", err)
+		}
+
+		if acquired, err := lock.TryUpgrade(ctx); err != nil || acquired {
+			t.Fatalf("Upgrade should wait for the other reader, got: %v, %v", acquired, err)
+		}
+
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			other.RUnlock(ctx)
+		}()
+		if err := lock.Upgrade(ctx); err != nil {
+			t.Fatalf("Failed to upgrade: %v", err)
+		}
+		if acquired, _ := client.NewRWLock("test-upgrade").TryRLock(ctx); acquired {
+			t.Fatal("Reader should not acquire an upgraded lock")
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release write side: %v", err)
+		}
+	})
+
+	t.Run("concurrent upgrades would deadlock", func(t *testing.T) {
+		first := client.NewRWLock("test-deadlock", WithWaitTimeout(2*time.Second))
+		second := client.NewRWLock("test-deadlock")
+		first.RLock(ctx)
+		second.RLock(ctx)
+
+		upgraded := make(chan error, 1)
+		go func() { upgraded <- first.Upgrade(ctx) }()
+		time.Sleep(50 * time.Millisecond)
+
+		if err := second.Upgrade(ctx); err != ErrUpgradeDeadlock {
+			t.Fatalf("Expected upgrade deadlock error, got: %v", err)
+		}
+
+		// Releasing the read side lets the first upgrade proceed
+		second.RUnlock(ctx)
+		if err := <-upgraded; err != nil {
+			t.Fatalf("Failed to upgrade: %v", err)
+		}
+		first.Unlock(ctx)
+	})
+}
@@ -0,0 +1,135 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+)
+
+// Saga runs a multi-resource workflow step by step under locks. Each step takes its
+// lock before it runs; when a step fails, the compensations of the steps that completed
+// run in reverse order while every lock is still held, and only then are the locks
+// released, so cleanup never races with another owner.
+type Saga struct {
+	client  *Client
+	steps   []sagaStep
+	opts    []Option
+	options *LockOptions
+	logger  Logger
+}
+
+type sagaStep struct {
+	lock       string
+	run        func(ctx context.Context) error
+	compensate func(ctx context.Context) error
+}
+
+// SagaError is returned by Saga.Run when a step failed. It unwraps to the error of the
+// step, and keeps the errors of compensations that failed in turn.
+type SagaError struct {
+	// Step is the index of the failed step
+	Step int
+	// Err is the error of the step, or of acquiring its lock
+	Err error
+	// Compensations holds the errors of failed compensations, latest step first
+	Compensations []error
+}
+
+func (e *SagaError) Error() string {
+	if len(e.Compensations) == 0 {
+		return fmt.Sprintf("saga step %d failed: %v", e.Step, e.Err)
+	}
+	return fmt.Sprintf("saga step %d failed: %v (%d compensations failed)", e.Step, e.Err, len(e.Compensations))
+}
+
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+// NewSaga creates an empty saga whose locks are created with opts. Panics of steps are
+// handled as set by WithPanicPolicy or WithPanicHandler after compensating.
+func (c *Client) NewSaga(opts ...Option) *Saga {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &Saga{client: c, opts: opts, options: options, logger: c.logger}
+}
+
+// Step appends a step running under the named lock, or under none if lock is "".
+// compensate undoes run and may be nil. Locks are acquired in step order, so sagas
+// sharing locks must add their steps in a consistent lock order to avoid deadlocks.
+func (s *Saga) Step(lock string, run, compensate func(ctx context.Context) error) *Saga {
+	s.steps = append(s.steps, sagaStep{lock: lock, run: run, compensate: compensate})
+	return s
+}
+
+// Run runs the steps in order and releases every lock it took. If a step or the
+// acquisition of its lock fails, the completed steps are compensated and Run returns
+// a *SagaError. Compensations run with a context that is not cancelled with ctx.
+func (s *Saga) Run(ctx context.Context) error {
+	var locks []Lock
+	panicked, err := s.run(ctx, &locks)
+
+	// Released in reverse acquisition order once compensations are done
+	for i := len(locks) - 1; i >= 0; i-- {
+		if err := locks[i].Unlock(context.WithoutCancel(ctx)); err != nil {
+			s.logger.Warn(ctx, "Failed to release lock of saga, error: %v", err)
+		}
+	}
+
+	if panicked != nil {
+		return s.options.handlePanic(ctx, panicked)
+	}
+	return err
+}
+
+// run runs the steps, appending the locks it acquires to locks, and compensates when
+// one fails. It returns the panic of a failed step, if it panicked.
+func (s *Saga) run(ctx context.Context, locks *[]Lock) (*recovered, error) {
+	held := make(map[string]bool)
+	for i, step := range s.steps {
+		var err error
+		if step.lock != "" && !held[step.lock] {
+			lock := s.client.NewLock(step.lock, s.opts...)
+			if err = lock.Lock(ctx); err == nil {
+				held[step.lock] = true
+				*locks = append(*locks, lock)
+			}
+		}
+
+		var panicked *recovered
+		if err == nil {
+			panicked, err = protect(func() error { return step.run(ctx) })
+		}
+		if panicked == nil && err == nil {
+			continue
+		}
+
+		s.logger.Warn(ctx, "Saga step %d failed, compensating %d steps", i, i)
+		return panicked, &SagaError{Step: i, Err: err, Compensations: s.compensate(ctx, i)}
+	}
+	return nil, nil
+}
+
+// compensate runs the compensations of the first done steps in reverse order and
+// returns their errors. A panicking compensation counts as failed.
+func (s *Saga) compensate(ctx context.Context, done int) []error {
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+	for i := done - 1; i >= 0; i-- {
+		fn := s.steps[i].compensate
+		if fn == nil {
+			continue
+		}
+		panicked, err := protect(func() error { return fn(ctx) })
+		if panicked != nil {
+			err = fmt.Errorf("%w: %v", ErrPanicked, panicked.value)
+		}
+		if err != nil {
+			s.logger.Error(ctx, "Failed to compensate saga step %d, error: %v", i, err)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
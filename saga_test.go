@@ -0,0 +1,105 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSaga(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("failed step compensates completed steps in reverse", func(t *testing.T) {
+		client := NewClient(nil, WithLogger(&NoopLogger{}))
+		var order []string
+		step := func(name string, err error) (func(context.Context) error, func(context.Context) error) {
+			return func(context.Context) error {
+					order = append(order, "run "+name)
+					return err
+				}, func(context.Context) error {
+					order = append(order, "undo "+name)
+					return nil
+				}
+		}
+
+		failed := errors.New("payment declined")
+		reserveRun, reserveUndo := step("reserve", nil)
+		chargeRun, chargeUndo := step("charge", nil)
+		shipRun, shipUndo := step("ship", failed)
+		err := client.NewSaga().
+			Step("", reserveRun, reserveUndo).
+			Step("", chargeRun, chargeUndo).
+			Step("", shipRun, shipUndo).
+			Run(ctx)
+
+		var sagaErr *SagaError
+		if !errors.As(err, &sagaErr) || sagaErr.Step != 2 || !errors.Is(err, failed) {
+			t.Fatalf("Expected step 2 to fail with the step error, got: %v", err)
+		}
+		want := []string{"run reserve", "run charge", "run ship", "undo charge", "undo reserve"}
+		if len(order) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, order)
+		}
+		for i := range want {
+			if order[i] != want[i] {
+				t.Fatalf("Expected %v, got %v", want, order)
+			}
+		}
+	})
+
+	t.Run("failed compensations are reported", func(t *testing.T) {
+		client := NewClient(nil, WithLogger(&NoopLogger{}))
+		undoFailed := errors.New("refund failed")
+		err := client.NewSaga().
+			Step("", func(context.Context) error { return nil }, func(context.Context) error { return undoFailed }).
+			Step("", func(context.Context) error { return errors.New("ship failed") }, nil).
+			Run(ctx)
+
+		var sagaErr *SagaError
+		if !errors.As(err, &sagaErr) || len(sagaErr.Compensations) != 1 || sagaErr.Compensations[0] != undoFailed {
+			t.Fatalf("Expected the failed compensation to be reported, got: %v", err)
+		}
+	})
+
+	t.Run("panicking step is compensated", func(t *testing.T) {
+		client := NewClient(nil, WithLogger(&NoopLogger{}))
+		compensated := false
+		err := client.NewSaga(WithPanicPolicy(PanicError)).
+			Step("", func(context.Context) error { return nil }, func(context.Context) error {
+				compensated = true
+				return nil
+			}).
+			Step("", func(context.Context) error { panic("boom") }, nil).
+			Run(ctx)
+		if !errors.Is(err, ErrPanicked) {
+			t.Fatalf("Expected ErrPanicked, got: %v", err)
+		}
+		if !compensated {
+			t.Error("Completed step should be compensated")
+		}
+	})
+
+	t.Run("steps run under their locks", func(t *testing.T) {
+		redisClient := setupRedis(t)
+		defer redisClient.Close()
+
+		client := NewClient(redisClient, WithKeyPrefix("test-saga:"))
+		err := client.NewSaga().
+			Step("test-orders", func(ctx context.Context) error {
+				if locked, _ := client.IsLocked(ctx, "test-orders"); !locked {
+					return errors.New("lock not held while running")
+				}
+				return nil
+			}, nil).
+			Step("test-stock", func(ctx context.Context) error { return nil }, nil).
+			Run(ctx)
+		if err != nil {
+			t.Fatalf("Saga failed: %v", err)
+		}
+		for _, name := range []string{"test-orders", "test-stock"} {
+			if locked, _ := client.IsLocked(ctx, name); locked {
+				t.Errorf("Lock %s should be released", name)
+			}
+		}
+	})
+}
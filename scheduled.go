@@ -0,0 +1,98 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+)
+
+// ScheduledRunner runs a periodic function on one instance of the cluster per tick.
+// Ticks are aligned to multiples of the interval since the Unix epoch, so every
+// instance agrees on them regardless of when it started. The instance that takes the
+// lock of a tick records it as the last run before calling the function, so instances
+// of overlapping deployments never fire the same tick twice.
+type ScheduledRunner struct {
+	client   *Client
+	name     string
+	key      string
+	interval time.Duration
+	fn       func(ctx context.Context) error
+	opts     []Option
+	logger   Logger
+}
+
+// NewScheduledRunner creates a runner calling fn every interval under the named lock.
+// The lock is kept by the watchdog while fn runs unless opts say otherwise.
+func (c *Client) NewScheduledRunner(name string, interval time.Duration, fn func(ctx context.Context) error, opts ...Option) *ScheduledRunner {
+	r := c.route(name)
+	return &ScheduledRunner{
+		client:   c,
+		name:     name,
+		key:      r.internalKey("lastrun:" + name),
+		interval: interval,
+		fn:       fn,
+		opts:     append([]Option{WithWatchDog(true)}, opts...),
+		logger:   c.logger,
+	}
+}
+
+// Run calls Tick at every tick until ctx is done and returns ctx.Err(). Errors of
+// single ticks are logged and the next tick runs regardless.
+func (s *ScheduledRunner) Run(ctx context.Context) error {
+	for {
+		next := time.Now().Truncate(s.interval).Add(s.interval)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if _, err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error(ctx, "Scheduled run failed: %s, error: %v", s.name, err)
+		}
+	}
+}
+
+// Tick runs the function for the current tick unless another instance holds the lock
+// or already ran it, and reports whether it ran here. The tick counts as run once the
+// function was called, also if it returned an error.
+func (s *ScheduledRunner) Tick(ctx context.Context) (bool, error) {
+	tick := time.Now().Truncate(s.interval)
+
+	lock := s.client.NewLock(s.name, s.opts...)
+	acquired, err := lock.TryLock(ctx)
+	if err != nil || !acquired {
+		return false, err
+	}
+	defer lock.Unlock(context.WithoutCancel(ctx))
+
+	last, ran, err := s.LastRun(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ran && !last.Before(tick) {
+		s.logger.Debug(ctx, "Skipping tick already run: %s", s.name)
+		return false, nil
+	}
+
+	// Kept for two intervals, the next tick is newer than the recorded one anyway
+	if _, err := s.client.route(s.name).do(ctx, "SET", s.key, tick.UnixMilli(), "PX", (2 * s.interval).Milliseconds()); err != nil {
+		return false, err
+	}
+	s.logger.Debug(ctx, "Running scheduled tick: %s", s.name)
+	return true, s.fn(ctx)
+}
+
+// LastRun returns the tick that ran last and whether one ran within the last two intervals
+func (s *ScheduledRunner) LastRun(ctx context.Context) (time.Time, bool, error) {
+	reply, err := s.client.route(s.name).do(ctx, "GET", s.key)
+	if err != nil || reply == nil {
+		return time.Time{}, false, err
+	}
+	ms, err := replyInt(reply, nil)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.UnixMilli(ms), true, nil
+}
@@ -0,0 +1,57 @@
+package arbiter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduledRunner(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-scheduled:"))
+	ctx := context.Background()
+
+	t.Run("each tick runs once across instances", func(t *testing.T) {
+		var runs atomic.Int32
+		job := func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}
+		first := client.NewScheduledRunner("test-tick", time.Hour, job)
+		second := client.NewScheduledRunner("test-tick", time.Hour, job)
+		redisClient.Del(ctx, first.key)
+
+		if ran, err := first.Tick(ctx); err != nil || !ran {
+			t.Fatalf("First instance should run the tick, got: %v, %v", ran, err)
+		}
+		if ran, err := second.Tick(ctx); err != nil || ran {
+			t.Fatalf("Second instance should skip the tick, got: %v, %v", ran, err)
+		}
+		if runs.Load() != 1 {
+			t.Errorf("Expected one run, got %d", runs.Load())
+		}
+		if _, ok, _ := second.LastRun(ctx); !ok {
+			t.Error("Last run should be recorded")
+		}
+	})
+
+	t.Run("run fires on every tick", func(t *testing.T) {
+		var runs atomic.Int32
+		runner := client.NewScheduledRunner("test-loop", 100*time.Millisecond, func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+
+		runCtx, cancel := context.WithTimeout(ctx, 450*time.Millisecond)
+		defer cancel()
+		if err := runner.Run(runCtx); err != context.DeadlineExceeded {
+			t.Fatalf("Expected deadline error, got: %v", err)
+		}
+		if n := runs.Load(); n < 3 || n > 5 {
+			t.Errorf("Expected about 4 runs, got %d", n)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LockScope tracks the locks acquired within a request and releases those still
+// held when the request context ends, so early returns in complicated handlers
+// cannot leak leases.
+type LockScope struct {
+	client *Client
+	stop   func() bool
+
+	mu     sync.Mutex
+	held   map[*scopedLock]struct{}
+	closed bool
+}
+
+// Scope returns a LockScope bound to ctx. Locks created with LockScope.NewLock that
+// are still held when ctx is done are released and reported as leaks.
+func (c *Client) Scope(ctx context.Context) *LockScope {
+	s := &LockScope{client: c, held: make(map[*scopedLock]struct{})}
+	s.stop = context.AfterFunc(ctx, func() {
+		s.release(context.WithoutCancel(ctx))
+	})
+	return s
+}
+
+// NewLock creates a lock tracked by the scope
+func (s *LockScope) NewLock(name string, opts ...Option) Lock {
+	return &scopedLock{lock: s.client.NewLock(name, opts...), scope: s, name: name}
+}
+
+// Release releases the locks of the scope that are still held and stops tracking.
+// Calling it with defer is an alternative to waiting for the context to end.
+func (s *LockScope) Release(ctx context.Context) {
+	s.stop()
+	s.release(ctx)
+}
+
+func (s *LockScope) release(ctx context.Context) {
+	s.mu.Lock()
+	held := s.held
+	s.held = nil
+	s.closed = true
+	s.mu.Unlock()
+
+	for l := range held {
+		s.client.logger.Warn(ctx, "Releasing lock leaked by scope: %s", l.name)
+		if err := l.lock.Unlock(ctx); err != nil && err != ErrLockNotHeld {
+			s.client.logger.Error(ctx, "Failed to release leaked lock: %s, error: %v", l.name, err)
+		}
+	}
+}
+
+func (s *LockScope) track(l *scopedLock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.held[l] = struct{}{}
+	}
+}
+
+func (s *LockScope) untrack(l *scopedLock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.held, l)
+}
+
+// scopedLock registers itself with its scope while held
+type scopedLock struct {
+	lock  Lock
+	scope *LockScope
+	name  string
+}
+
+func (l *scopedLock) Lock(ctx context.Context) error {
+	if err := l.lock.Lock(ctx); err != nil {
+		return err
+	}
+	l.scope.track(l)
+	return nil
+}
+
+func (l *scopedLock) TryLock(ctx context.Context) (bool, error) {
+	acquired, err := l.lock.TryLock(ctx)
+	if acquired {
+		l.scope.track(l)
+	}
+	return acquired, err
+}
+
+func (l *scopedLock) TryLockInfo(ctx context.Context) (bool, LockInfo, error) {
+	acquired, holder, err := l.lock.TryLockInfo(ctx)
+	if acquired {
+		l.scope.track(l)
+	}
+	return acquired, holder, err
+}
+
+func (l *scopedLock) TryLockFor(ctx context.Context, wait time.Duration) (bool, error) {
+	acquired, err := l.lock.TryLockFor(ctx, wait)
+	if acquired {
+		l.scope.track(l)
+	}
+	return acquired, err
+}
+
+func (l *scopedLock) Unlock(ctx context.Context) error {
+	l.scope.untrack(l)
+	return l.lock.Unlock(ctx)
+}
+
+func (l *scopedLock) Refresh(ctx context.Context) error {
+	return l.lock.Refresh(ctx)
+}
+
+func (l *scopedLock) Fence() int64 {
+	return l.lock.Fence()
+}
+
+func (l *scopedLock) Group(ctx context.Context) (*Group, context.Context) {
+	return l.lock.Group(ctx)
+}
+
+func (l *scopedLock) Do(ctx context.Context, fn func(ctx context.Context, checkpoint Checkpoint) error) error {
+	return l.lock.Do(ctx, fn)
+}
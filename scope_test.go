@@ -0,0 +1,55 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLockScope(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-scope:"))
+
+	t.Run("leaked locks are released when the context ends", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		scope := client.Scope(ctx)
+
+		leaked := scope.NewLock("test-leaked")
+		if err := leaked.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		released := scope.NewLock("test-released")
+		if err := released.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		if err := released.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+
+		cancel()
+		waitFor(t, func() bool {
+			locked, _ := client.IsLocked(context.Background(), "test-leaked")
+			return !locked
+		})
+	})
+
+	t.Run("release", func(t *testing.T) {
+		ctx := context.Background()
+		scope := client.Scope(ctx)
+
+		lock := scope.NewLock("test-release")
+		acquired, err := lock.TryLock(ctx)
+		if err != nil || !acquired {
+			t.Fatalf("Failed to acquire lock: %v, %v", acquired, err)
+		}
+
+		scope.Release(ctx)
+		if locked, _ := client.IsLocked(ctx, "test-release"); locked {
+			t.Fatal("Lock should be released with the scope")
+		}
+		if err := lock.Unlock(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+	})
+}
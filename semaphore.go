@@ -0,0 +1,161 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// Semaphore is a distributed counting semaphore. Each instance holds at most one
+// permit; create one instance per concurrent holder.
+type Semaphore interface {
+	// Acquire takes a permit, waiting in line until one is granted or ctx is done
+	Acquire(ctx context.Context) error
+
+	// TryAcquire takes a permit only if one is free and nobody is waiting for it
+	TryAcquire(ctx context.Context) (bool, error)
+
+	// Release returns the permit
+	Release(ctx context.Context) error
+
+	// Refresh extends the lease of the permit
+	Refresh(ctx context.Context) error
+}
+
+type fairSemaphore struct {
+	client  *Client
+	name    string
+	keys    []string
+	permits int
+	value   string
+	options *LockOptions
+	logger  Logger
+}
+
+// NewFairSemaphore creates a semaphore of permits that are granted in arrival order.
+// Waiters line up in a Redis list, so a steady stream of newcomers cannot starve
+// earlier waiters, and waiters that are cancelled or vanish leave the line. Permits
+// use the lease time of the lock options and are extended with Refresh; the watchdog
+// is not supported.
+func (c *Client) NewFairSemaphore(name string, permits int, opts ...Option) Semaphore {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	c.cardinality.track(context.Background(), c, name)
+	c = c.route(name)
+	base := c.primitiveKey("semaphore", name)
+	return &fairSemaphore{
+		client:  c,
+		name:    name,
+		keys:    []string{base + ":holders", base + ":queue", base + ":waiting"},
+		permits: permits,
+		value:   generateValue(),
+		options: options,
+		logger:  c.logger,
+	}
+}
+
+func (s *fairSemaphore) Acquire(ctx context.Context) error {
+	// Leave the line right away when giving up, also when ctx was cancelled
+	defer s.leave(context.WithoutCancel(ctx))
+
+	deadline, charge := waitDeadline(ctx, s.options.WaitTimeout)
+	defer charge()
+	for attempt := 1; ; attempt++ {
+		acquired, err := s.try(ctx, true)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			s.logger.Info(ctx, "Acquired permit of semaphore: %s", s.name)
+			return nil
+		}
+
+		if waitExpired(deadline) {
+			s.logger.Warn(ctx, "Timeout waiting for semaphore: %s", s.name)
+			return ErrLockTimeout
+		}
+		if s.options.retriesExhausted(attempt - 1) {
+			return ErrMaxRetries
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.options.nextDelay(attempt)):
+		}
+	}
+}
+
+func (s *fairSemaphore) TryAcquire(ctx context.Context) (bool, error) {
+	return s.try(ctx, false)
+}
+
+func (s *fairSemaphore) Release(ctx context.Context) error {
+	if err := s.client.policy.check(s.name); err != nil {
+		return err
+	}
+
+	ok, err := s.client.runScriptBool(ctx, lua.SemRelease, s.keys, s.value)
+	if err != nil {
+		s.logger.Error(ctx, "Error releasing permit of semaphore: %s", s.name)
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+
+	s.logger.Info(ctx, "Released permit of semaphore: %s", s.name)
+	return nil
+}
+
+func (s *fairSemaphore) Refresh(ctx context.Context) error {
+	if err := s.client.policy.check(s.name); err != nil {
+		return err
+	}
+
+	ok, err := s.client.runScriptBool(ctx, lua.SemRefresh, s.keys[:1], s.value,
+		s.options.LeaseTime.Milliseconds(), time.Now().UnixMilli())
+	if err != nil {
+		s.logger.Error(ctx, "Error refreshing permit of semaphore: %s", s.name)
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// try runs one acquisition attempt, enqueueing the semaphore in line if enqueue is set
+func (s *fairSemaphore) try(ctx context.Context, enqueue bool) (bool, error) {
+	if err := s.client.policy.check(s.name); err != nil {
+		s.logger.Warn(ctx, "Rejected acquisition of semaphore: %s, error: %v", s.name, err)
+		return false, err
+	}
+	if err := s.client.checkRole(ctx); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	flag := 0
+	if enqueue {
+		flag = 1
+	}
+	acquired, err := s.client.runScriptBool(ctx, lua.SemAcquire, s.keys, s.value, s.permits,
+		s.options.LeaseTime.Milliseconds(), now.UnixMilli(), now.Add(waiterTTL).UnixMilli(), flag)
+	if err != nil {
+		s.logger.Error(ctx, "Error trying to acquire semaphore: %s", s.name)
+		return false, err
+	}
+	return acquired, nil
+}
+
+// leave withdraws the semaphore from the line of waiters without touching a held permit
+func (s *fairSemaphore) leave(ctx context.Context) {
+	if _, err := s.client.runScript(ctx, lua.SemLeave, s.keys[1:], s.value); err != nil {
+		s.logger.Warn(ctx, "Failed to remove waiter of semaphore: %s, error: %v", s.name, err)
+	}
+}
@@ -0,0 +1,145 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFairSemaphore(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-semaphore:"))
+	ctx := context.Background()
+
+	t.Run("permits are limited", func(t *testing.T) {
+		first := client.NewFairSemaphore("test-limit", 2)
+		second := client.NewFairSemaphore("test-limit", 2)
+		third := client.NewFairSemaphore("test-limit", 2)
+
+		for _, s := range []Semaphore{first, second} {
+			if acquired, err := s.TryAcquire(ctx); err != nil || !acquired {
+				t.Fatalf("Permit should be granted, got: %v, %v", acquired, err)
+			}
+		}
+		if acquired, err := third.TryAcquire(ctx); err != nil || acquired {
+			t.Fatalf("No permit should be left, got: %v, %v", acquired, err)
+		}
+
+		if err := first.Refresh(ctx); err != nil {
+			t.Fatalf("Failed to refresh permit: %v", err)
+		}
+		if err := first.Release(ctx); err != nil {
+			t.Fatalf("Failed to release permit: %v", err)
+		}
+		if err := first.Release(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+		if acquired, err := third.TryAcquire(ctx); err != nil || !acquired {
+			t.Fatalf("Released permit should be granted, got: %v, %v", acquired, err)
+		}
+		second.Release(ctx)
+		third.Release(ctx)
+	})
+
+	t.Run("permits are granted in arrival order", func(t *testing.T) {
+		holder := client.NewFairSemaphore("test-fifo", 1)
+		if err := holder.Acquire(ctx); err != nil {
+			t.Fatalf("Failed to acquire permit: %v", err)
+		}
+
+		order := make(chan int, 3)
+		for i := 0; i < 3; i++ {
+			waiter := client.NewFairSemaphore("test-fifo", 1)
+			go func(i int) {
+				if err := waiter.Acquire(ctx); err != nil {
+					t.Errorf("Failed to acquire permit: %v", err)
+					return
+				}
+				order <- i
+				time.Sleep(50 * time.Millisecond)
+				waiter.Release(ctx)
+			}(i)
+
+			// Wait until the waiter is in line before the next one arrives
+			waitFor(t, func() bool {
+				n, _ := redisClient.LLen(ctx, client.internalKey("semaphore:test-fifo:queue")).Result()
+				return n == int64(i+1)
+			})
+		}
+
+		// Newcomers do not jump the line
+		if acquired, _ := client.NewFairSemaphore("test-fifo", 1).TryAcquire(ctx); acquired {
+			t.Fatal("Newcomer should not jump the line")
+		}
+
+		holder.Release(ctx)
+		for want := 0; want < 3; want++ {
+			if got := <-order; got != want {
+				t.Fatalf("Expected waiter %d to be granted next, got: %d", want, got)
+			}
+		}
+	})
+
+	t.Run("cancelled waiter leaves the line", func(t *testing.T) {
+		holder := client.NewFairSemaphore("test-cancel", 1)
+		if err := holder.Acquire(ctx); err != nil {
+			t.Fatalf("Failed to acquire permit: %v", err)
+		}
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- client.NewFairSemaphore("test-cancel", 1).Acquire(waitCtx) }()
+
+		queue := client.internalKey("semaphore:test-cancel:queue")
+		waitFor(t, func() bool {
+			n, _ := redisClient.LLen(ctx, queue).Result()
+			return n == 1
+		})
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Fatalf("Expected cancellation, got: %v", err)
+		}
+		if n, _ := redisClient.LLen(ctx, queue).Result(); n != 0 {
+			t.Fatalf("Cancelled waiter should leave the line, got %d waiters", n)
+		}
+
+		holder.Release(ctx)
+		next := client.NewFairSemaphore("test-cancel", 1)
+		if acquired, err := next.TryAcquire(ctx); err != nil || !acquired {
+			t.Fatalf("Permit should be granted, got: %v, %v", acquired, err)
+		}
+		next.Release(ctx)
+	})
+
+	t.Run("lapsed holders and waiters are dropped", func(t *testing.T) {
+		lapsed := client.NewFairSemaphore("test-lapsed", 1, WithLeaseTime(100*time.Millisecond))
+		if err := lapsed.Acquire(ctx); err != nil {
+			t.Fatalf("Failed to acquire permit: %v", err)
+		}
+
+		// A waiter that vanished without leaving the line
+		redisClient.RPush(ctx, client.internalKey("semaphore:test-lapsed:queue"), "gone")
+
+		time.Sleep(150 * time.Millisecond)
+		other := client.NewFairSemaphore("test-lapsed", 1)
+		if acquired, err := other.TryAcquire(ctx); err != nil || !acquired {
+			t.Fatalf("Permit of lapsed holder should be granted, got: %v, %v", acquired, err)
+		}
+		if err := lapsed.Refresh(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+		other.Release(ctx)
+	})
+
+	t.Run("wait timeout", func(t *testing.T) {
+		holder := client.NewFairSemaphore("test-timeout", 1)
+		holder.Acquire(ctx)
+		defer holder.Release(ctx)
+
+		if err := client.NewFairSemaphore("test-timeout", 1, WithWaitTimeout(200*time.Millisecond)).Acquire(ctx); err != ErrLockTimeout {
+			t.Fatalf("Expected timeout error, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+)
+
+// ShardedLock splits a coarse lock into a fixed number of finer locks, known as lock
+// striping. Callers lock the shard of the key they touch, so unrelated keys no longer
+// serialize, while maintenance takes every shard with LockAll.
+type ShardedLock struct {
+	client *Client
+	name   string
+	shards int
+	opts   []Option
+}
+
+// NewShardedLock creates a lock split into shards locks named "<name>#<shard>".
+// The number of shards must stay the same across all clients sharing the lock,
+// otherwise a key maps to different shards.
+func (c *Client) NewShardedLock(name string, shards int, opts ...Option) *ShardedLock {
+	return &ShardedLock{client: c, name: name, shards: max(shards, 1), opts: opts}
+}
+
+// NewStripedLock creates a striped lock, hashing item keys onto shards underlying locks.
+// It is NewShardedLock under the name of the technique, and the two share their locks.
+func (c *Client) NewStripedLock(name string, shards int, opts ...Option) *ShardedLock {
+	return c.NewShardedLock(name, shards, opts...)
+}
+
+// For returns the lock of the shard key belongs to
+func (s *ShardedLock) For(key string) Lock {
+	return s.client.NewLock(s.shardName(s.Shard(key)), s.opts...)
+}
+
+// Shard returns the shard key belongs to
+func (s *ShardedLock) Shard(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(s.shards))
+}
+
+// LockAll acquires every shard, in the deadlock-free order of a multi lock, and
+// returns the held lock to release them with
+func (s *ShardedLock) LockAll(ctx context.Context) (Lock, error) {
+	names := make([]string, s.shards)
+	for i := range names {
+		names[i] = s.shardName(i)
+	}
+
+	lock := s.client.NewMultiLock(names, s.opts...)
+	if err := lock.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// shardName returns the lock name of a shard
+func (s *ShardedLock) shardName(shard int) string {
+	return fmt.Sprintf("%s#%d", s.name, shard)
+}
@@ -0,0 +1,99 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestShardedLock(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-sharded:"))
+	ctx := context.Background()
+	sharded := client.NewShardedLock("inventory", 8, WithWaitTimeout(200*time.Millisecond))
+
+	t.Run("keys map to stable shards", func(t *testing.T) {
+		seen := make(map[int]bool)
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("sku-%d", i)
+			shard := sharded.Shard(key)
+			if shard < 0 || shard >= 8 || shard != sharded.Shard(key) {
+				t.Fatalf("Unexpected shard %d for %s", shard, key)
+			}
+			seen[shard] = true
+		}
+		if len(seen) != 8 {
+			t.Errorf("Keys should spread over every shard, got %d", len(seen))
+		}
+	})
+
+	t.Run("keys of other shards do not serialize", func(t *testing.T) {
+		first, other := "sku-1", "sku-2"
+		for sharded.Shard(other) == sharded.Shard(first) {
+			other += "x"
+		}
+
+		lock := sharded.For(first)
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire shard: %v", err)
+		}
+		defer lock.Unlock(ctx)
+
+		if acquired, _ := sharded.For(first).TryLock(ctx); acquired {
+			t.Fatal("Same shard should be exclusive")
+		}
+		otherLock := sharded.For(other)
+		if acquired, err := otherLock.TryLock(ctx); err != nil || !acquired {
+			t.Fatalf("Other shard should be free, got: %v, %v", acquired, err)
+		}
+		otherLock.Unlock(ctx)
+	})
+
+	t.Run("lock all waits for every shard", func(t *testing.T) {
+		lock := sharded.For("sku-3")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire shard: %v", err)
+		}
+		if _, err := sharded.LockAll(ctx); err != ErrLockTimeout {
+			t.Fatalf("Expected timeout error, got: %v", err)
+		}
+		lock.Unlock(ctx)
+
+		all, err := sharded.LockAll(ctx)
+		if err != nil {
+			t.Fatalf("Failed to acquire all shards: %v", err)
+		}
+		if acquired, _ := sharded.For("sku-4").TryLock(ctx); acquired {
+			t.Fatal("Shards should be held by LockAll")
+		}
+		if err := all.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release all shards: %v", err)
+		}
+	})
+}
+
+func TestStripedLock(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	striped := client.NewStripedLock("inventory", 8)
+	sharded := client.NewShardedLock("inventory", 8, WithWaitTimeout(50*time.Millisecond))
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("sku-%d", i)
+		if striped.Shard(key) != sharded.Shard(key) {
+			t.Fatalf("Expected %s on the same shard as the sharded lock", key)
+		}
+	}
+
+	lock := striped.For("sku-1")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire stripe: %v", err)
+	}
+	defer lock.Unlock(ctx)
+	if err := sharded.For("sku-1").Lock(ctx); err != ErrLockTimeout {
+		t.Errorf("Expected the sharded lock to share the stripe, got: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+var (
+	// ErrStateLocked is wrapped by the error StateLock.Lock returns while another operation holds the lock
+	ErrStateLocked = errors.New("state locked")
+
+	// ErrLockIDMismatch is returned by Admin.ForceUnlockState when the lock ID does not match the holder
+	ErrLockIDMismatch = errors.New("lock ID mismatch")
+)
+
+// stateFieldPrefix starts the lock hash fields a StateLock stamps its metadata into
+const stateFieldPrefix = "state:"
+
+// StateLockInfo describes the operation holding a StateLock
+type StateLockInfo struct {
+	// ID identifies the acquisition and confirms Admin.ForceUnlockState
+	ID string
+
+	// Operation is the operation holding the lock, e.g. "plan" or "apply"
+	Operation string
+
+	// Who is the operator holding the lock, user@hostname when left empty
+	Who string
+
+	// Info is free-form detail about the operation
+	Info string
+
+	// Created is when the lock was acquired
+	Created time.Time
+}
+
+// String returns the info in the form shown to competing operators
+func (i StateLockInfo) String() string {
+	return fmt.Sprintf("ID: %s, operation: %s, who: %s, created: %s, info: %s",
+		i.ID, i.Operation, i.Who, i.Created.Format(time.RFC3339), i.Info)
+}
+
+// StateLock models the state lock of infrastructure tools such as Terraform.
+// Acquisitions are stamped with the operation holding the lock, competing
+// operators are told who holds it, and a stuck lock is released with its ID.
+type StateLock struct {
+	client *Client
+	name   string
+	lock   *lockImpl
+	info   StateLockInfo
+}
+
+// NewStateLock creates a state lock. The watchdog is enabled so long operations
+// keep the lock. Lock fails at once while the lock is held, unless WithWaitTimeout
+// sets how long to wait, like the -lock-timeout flag of Terraform.
+func (c *Client) NewStateLock(name string, opts ...Option) *StateLock {
+	return &StateLock{
+		client: c,
+		name:   name,
+		lock:   c.NewLock(name, append([]Option{WithWatchDog(true)}, opts...)...).(*lockImpl),
+	}
+}
+
+// Lock acquires the lock for the operation described by info and returns the
+// stamped info. While another operation holds the lock it returns an error
+// wrapping ErrStateLocked that describes the holder.
+func (s *StateLock) Lock(ctx context.Context, info StateLockInfo) (StateLockInfo, error) {
+	if err := s.acquire(ctx); err != nil {
+		if err != ErrStateLocked && err != ErrLockTimeout {
+			return StateLockInfo{}, err
+		}
+		holder, held, err := s.client.StateLockInfo(ctx, s.name)
+		if err != nil {
+			return StateLockInfo{}, err
+		}
+		if !held {
+			return StateLockInfo{}, fmt.Errorf("%w: %s", ErrStateLocked, s.name)
+		}
+		return StateLockInfo{}, fmt.Errorf("%w: %s, %s", ErrStateLocked, s.name, holder)
+	}
+
+	info.ID = NewToken().String()
+	info.Created = time.Now()
+	if info.Who == "" {
+		info.Who = operator()
+	}
+
+	c := s.lock.client
+	ok, err := c.runScriptBool(ctx, lua.Stamp, []string{s.lock.key}, s.lock.value,
+		stateFieldPrefix+"id", info.ID,
+		stateFieldPrefix+"operation", info.Operation,
+		stateFieldPrefix+"who", info.Who,
+		stateFieldPrefix+"info", c.encodeValue(ctx, info.Info),
+		stateFieldPrefix+"created", info.Created.Format(time.RFC3339Nano))
+	if err != nil {
+		c.logger.Error(ctx, "Failed to stamp state lock: %s, error: %v", s.name, err)
+		s.lock.Unlock(ctx)
+		return StateLockInfo{}, err
+	}
+	if !ok {
+		s.lock.Unlock(ctx)
+		return StateLockInfo{}, ErrLockLost
+	}
+
+	s.info = info
+	c.logger.Info(ctx, "Acquired state lock: %s, %s", s.name, info)
+	return info, nil
+}
+
+// acquire takes the lock, returning ErrStateLocked or ErrLockTimeout while it is held
+func (s *StateLock) acquire(ctx context.Context) error {
+	if s.lock.options.WaitTimeout > 0 {
+		return s.lock.Lock(ctx)
+	}
+
+	acquired, err := s.lock.TryLock(ctx)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrStateLocked
+	}
+	return nil
+}
+
+// Unlock releases the lock
+func (s *StateLock) Unlock(ctx context.Context) error {
+	s.info = StateLockInfo{}
+	return s.lock.Unlock(ctx)
+}
+
+// Info returns the info of the current acquisition, the zero value if the lock is not held
+func (s *StateLock) Info() StateLockInfo {
+	return s.info
+}
+
+// StateLockInfo returns the info of the operation holding a state lock and
+// whether the lock is held
+func (c *Client) StateLockInfo(ctx context.Context, name string) (StateLockInfo, bool, error) {
+	infos, err := c.InspectLocks(ctx, []string{name})
+	if err != nil {
+		return StateLockInfo{}, false, err
+	}
+	if !infos[0].Held {
+		return StateLockInfo{}, false, nil
+	}
+
+	fields := infos[0].Metadata
+	info := StateLockInfo{
+		ID:        fields[stateFieldPrefix+"id"],
+		Operation: fields[stateFieldPrefix+"operation"],
+		Who:       fields[stateFieldPrefix+"who"],
+		Info:      fields[stateFieldPrefix+"info"],
+	}
+	info.Created, _ = time.Parse(time.RFC3339Nano, fields[stateFieldPrefix+"created"])
+	return info, true, nil
+}
+
+// ForceUnlockState releases a state lock held by another operator, like
+// "terraform force-unlock". id must be the ID of the holder as reported in the
+// ErrStateLocked error, so a lock taken over in the meantime is not released.
+// It returns ErrLockIDMismatch if id does not match and ErrLockNotHeld if the
+// lock was not held.
+func (a *Admin) ForceUnlockState(ctx context.Context, name, id string) error {
+	if err := a.client.authorize(ctx, OpForceUnlock, name); err != nil {
+		return err
+	}
+	return a.forceUnlock(ctx, name, stateFieldPrefix+"id", id)
+}
+
+// operator returns user@hostname of the current process
+func operator() string {
+	who := "unknown"
+	if u, err := user.Current(); err == nil {
+		who = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		who += "@" + host
+	}
+	return who
+}
@@ -0,0 +1,93 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateLock(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-state:"))
+	defer client.Close()
+	ctx := context.Background()
+
+	t.Run("competing operators are told who holds the lock", func(t *testing.T) {
+		apply := client.NewStateLock("test-prod")
+		info, err := apply.Lock(ctx, StateLockInfo{Operation: "apply", Who: "alice@laptop", Info: "PR #42"})
+		if err != nil {
+			t.Fatalf("Failed to acquire state lock: %v", err)
+		}
+		defer apply.Unlock(ctx)
+		if info.ID == "" || info.Created.IsZero() {
+			t.Fatalf("Info should be stamped: %+v", info)
+		}
+
+		_, err = client.NewStateLock("test-prod").Lock(ctx, StateLockInfo{Operation: "plan"})
+		if !errors.Is(err, ErrStateLocked) {
+			t.Fatalf("Expected state locked error, got: %v", err)
+		}
+		for _, want := range []string{info.ID, "apply", "alice@laptop", "PR #42"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Error should mention %q: %v", want, err)
+			}
+		}
+
+		holder, held, err := client.StateLockInfo(ctx, "test-prod")
+		if err != nil || !held {
+			t.Fatalf("Failed to read state lock info: %v, %v", held, err)
+		}
+		if holder.ID != info.ID || holder.Operation != "apply" || !holder.Created.Equal(info.Created) {
+			t.Errorf("Unexpected holder info: %+v, want: %+v", holder, info)
+		}
+	})
+
+	t.Run("lock timeout waits for the holder", func(t *testing.T) {
+		holder := client.NewStateLock("test-timeout")
+		if _, err := holder.Lock(ctx, StateLockInfo{Operation: "apply"}); err != nil {
+			t.Fatalf("Failed to acquire state lock: %v", err)
+		}
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			holder.Unlock(ctx)
+		}()
+
+		waiter := client.NewStateLock("test-timeout", WithWaitTimeout(2*time.Second))
+		if _, err := waiter.Lock(ctx, StateLockInfo{Operation: "plan"}); err != nil {
+			t.Fatalf("Failed to acquire released state lock: %v", err)
+		}
+		if err := waiter.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release state lock: %v", err)
+		}
+	})
+
+	t.Run("force unlock requires the lock ID", func(t *testing.T) {
+		stuck := client.NewStateLock("test-stuck")
+		info, err := stuck.Lock(ctx, StateLockInfo{Operation: "apply"})
+		if err != nil {
+			t.Fatalf("Failed to acquire state lock: %v", err)
+		}
+
+		admin := client.Admin()
+		if err := admin.ForceUnlockState(ctx, "test-stuck", "wrong"); err != ErrLockIDMismatch {
+			t.Fatalf("Expected lock ID mismatch, got: %v", err)
+		}
+		if locked, _ := client.IsLocked(ctx, "test-stuck"); !locked {
+			t.Fatal("Lock should still be held")
+		}
+
+		if err := admin.ForceUnlockState(ctx, "test-stuck", info.ID); err != nil {
+			t.Fatalf("Failed to force unlock: %v", err)
+		}
+		if err := admin.ForceUnlockState(ctx, "test-stuck", info.ID); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+		if err := stuck.Unlock(ctx); err != ErrLockNotHeld {
+			t.Fatalf("Expected not held error, got: %v", err)
+		}
+	})
+}
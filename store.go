@@ -0,0 +1,183 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// Store is the backend the core lock operations run against. The client talks to
+// Redis through the default store; alternative backends such as etcd, Postgres or an
+// in-memory map implement Store and are set with WithStore, without touching the lock
+// logic of acquisition, watchdog, groups and callbacks. Operator controls and the
+// other primitives still use Redis.
+type Store interface {
+	// TryAcquire attempts one acquisition of req.Key for req.Owner. An owner already
+	// holding the lock re-enters it and keeps its fencing token, a new owner takes the
+	// next one. It returns ErrLockFrozen or a quota error if the acquisition is refused.
+	TryAcquire(ctx context.Context, req LeaseRequest) (AcquireResult, error)
+
+	// Release releases key if held by owner and reports whether it was
+	Release(ctx context.Context, key, owner string) (bool, error)
+
+	// Extend sets the remaining lease of req.Key if held by req.Owner and reports
+	// whether it was held
+	Extend(ctx context.Context, req LeaseRequest) (bool, error)
+
+	// Watch calls fn with the key of every lock that changed hands until the returned
+	// function is called. fn must not block.
+	Watch(ctx context.Context, fn func(key string)) (func(), error)
+}
+
+// ReplicatedStore is implemented by stores that can wait for an acquisition to reach
+// replicas, which WithReplicationWait needs. The Redis store implements it, locks with
+// WithReplicationWait fail with ErrStoreUnsupported on other stores.
+type ReplicatedStore interface {
+	// TryAcquireReplicated acquires like TryAcquire and then waits up to timeout for the
+	// acquisition to reach replicas, reporting how many acknowledged it in Replicated
+	TryAcquireReplicated(ctx context.Context, req LeaseRequest, replicas int, timeout time.Duration) (AcquireResult, error)
+}
+
+// LeaseRequest describes an acquisition or extension passed to a Store
+type LeaseRequest struct {
+	// Key is the storage key of the lock and Name the lock name it was derived from
+	Key  string
+	Name string
+
+	// Owner is the owner token of the caller
+	Owner string
+
+	// Lease is how long the lock is held, 0 to store it without expiry and keep it
+	// alive by a heartbeat of Heartbeat instead. Stores without a notion of expiry may
+	// keep the lock alive by Expires, which is then a heartbeat away.
+	Lease     time.Duration
+	Heartbeat time.Duration
+
+	// Now is the time of the request and Expires when the lease lapses
+	Now     time.Time
+	Expires time.Time
+
+	// ReadHolder asks TryAcquire to report the holder when the lock is taken. It is a
+	// hint for log messages, stores that cannot read the holder leave it empty.
+	ReadHolder bool
+}
+
+// AcquireResult is the outcome of Store.TryAcquire
+type AcquireResult struct {
+	// Acquired reports whether the caller holds the lock
+	Acquired bool
+
+	// Fence is the fencing token of the acquisition
+	Fence int64
+
+	// Holder describes the current holder if the lock was taken and ReadHolder was set
+	Holder LockInfo
+
+	// Replicated is the number of replicas that acknowledged the acquisition, set by
+	// ReplicatedStore.TryAcquireReplicated
+	Replicated int
+}
+
+// ErrStoreUnsupported is returned by operations that need Redis on a client created
+// without one, running its locks on a Store
+var ErrStoreUnsupported = errors.New("operation not supported by the store")
+
+// WithStore sets the store the core lock operations run against instead of the Redis
+// of the client. A client for a backend other than Redis is created with a nil Redis:
+// acquiring, releasing and refreshing locks with their watchdog, groups and callbacks
+// work, while operations that need Redis return ErrStoreUnsupported.
+func WithStore(store Store) ClientOption {
+	return func(c *Client) {
+		c.store = store
+	}
+}
+
+// redisStore is the default Store, running the Lua scripts against the Redis of a client
+type redisStore struct {
+	client *Client
+}
+
+func (s *redisStore) TryAcquire(ctx context.Context, req LeaseRequest) (AcquireResult, error) {
+	return s.tryAcquire(ctx, req, 0, 0)
+}
+
+func (s *redisStore) TryAcquireReplicated(ctx context.Context, req LeaseRequest, replicas int, timeout time.Duration) (AcquireResult, error) {
+	return s.tryAcquire(ctx, req, replicas, timeout)
+}
+
+// tryAcquire runs the TryLock script, followed by WAIT for replicas if not 0
+func (s *redisStore) tryAcquire(ctx context.Context, req LeaseRequest, replicas int, timeout time.Duration) (AcquireResult, error) {
+	c := s.client
+	quota := c.quota()
+	keys := []string{req.Key, c.frozenKey(req.Key), c.frozenPrefixKey(req.Key), c.heartbeatKey(req.Key),
+		c.permanentKey(req.Key), c.heldKey(req.Key), c.rateKey(req.Key, req.Now), c.fenceKey(req.Key)}
+	args := []interface{}{req.Owner, req.Lease.Milliseconds(), req.Name,
+		req.Heartbeat.Milliseconds(), c.eventsChannel(), quota.MaxHeld, quota.MaxAcquireRate,
+		req.Now.UnixMilli(), req.Expires.UnixMilli(), btoi(req.ReadHolder)}
+
+	var (
+		raw        interface{}
+		replicated int
+		err        error
+	)
+	if replicas > 0 {
+		raw, replicated, err = s.evalReplicated(ctx, keys, args, replicas, timeout)
+	} else {
+		raw, err = c.runScript(ctx, lua.TryLock, keys, args...)
+	}
+	if err != nil {
+		return AcquireResult{}, err
+	}
+
+	res, ok := raw.(int64)
+	if !ok {
+		var result AcquireResult
+		readHolder(&result.Holder, raw)
+		return result, nil
+	}
+	switch res {
+	case lua.Frozen:
+		return AcquireResult{}, ErrLockFrozen
+	case lua.QuotaHeld:
+		return AcquireResult{}, c.quotaError("held locks", quota.MaxHeld)
+	case lua.QuotaRate:
+		return AcquireResult{}, c.quotaError("acquisitions per second", quota.MaxAcquireRate)
+	case lua.NotAcquired:
+		return AcquireResult{}, nil
+	}
+	return AcquireResult{Acquired: true, Fence: res, Replicated: replicated}, nil
+}
+
+// evalReplicated runs the TryLock script followed by WAIT on the primary of the lock key
+func (s *redisStore) evalReplicated(ctx context.Context, keys []string, args []interface{}, replicas int, timeout time.Duration) (interface{}, int, error) {
+	executor, ok := s.client.executor.(ReplicationExecutor)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %T cannot wait for replication", ErrStoreUnsupported, s.client.executor)
+	}
+	return executor.EvalWait(ctx, lua.TryLock, keys, replicas, timeout, args...)
+}
+
+func (s *redisStore) Release(ctx context.Context, key, owner string) (bool, error) {
+	c := s.client
+	return c.runScriptBool(ctx, lua.Unlock, []string{key, c.heartbeatKey(key), c.permanentKey(key), c.heldKey(key)},
+		owner, c.eventsChannel(), c.releasesChannel())
+}
+
+func (s *redisStore) Extend(ctx context.Context, req LeaseRequest) (bool, error) {
+	c := s.client
+
+	// The held set is only maintained under a held-lock quota
+	var expiry int64
+	if c.quota().MaxHeld > 0 {
+		expiry = req.Expires.UnixMilli()
+	}
+	return c.runScriptBool(ctx, lua.Refresh, []string{req.Key, c.heartbeatKey(req.Key), c.heldKey(req.Key)}, req.Owner,
+		req.Lease.Milliseconds(), req.Heartbeat.Milliseconds(), expiry)
+}
+
+func (s *redisStore) Watch(ctx context.Context, fn func(key string)) (func(), error) {
+	return s.client.notifier.listen(ctx, fn)
+}
@@ -0,0 +1,197 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryStore is an in-memory Store for a single process
+type memoryStore struct {
+	mu     sync.Mutex
+	owners map[string]string
+	fences map[string]int64
+	ops    []string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{owners: make(map[string]string), fences: make(map[string]int64)}
+}
+
+func (s *memoryStore) TryAcquire(ctx context.Context, req LeaseRequest) (AcquireResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ops = append(s.ops, "acquire")
+	owner, held := s.owners[req.Key]
+	if held && owner != req.Owner {
+		return AcquireResult{Holder: LockInfo{Held: true, Owner: owner, Fence: s.fences[req.Key]}}, nil
+	}
+	if !held {
+		s.owners[req.Key] = req.Owner
+		s.fences[req.Key]++
+	}
+	return AcquireResult{Acquired: true, Fence: s.fences[req.Key]}, nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key, owner string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ops = append(s.ops, "release")
+	if s.owners[key] != owner {
+		return false, nil
+	}
+	delete(s.owners, key)
+	return true, nil
+}
+
+func (s *memoryStore) Extend(ctx context.Context, req LeaseRequest) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ops = append(s.ops, "extend")
+	return s.owners[req.Key] == req.Owner, nil
+}
+
+func (s *memoryStore) Watch(ctx context.Context, fn func(key string)) (func(), error) {
+	return func() {}, nil
+}
+
+func TestStoreWithoutRedis(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	holder := client.NewLock("test-store", WithWatchDog(true))
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if err := client.NewLock("test-store", WithWaitTimeout(200*time.Millisecond)).Lock(ctx); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected ErrLockTimeout, got: %v", err)
+	}
+	if err := holder.Transfer(ctx, NewToken()); !errors.Is(err, ErrStoreUnsupported) {
+		t.Errorf("Expected ErrStoreUnsupported, got: %v", err)
+	}
+	if _, err := client.IsLocked(ctx, "test-store"); !errors.Is(err, ErrStoreUnsupported) {
+		t.Errorf("Expected ErrStoreUnsupported, got: %v", err)
+	}
+	if err := holder.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("Failed to close client: %v", err)
+	}
+}
+
+func TestStoreUnsupportedOperations(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()), WithTombstones(time.Minute))
+	ctx := context.Background()
+	admin := client.Admin()
+
+	for name, op := range map[string]func() error{
+		"Freeze":           func() error { return admin.Freeze(ctx, "a") },
+		"Unfreeze":         func() error { return admin.Unfreeze(ctx, "a") },
+		"Frozen":           func() error { _, err := admin.Frozen(ctx); return err },
+		"ForceUnlock":      func() error { return admin.ForceUnlock(ctx, "a") },
+		"Steal":            func() error { _, err := admin.Steal(ctx, "a"); return err },
+		"Annotate":         func() error { return admin.Annotate(ctx, "a", "k", "v") },
+		"RemoveAnnotation": func() error { return admin.RemoveAnnotation(ctx, "a", "k") },
+		"ListLocks":        func() error { _, err := admin.ListLocks(ctx); return err },
+		"InspectLocks":     func() error { _, err := client.InspectLocks(ctx, []string{"a"}); return err },
+		"Throttle":         func() error { _, err := client.Throttle(ctx, "a", time.Second); return err },
+		"RetryAfter":       func() error { _, err := client.RetryAfter(ctx, "a"); return err },
+		"LastHolder":       func() error { _, _, err := client.LastHolder(ctx, "a"); return err },
+		"Watch":            func() error { _, err := client.Watch(ctx, "a"); return err },
+		"GC":               func() error { _, err := client.GC(ctx); return err },
+		"Reap":             func() error { _, err := client.Reap(ctx); return err },
+		"Capabilities":     func() error { _, err := client.Capabilities(ctx); return err },
+		"CompletedFence":   func() error { _, _, err := client.CompletedFence(ctx, "a"); return err },
+		"RWLock":           func() error { return client.NewRWLock("a").Lock(ctx) },
+		"RWLock.RLock":     func() error { return client.NewRWLock("a").RLock(ctx) },
+		"RWLock.Unlock":    func() error { return client.NewRWLock("a").Unlock(ctx) },
+		"Semaphore":        func() error { return client.NewFairSemaphore("a", 1).Acquire(ctx) },
+		"Semaphore.Release": func() error {
+			return client.NewFairSemaphore("a", 1).Release(ctx)
+		},
+		"Counter":      func() error { _, err := client.NewCounter("a").Add(ctx, 1); return err },
+		"Latch":        func() error { return client.NewCountDownLatch("a", 1).CountDown(ctx) },
+		"Barrier":      func() error { return client.NewBarrier("a", 1).Enter(ctx) },
+		"RateLimiter":  func() error { _, err := client.NewRateLimiter("a", 1, 1).Allow(ctx); return err },
+		"Concurrency":  func() error { _, err := client.NewConcurrencyLimiter("a", 1, time.Second).Acquire(ctx); return err },
+		"StateLock":    func() error { _, err := client.NewStateLock("a").Lock(ctx, StateLockInfo{}); return err },
+		"IDAllocator":  func() error { _, err := client.NewIDAllocator("a", 10).Next(ctx); return err },
+		"ScheduledRun": func() error { _, err := client.NewScheduledRunner("a", time.Second, nil).Tick(ctx); return err },
+	} {
+		if err := op(); !errors.Is(err, ErrStoreUnsupported) {
+			t.Errorf("%s: expected ErrStoreUnsupported, got: %v", name, err)
+		}
+	}
+	// Not wrapped as a failed quorum
+	if _, err := client.InspectQuorum(ctx, "a"); err != ErrStoreUnsupported {
+		t.Errorf("InspectQuorum: expected ErrStoreUnsupported, got: %v", err)
+	}
+
+	// Tombstones are skipped, a watchdog resync after a stall defers to the refresh
+	lock := client.NewLock("b")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if err := lock.(*lockImpl).resync(ctx, lock.Fence(), time.Second); err != nil {
+		t.Errorf("Expected resync to pass without Redis, got: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	// Nothing listens on the address, every lock operation must go to the store
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer redisClient.Close()
+
+	store := newMemoryStore()
+	client := NewClient(redisClient, WithLogger(&NoopLogger{}), WithoutReplicaCheck(), WithStore(store))
+	ctx := context.Background()
+
+	first := client.NewLock("test-store")
+	acquired, err := first.TryLock(ctx)
+	if err != nil || !acquired {
+		t.Fatalf("Expected to acquire lock, got: %v, %v", acquired, err)
+	}
+	if fence := first.Fence(); fence != 1 {
+		t.Errorf("Expected fence 1, got: %d", fence)
+	}
+
+	acquired, holder, err := client.NewLock("test-store").TryLockInfo(ctx)
+	if err != nil || acquired {
+		t.Fatalf("Expected lock to be taken, got: %v, %v", acquired, err)
+	}
+	if !holder.Held || holder.Name != "test-store" || holder.Fence != 1 {
+		t.Errorf("Expected holder of fence 1, got: %+v", holder)
+	}
+
+	if err := first.Refresh(ctx); err != nil {
+		t.Errorf("Failed to refresh lock: %v", err)
+	}
+	if err := first.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+	if err := first.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld, got: %v", err)
+	}
+
+	want := []string{"acquire", "acquire", "extend", "release", "release"}
+	if len(store.ops) != len(want) {
+		t.Fatalf("Expected operations %v, got: %v", want, store.ops)
+	}
+	for i := range want {
+		if store.ops[i] != want[i] {
+			t.Errorf("Expected operations %v, got: %v", want, store.ops)
+			break
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package arbiter
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryStrategy decides how long a waiting Lock call sleeps before its next attempt.
+// Implementations must be safe for concurrent use, one strategy may serve many calls.
+type RetryStrategy interface {
+	// NextDelay returns the delay before the retry numbered attempt, counted from 1
+	// within a Lock call
+	NextDelay(attempt int) time.Duration
+}
+
+// WithRetryStrategy sets the strategy waiting Lock calls, read-write locks and
+// semaphores sleep by between attempts instead of the fixed retry interval. Releases
+// still wake waiting Lock calls early. WithCoordinatedBackoff takes precedence.
+func WithRetryStrategy(strategy RetryStrategy) Option {
+	return func(o *LockOptions) {
+		o.RetryStrategy = strategy
+	}
+}
+
+// nextDelay returns how long to sleep before the retry numbered attempt
+func (o *LockOptions) nextDelay(attempt int) time.Duration {
+	if o.RetryStrategy == nil {
+		return o.retryInterval()
+	}
+	return o.RetryStrategy.NextDelay(attempt)
+}
+
+// ConstantRetry returns a strategy sleeping d between all attempts
+func ConstantRetry(d time.Duration) RetryStrategy {
+	return constantRetry(d)
+}
+
+type constantRetry time.Duration
+
+func (s constantRetry) NextDelay(int) time.Duration {
+	return time.Duration(s)
+}
+
+// ExponentialRetry returns a strategy doubling the delay from base after every attempt
+// up to max. Each delay is drawn at random from its upper half, so waiters that failed
+// together spread out.
+func ExponentialRetry(base, max time.Duration) RetryStrategy {
+	return &exponentialRetry{base: base, max: max}
+}
+
+type exponentialRetry struct {
+	base, max time.Duration
+}
+
+func (s *exponentialRetry) NextDelay(attempt int) time.Duration {
+	delay := s.base
+	for i := 1; i < attempt && delay < s.max; i++ {
+		delay *= 2
+	}
+	delay = min(delay, s.max)
+	if delay <= 1 {
+		return delay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// FibonacciRetry returns a strategy growing the delay from base along the Fibonacci
+// sequence, base, base, 2*base, 3*base, 5*base and so on, up to max. It backs off more
+// gently than ExponentialRetry.
+func FibonacciRetry(base, max time.Duration) RetryStrategy {
+	return &fibonacciRetry{base: base, max: max}
+}
+
+type fibonacciRetry struct {
+	base, max time.Duration
+}
+
+func (s *fibonacciRetry) NextDelay(attempt int) time.Duration {
+	prev, delay := time.Duration(0), s.base
+	for i := 1; i < attempt && delay < s.max; i++ {
+		prev, delay = delay, prev+delay
+	}
+	return min(delay, s.max)
+}
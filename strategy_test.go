@@ -0,0 +1,102 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryStrategies(t *testing.T) {
+	t.Run("constant", func(t *testing.T) {
+		s := ConstantRetry(30 * time.Millisecond)
+		for attempt := 1; attempt <= 5; attempt++ {
+			if delay := s.NextDelay(attempt); delay != 30*time.Millisecond {
+				t.Errorf("Attempt %d: expected 30ms, got: %v", attempt, delay)
+			}
+		}
+	})
+
+	t.Run("exponential", func(t *testing.T) {
+		s := ExponentialRetry(10*time.Millisecond, 100*time.Millisecond)
+		for attempt, want := range map[int]time.Duration{1: 10, 2: 20, 3: 40, 4: 80, 5: 100, 50: 100} {
+			want *= time.Millisecond
+			for i := 0; i < 20; i++ {
+				if delay := s.NextDelay(attempt); delay < want/2 || delay > want {
+					t.Fatalf("Attempt %d: expected delay within [%v, %v], got: %v", attempt, want/2, want, delay)
+				}
+			}
+		}
+	})
+
+	t.Run("fibonacci", func(t *testing.T) {
+		s := FibonacciRetry(10*time.Millisecond, 60*time.Millisecond)
+		for attempt, want := range []time.Duration{10, 10, 20, 30, 50, 60, 60} {
+			if delay := s.NextDelay(attempt + 1); delay != want*time.Millisecond {
+				t.Errorf("Attempt %d: expected %v, got: %v", attempt+1, want*time.Millisecond, delay)
+			}
+		}
+	})
+}
+
+// countingStrategy records the attempts it was asked about
+type countingStrategy struct {
+	attempts []int
+}
+
+func (s *countingStrategy) NextDelay(attempt int) time.Duration {
+	s.attempts = append(s.attempts, attempt)
+	return 10 * time.Millisecond
+}
+
+func TestWithRetryStrategy(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	holder := client.NewLock("test-strategy")
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	strategy := &countingStrategy{}
+	err := client.NewLock("test-strategy", WithRetryStrategy(strategy), WithWaitTimeout(55*time.Millisecond)).Lock(ctx)
+	if err != ErrLockTimeout {
+		t.Fatalf("Expected ErrLockTimeout, got: %v", err)
+	}
+	if len(strategy.attempts) < 3 {
+		t.Fatalf("Expected the strategy to pace the retries, got: %v", strategy.attempts)
+	}
+	for i, attempt := range strategy.attempts {
+		if attempt != i+1 {
+			t.Fatalf("Expected consecutive attempts, got: %v", strategy.attempts)
+		}
+	}
+}
+
+func TestWithMaxRetries(t *testing.T) {
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithStore(newMemoryStore()))
+	ctx := context.Background()
+
+	holder := client.NewLock("test-retries")
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	strategy := &countingStrategy{}
+	start := time.Now()
+	err := client.NewLock("test-retries", WithRetryStrategy(strategy), WithMaxRetries(3), WithWaitTimeout(time.Minute)).Lock(ctx)
+	if !errors.Is(err, ErrMaxRetries) || Code(err) != CodeTimeout {
+		t.Fatalf("Expected ErrMaxRetries, got: %v", err)
+	}
+	if len(strategy.attempts) != 3 {
+		t.Errorf("Expected 3 retries, got: %v", strategy.attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to give up before the wait timeout, took %v", elapsed)
+	}
+
+	holder.Unlock(ctx)
+	if err := client.NewLock("test-retries", WithMaxRetries(3)).Lock(ctx); err != nil {
+		t.Errorf("Expected free lock to be acquired, got: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package arbiter
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Throttle lets an action run at most once per window across all instances sharing
+// the name, e.g. to send an alert at most every five minutes. It reports whether the
+// caller won the slot of the current window and should run the action. Windows start
+// with the first winning call, not on fixed boundaries.
+func (c *Client) Throttle(ctx context.Context, name string, window time.Duration) (bool, error) {
+	if routed := c.route(name); routed != c {
+		return routed.Throttle(ctx, name, window)
+	}
+	if err := c.policy.check(name); err != nil {
+		return false, err
+	}
+	reply, err := c.do(ctx, "SET", c.throttleKey(name), strconv.FormatInt(time.Now().UnixMilli(), 10), "PX", window.Milliseconds(), "NX")
+	if err != nil {
+		c.logger.Error(ctx, "Failed to throttle: %s, error: %v", name, err)
+		return false, err
+	}
+	won := reply != nil
+	if !won {
+		c.logger.Debug(ctx, "Throttled: %s", name)
+	}
+	return won, nil
+}
+
+// throttleKey returns the Redis key marking the current window of a throttle
+func (c *Client) throttleKey(name string) string {
+	return c.internalKey("throttle:" + name)
+}
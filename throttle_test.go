@@ -0,0 +1,41 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-throttle:"))
+	ctx := context.Background()
+	redisClient.Del(ctx, client.throttleKey("test-alert"))
+
+	var (
+		winners atomic.Int32
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if won, err := client.Throttle(ctx, "test-alert", 200*time.Millisecond); err == nil && won {
+				winners.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if winners.Load() != 1 {
+		t.Fatalf("Expected one winner per window, got %d", winners.Load())
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if won, err := client.Throttle(ctx, "test-alert", 200*time.Millisecond); err != nil || !won {
+		t.Errorf("Next window should be won again, got: %v, %v", won, err)
+	}
+}
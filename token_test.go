@@ -0,0 +1,52 @@
+package arbiter
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestToken(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		token := NewToken()
+		s := token.String()
+		if !strings.HasPrefix(s, "arb1.") {
+			t.Fatalf("Unexpected encoding: %s", s)
+		}
+
+		parsed, err := ParseToken(s)
+		if err != nil {
+			t.Fatalf("Failed to parse token: %v", err)
+		}
+		if parsed != token {
+			t.Errorf("ParseToken(%s) = %+v, want %+v", s, parsed, token)
+		}
+	})
+
+	t.Run("version 0", func(t *testing.T) {
+		legacy := base64.StdEncoding.EncodeToString(make([]byte, 16))
+
+		parsed, err := ParseToken(legacy)
+		if err != nil {
+			t.Fatalf("Failed to parse legacy token: %v", err)
+		}
+		if parsed.Version != 0 || parsed.String() != legacy {
+			t.Errorf("Unexpected legacy token: %+v", parsed)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"arb1.",
+			"arb1.short",
+			"arb1." + base64.RawURLEncoding.EncodeToString(make([]byte, 17)),
+			"arb1." + base64.URLEncoding.EncodeToString(make([]byte, 16)),
+			"not a token",
+		} {
+			if _, err := ParseToken(s); err != ErrInvalidToken {
+				t.Errorf("ParseToken(%q) error = %v, want %v", s, err, ErrInvalidToken)
+			}
+		}
+	})
+}
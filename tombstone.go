@@ -0,0 +1,138 @@
+package arbiter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// TombstoneReason tells how the acquisition recorded in a tombstone ended
+type TombstoneReason string
+
+const (
+	// ReasonReleased means the holder released the lock
+	ReasonReleased TombstoneReason = "released"
+	// ReasonExpired means the lease lapsed without being released, e.g. after a crash
+	ReasonExpired TombstoneReason = "expired"
+	// ReasonForceUnlocked means an operator released the lock with Admin.ForceUnlock
+	ReasonForceUnlocked TombstoneReason = "force_unlocked"
+	// ReasonStolen means an operator took the lock over with Admin.Steal
+	ReasonStolen TombstoneReason = "stolen"
+	// ReasonTransferred means the holder handed the lock to another owner with Transfer
+	ReasonTransferred TombstoneReason = "transferred"
+)
+
+// Tombstone describes the last ended acquisition of a lock
+type Tombstone struct {
+	Name   string
+	Owner  string
+	Fence  int64
+	Reason TombstoneReason
+
+	// Acquired is when the acquisition started, the zero time if unknown
+	Acquired time.Time
+
+	// Ended is when the lock was released, or when its lease lapsed
+	Ended time.Time
+}
+
+// WithTombstones keeps a tombstone of the last holder of every lock, for retention
+// after the lock was released or expired, so who held a lock last can be seen
+// without an external audit pipeline. Expiry is detected without keyspace
+// notifications: every acquisition and refresh records the holder next to the
+// lock, costing one more round trip each.
+func WithTombstones(retention time.Duration) ClientOption {
+	return func(c *Client) {
+		c.tombstones = retention
+	}
+}
+
+// LastHolder returns the tombstone of the last ended acquisition of a lock and
+// whether one is retained. It requires WithTombstones.
+func (c *Client) LastHolder(ctx context.Context, name string) (Tombstone, bool, error) {
+	r := c.route(name)
+	fields, err := replyFields(r.do(ctx, "HGETALL", r.tombstoneKey(r.lockKey(name))))
+	if err != nil {
+		return Tombstone{}, false, err
+	}
+
+	// A recorded holder past its lease expired without a successor yet
+	if expires := unixMilli(fields["holder_expires"]); fields["holder"] != "" && expires.Before(time.Now()) {
+		return Tombstone{
+			Name:     name,
+			Owner:    fields["holder"],
+			Fence:    parseFence(fields["holder_fence"]),
+			Reason:   ReasonExpired,
+			Acquired: unixMilli(fields["holder_acquired"]),
+			Ended:    expires,
+		}, true, nil
+	}
+	if fields["owner"] == "" {
+		return Tombstone{}, false, nil
+	}
+	return Tombstone{
+		Name:     name,
+		Owner:    fields["owner"],
+		Fence:    parseFence(fields["fence"]),
+		Reason:   TombstoneReason(fields["reason"]),
+		Acquired: unixMilli(fields["acquired"]),
+		Ended:    unixMilli(fields["ended"]),
+	}, true, nil
+}
+
+// holdTombstone records l as the current holder, acquired at acquired with fence.
+// Refreshes of the recorded holder only move its expiry and may pass a zero fence.
+func (l *lockImpl) holdTombstone(ctx context.Context, fence int64, acquired time.Time) {
+	c := l.client
+	if c.tombstones <= 0 || c.executor == nil {
+		return
+	}
+
+	expires := l.expires.Load()
+	ttl := time.Until(time.UnixMilli(expires)) + c.tombstones
+	_, err := c.runScript(ctx, lua.HoldTombstone, []string{c.tombstoneKey(l.key)}, l.value, fence,
+		acquired.UnixMilli(), expires, ttl.Milliseconds())
+	if err != nil {
+		l.logger.Warn(ctx, "Failed to record holder of lock: %s, error: %v", l.key, err)
+	}
+}
+
+// buryTombstone records how the acquisition of owner ended, fence is 0 if unknown
+func (c *Client) buryTombstone(ctx context.Context, lockKey, owner string, fence int64, reason TombstoneReason) {
+	if c.tombstones <= 0 || c.executor == nil {
+		return
+	}
+
+	var token string
+	if fence > 0 {
+		token = strconv.FormatInt(fence, 10)
+	}
+	_, err := c.runScript(ctx, lua.BuryTombstone, []string{c.tombstoneKey(lockKey)}, owner, token,
+		time.Now().UnixMilli(), string(reason), c.tombstones.Milliseconds())
+	if err != nil {
+		c.logger.Warn(ctx, "Failed to record tombstone of lock: %s, error: %v", lockKey, err)
+	}
+}
+
+// tombstoneKey returns the Redis key of the tombstone of a lock key
+func (c *Client) tombstoneKey(lockKey string) string {
+	return c.internalKey("tombstone:" + strings.TrimPrefix(lockKey, c.prefix))
+}
+
+// unixMilli parses a time stored in Unix milliseconds, the zero time if empty
+func unixMilli(value string) time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// parseFence parses a stored fencing token, 0 if empty
+func parseFence(value string) int64 {
+	fence, _ := strconv.ParseInt(value, 10, 64)
+	return fence
+}
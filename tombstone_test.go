@@ -0,0 +1,89 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTombstones(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-tombstone:"), WithTombstones(time.Minute))
+	ctx := context.Background()
+
+	t.Run("released locks leave a tombstone", func(t *testing.T) {
+		lock := client.NewLock("test-released")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		fence := lock.Fence()
+		if _, ok, _ := client.LastHolder(ctx, "test-released"); ok {
+			t.Fatal("Held lock without earlier holders should have no tombstone")
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatalf("Failed to release lock: %v", err)
+		}
+
+		tomb, ok, err := client.LastHolder(ctx, "test-released")
+		if err != nil || !ok {
+			t.Fatalf("Expected tombstone, got: %v, %v", ok, err)
+		}
+		if tomb.Reason != ReasonReleased || tomb.Owner != lock.(*lockImpl).value || tomb.Fence != fence {
+			t.Errorf("Unexpected tombstone: %+v", tomb)
+		}
+		if tomb.Acquired.IsZero() || tomb.Ended.Before(tomb.Acquired) {
+			t.Errorf("Unexpected timestamps: %+v", tomb)
+		}
+		if ttl := redisClient.PTTL(ctx, client.tombstoneKey(client.lockKey("test-released"))).Val(); ttl <= 0 || ttl > time.Minute {
+			t.Errorf("Tombstone should expire after the retention, got TTL: %v", ttl)
+		}
+	})
+
+	t.Run("expired leases are reported", func(t *testing.T) {
+		crashed := client.NewLock("test-expired", WithLeaseTime(200*time.Millisecond))
+		if err := crashed.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		owner := crashed.(*lockImpl).value
+		time.Sleep(300 * time.Millisecond)
+
+		tomb, ok, _ := client.LastHolder(ctx, "test-expired")
+		if !ok || tomb.Reason != ReasonExpired || tomb.Owner != owner {
+			t.Fatalf("Expected expired tombstone, got: %+v, %v", tomb, ok)
+		}
+
+		// The next holder keeps the expired acquisition visible once it ends
+		redisClient.Del(ctx, client.lockKey("test-expired"))
+		next := client.NewLock("test-expired")
+		if err := next.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		defer next.Unlock(ctx)
+		if tomb, _, _ := client.LastHolder(ctx, "test-expired"); tomb.Reason != ReasonExpired || tomb.Owner != owner {
+			t.Errorf("Expired holder should stay recorded, got: %+v", tomb)
+		}
+	})
+
+	t.Run("operator takeovers are recorded", func(t *testing.T) {
+		stuck := client.NewLock("test-stolen")
+		if err := stuck.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		lock, err := client.Admin().Steal(ctx, "test-stolen")
+		if err != nil {
+			t.Fatalf("Failed to steal lock: %v", err)
+		}
+		if tomb, _, _ := client.LastHolder(ctx, "test-stolen"); tomb.Reason != ReasonStolen || tomb.Owner != stuck.(*lockImpl).value {
+			t.Errorf("Expected stolen tombstone, got: %+v", tomb)
+		}
+
+		if err := client.Admin().ForceUnlock(ctx, "test-stolen"); err != nil {
+			t.Fatalf("Failed to force unlock: %v", err)
+		}
+		if tomb, _, _ := client.LastHolder(ctx, "test-stolen"); tomb.Reason != ReasonForceUnlocked || tomb.Owner != lock.(*lockImpl).value {
+			t.Errorf("Expected force unlocked tombstone, got: %+v", tomb)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package arbiter
+
+import (
+	"context"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+func (l *lockImpl) Transfer(ctx context.Context, token Token) error {
+	// The lease stays valid while the goroutines of its groups finish
+	l.waitGroups()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.client.policy.check(l.name); err != nil {
+		return err
+	}
+	owner := token.String()
+	ok, err := l.client.runScriptBool(ctx, lua.Transfer, []string{l.key, l.aux[0]}, l.value, owner)
+	if err != nil {
+		l.logger.Error(ctx, "Failed to transfer lock: %s, error: %v", l.key, err)
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+
+	l.stopWatchDog()
+	l.leaveHandoff()
+	l.held.Store(false)
+	l.client.buryTombstone(ctx, l.key, l.value, l.fence, ReasonTransferred)
+
+	l.logger.Info(ctx, "Transferred lock: %s, new owner: %s", l.key, owner)
+	return nil
+}
+
+func (l *scopedLock) Transfer(ctx context.Context, token Token) error {
+	l.scope.untrack(l)
+	return l.lock.Transfer(ctx, token)
+}
+
+// Transfer hands every lock to the owner token and returns the first error
+func (m *multiLock) Transfer(ctx context.Context, token Token) error {
+	var first error
+	for _, lock := range m.locks {
+		if err := lock.Transfer(ctx, token); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,58 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTransfer(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-transfer:"), WithTombstones(time.Minute))
+	ctx := context.Background()
+
+	draining := client.NewLock("test-leader", WithWatchDog(true))
+	if err := draining.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	fence := draining.Fence()
+	ttl := redisClient.PTTL(ctx, client.lockKey("test-leader")).Val()
+
+	token := NewToken()
+	if err := draining.Transfer(ctx, token); err != nil {
+		t.Fatalf("Failed to transfer lock: %v", err)
+	}
+	if owner := redisClient.HGet(ctx, client.lockKey("test-leader"), "owner").Val(); owner != token.String() {
+		t.Fatalf("Owner should be the new token, got: %s", owner)
+	}
+	if after := redisClient.PTTL(ctx, client.lockKey("test-leader")).Val(); after <= 0 || after > ttl {
+		t.Errorf("Transfer should keep the lease, got TTL %v after %v", after, ttl)
+	}
+	if tomb, _, _ := client.LastHolder(ctx, "test-leader"); tomb.Reason != ReasonTransferred {
+		t.Errorf("Expected transferred tombstone, got: %+v", tomb)
+	}
+
+	// Neither the previous holder nor third parties hold the lock
+	if err := draining.Unlock(ctx); err != ErrLockNotHeld {
+		t.Errorf("Expected not held error, got: %v", err)
+	}
+	if err := draining.Transfer(ctx, NewToken()); err != ErrLockNotHeld {
+		t.Errorf("Expected not held error, got: %v", err)
+	}
+	if acquired, _ := client.NewLock("test-leader").TryLock(ctx); acquired {
+		t.Fatal("Transferred lock should stay held")
+	}
+
+	replacement := client.NewLock("test-leader", WithOwnerToken(token), WithWatchDog(true))
+	if acquired, err := replacement.TryLock(ctx); err != nil || !acquired {
+		t.Fatalf("Replacement should take over the lock, got: %v, %v", acquired, err)
+	}
+	if replacement.Fence() != fence {
+		t.Errorf("Transfer should keep the fencing token %d, got: %d", fence, replacement.Fence())
+	}
+	if err := replacement.Unlock(ctx); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+}
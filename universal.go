@@ -0,0 +1,100 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisProtocol returns the RESP protocol a client was configured with, 0 if unset
+func redisProtocol(rc redis.UniversalClient) int {
+	switch rc := rc.(type) {
+	case *redis.Client:
+		return rc.Options().Protocol
+	case *redis.ClusterClient:
+		return rc.Options().Protocol
+	case *redis.Ring:
+		return rc.Options().Protocol
+	}
+	return 0
+}
+
+// redisAddr describes the server a client connects to, e.g. "localhost:6379/0", or
+// its seed nodes and shards for a cluster or ring
+func redisAddr(rc redis.UniversalClient) string {
+	switch rc := rc.(type) {
+	case *redis.Client:
+		opts := rc.Options()
+		return fmt.Sprintf("%s/%d", opts.Addr, opts.DB)
+	case *redis.ClusterClient:
+		return "cluster:" + strings.Join(rc.Options().Addrs, ",")
+	case *redis.Ring:
+		addrs := make([]string, 0, len(rc.Options().Addrs))
+		for _, addr := range rc.Options().Addrs {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+		return "ring:" + strings.Join(addrs, ",")
+	}
+	return fmt.Sprintf("%T", rc)
+}
+
+// primaryForKey returns a client of the primary owning key, for commands that must share
+// a connection with the writes to key. A ring cannot be asked for the shard of a key.
+func primaryForKey(ctx context.Context, rc redis.UniversalClient, key string) (*redis.Client, error) {
+	switch rc := rc.(type) {
+	case *redis.Client:
+		return rc, nil
+	case *redis.ClusterClient:
+		return rc.MasterForKey(ctx, key)
+	}
+	return nil, fmt.Errorf("cannot find the primary of a key on %T", rc)
+}
+
+// checkReplication refuses WithReplicationWait on a redis.Ring, which cannot tell the
+// shard of a key to run WAIT on
+func (c *Client) checkReplication() error {
+	if _, ok := c.store.(*redisStore); !ok {
+		return nil
+	}
+	if e, ok := c.executor.(*goRedisExecutor); ok {
+		if _, ok := e.redis.(*redis.Ring); ok {
+			return fmt.Errorf("%w: WithReplicationWait is not supported on a redis.Ring", ErrStoreUnsupported)
+		}
+	}
+	return nil
+}
+
+// scanKeys calls fn for every key matching match. A cluster or ring is scanned on each
+// of its primaries or shards, since SCAN only walks the keys of the node it runs on.
+func scanKeys(ctx context.Context, rc redis.UniversalClient, match string, fn func(key string) error) error {
+	// Nodes are scanned concurrently, fn is called by one at a time
+	var mu sync.Mutex
+	scan := func(ctx context.Context, node redis.Cmdable) error {
+		iter := node.Scan(ctx, 0, match, 100).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			err := fn(iter.Val())
+			mu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+	each := func(ctx context.Context, node *redis.Client) error {
+		return scan(ctx, node)
+	}
+
+	switch rc := rc.(type) {
+	case *redis.ClusterClient:
+		return rc.ForEachMaster(ctx, each)
+	case *redis.Ring:
+		return rc.ForEachShard(ctx, each)
+	}
+	return scan(ctx, rc)
+}
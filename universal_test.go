@@ -0,0 +1,39 @@
+package arbiter
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestUniversalClient(t *testing.T) {
+	single := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 2, Protocol: 2})
+	defer single.Close()
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"a:7000", "b:7001"}})
+	defer cluster.Close()
+	ring := redis.NewRing(&redis.RingOptions{Addrs: map[string]string{"two": "b:6379", "one": "a:6379"}, Protocol: 3})
+	defer ring.Close()
+
+	tests := []struct {
+		rc       redis.UniversalClient
+		addr     string
+		protocol int
+	}{
+		{single, "localhost:6379/2", 2},
+		{cluster, "cluster:a:7000,b:7001", 0},
+		{ring, "ring:a:6379,b:6379", 3},
+	}
+	for _, tt := range tests {
+		if addr := redisAddr(tt.rc); addr != tt.addr {
+			t.Errorf("Expected address %q, got: %q", tt.addr, addr)
+		}
+		if protocol := redisProtocol(tt.rc); protocol != tt.protocol {
+			t.Errorf("Expected protocol %d for %s, got: %d", tt.protocol, tt.addr, protocol)
+		}
+
+		// Every deployment is accepted as is
+		if client := NewClient(tt.rc, WithLogger(&NoopLogger{})); client.redis != tt.rc {
+			t.Errorf("Expected client to use the given Redis for %s", tt.addr)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type waitBudgetKey struct{}
+
+// waitBudget is the wait time left to the acquisitions sharing a context
+type waitBudget struct {
+	mu        sync.Mutex
+	remaining time.Duration
+}
+
+// ContextWithWaitBudget returns a context bounding the total time every acquisition
+// made with it spends waiting. Each lock, read-write lock, semaphore or redlock
+// waiting under the context draws from the same budget, so helpers composing several
+// acquisitions wait at most budget in total instead of the wait timeout of every
+// primitive. Once the budget is spent acquisitions try once and return ErrLockTimeout.
+// A wait timeout set on a lock still bounds its own wait.
+func ContextWithWaitBudget(ctx context.Context, budget time.Duration) context.Context {
+	return context.WithValue(ctx, waitBudgetKey{}, &waitBudget{remaining: max(budget, 0)})
+}
+
+// WaitBudgetFromContext returns the wait time left of the budget stored by ContextWithWaitBudget
+func WaitBudgetFromContext(ctx context.Context) (time.Duration, bool) {
+	b, ok := ctx.Value(waitBudgetKey{}).(*waitBudget)
+	if !ok {
+		return 0, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining, true
+}
+
+// waitDeadline returns when a wait starting now gives up: after timeout if one is
+// set and at the latest once the wait budget of ctx is spent. The zero time means
+// the wait is unbounded. The returned func charges the time waited to the budget.
+func waitDeadline(ctx context.Context, timeout time.Duration) (time.Time, func()) {
+	start := time.Now()
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = start.Add(timeout)
+	}
+
+	b, ok := ctx.Value(waitBudgetKey{}).(*waitBudget)
+	if !ok {
+		return deadline, func() {}
+	}
+
+	remaining, _ := WaitBudgetFromContext(ctx)
+	if spent := start.Add(remaining); deadline.IsZero() || spent.Before(deadline) {
+		deadline = spent
+	}
+	return deadline, func() {
+		b.mu.Lock()
+		b.remaining = max(b.remaining-time.Since(start), 0)
+		b.mu.Unlock()
+	}
+}
+
+// waitExpired reports whether a wait bounded by deadline has to give up
+func waitExpired(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
@@ -0,0 +1,85 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitBudget(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-budget:"))
+	ctx := context.Background()
+
+	holder := client.NewMultiLock([]string{"a", "b"})
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire locks: %v", err)
+	}
+	defer holder.Unlock(ctx)
+
+	t.Run("waits share one budget", func(t *testing.T) {
+		budgetCtx := ContextWithWaitBudget(ctx, 300*time.Millisecond)
+
+		start := time.Now()
+		if err := client.NewLock("a").Lock(budgetCtx); err != ErrLockTimeout {
+			t.Fatalf("Expected ErrLockTimeout, got: %v", err)
+		}
+		if err := client.NewLock("b").Lock(budgetCtx); err != ErrLockTimeout {
+			t.Fatalf("Expected ErrLockTimeout, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 600*time.Millisecond {
+			t.Errorf("Both waits should fit in the budget, took %v", elapsed)
+		}
+		if remaining, ok := WaitBudgetFromContext(budgetCtx); !ok || remaining != 0 {
+			t.Errorf("Budget should be spent, got: %v, %v", remaining, ok)
+		}
+	})
+
+	t.Run("wait timeout bounds the own wait", func(t *testing.T) {
+		budgetCtx := ContextWithWaitBudget(ctx, time.Minute)
+		lock := client.NewLock("a", WithWaitTimeout(200*time.Millisecond))
+		if err := lock.Lock(budgetCtx); err != ErrLockTimeout {
+			t.Fatalf("Expected ErrLockTimeout, got: %v", err)
+		}
+		if remaining, _ := WaitBudgetFromContext(budgetCtx); remaining > time.Minute-200*time.Millisecond {
+			t.Errorf("Wait should be charged to the budget, %v left", remaining)
+		}
+	})
+
+	t.Run("spent budget still tries once", func(t *testing.T) {
+		budgetCtx := ContextWithWaitBudget(ctx, 0)
+		lock := client.NewLock("free")
+		if err := lock.Lock(budgetCtx); err != nil {
+			t.Fatalf("Free lock should be acquired: %v", err)
+		}
+		lock.Unlock(ctx)
+
+		rw := client.NewRWLock("a-rw")
+		writer := client.NewRWLock("a-rw")
+		if err := writer.Lock(ctx); err != nil {
+			t.Fatalf("Failed to acquire write lock: %v", err)
+		}
+		defer writer.Unlock(ctx)
+		if err := rw.RLock(budgetCtx); err != ErrLockTimeout {
+			t.Errorf("Expected ErrLockTimeout, got: %v", err)
+		}
+	})
+
+	t.Run("multi lock members draw from the budget", func(t *testing.T) {
+		budgetCtx := ContextWithWaitBudget(ctx, 200*time.Millisecond)
+		start := time.Now()
+		if err := client.NewMultiLock([]string{"a", "0"}).Lock(budgetCtx); err != ErrLockTimeout {
+			t.Fatalf("Expected ErrLockTimeout, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Multi lock should stop with the budget, took %v", elapsed)
+		}
+		member := client.NewLock("0")
+		if acquired, _ := member.TryLock(ctx); !acquired {
+			t.Error("Members acquired before the timeout should be released")
+		}
+		member.Unlock(ctx)
+	})
+}
@@ -0,0 +1,48 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+)
+
+// releaseWait is the longest a waiting Lock call sleeps between attempts while releases
+// wake it, catching leases that lapsed and wakeups lost with a broken subscription
+const releaseWait = time.Second
+
+// releasesChannel returns the pub/sub channel releases are published on to wake waiting
+// Lock calls. Acquisitions are not published on it, so waiters only wake when there is
+// a lock to take.
+func (c *Client) releasesChannel() string {
+	return c.internalKey("released")
+}
+
+// watchReleases returns a channel receiving when the lock is released and a function
+// ending the watch. The channel is nil if releases cannot be watched, the Lock call
+// keeps polling then.
+func (l *lockImpl) watchReleases(ctx context.Context) (<-chan struct{}, func()) {
+	released := make(chan struct{}, 1)
+	wake := func(key string) {
+		if key != l.key {
+			return
+		}
+		select {
+		case released <- struct{}{}:
+		default:
+		}
+	}
+
+	var (
+		stop func()
+		err  error
+	)
+	if l.client.executor != nil {
+		stop, err = l.client.releases.listen(ctx, wake)
+	} else {
+		stop, err = l.store.Watch(ctx, wake)
+	}
+	if err != nil {
+		l.logger.Debug(ctx, "Polling for release of lock: %s, error: %v", l.key, err)
+		return nil, func() {}
+	}
+	return released, stop
+}
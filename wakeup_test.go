@@ -0,0 +1,79 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// notifyingStore reports every release of a memoryStore to its watchers
+type notifyingStore struct {
+	*memoryStore
+	watchMu  sync.Mutex
+	watchers []func(key string)
+}
+
+func (s *notifyingStore) Release(ctx context.Context, key, owner string) (bool, error) {
+	released, err := s.memoryStore.Release(ctx, key, owner)
+	if released {
+		s.watchMu.Lock()
+		for _, fn := range s.watchers {
+			fn(key)
+		}
+		s.watchMu.Unlock()
+	}
+	return released, err
+}
+
+func (s *notifyingStore) Watch(ctx context.Context, fn func(key string)) (func(), error) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	s.watchers = append(s.watchers, fn)
+	return func() {}, nil
+}
+
+// testReleaseWakeup checks that a waiting Lock call acquires right after the release,
+// well before its fallback timer
+func testReleaseWakeup(t *testing.T, client *Client) {
+	ctx := context.Background()
+	holder := client.NewLock("test-wakeup", WithLeaseTime(30*time.Second))
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	acquired := make(chan time.Time, 1)
+	go func() {
+		waiter := client.NewLock("test-wakeup", WithLeaseTime(30*time.Second), WithWaitTimeout(5*time.Second))
+		if err := waiter.Lock(ctx); err != nil {
+			t.Errorf("Failed to acquire released lock: %v", err)
+		}
+		acquired <- time.Now()
+		waiter.Unlock(ctx)
+	}()
+
+	// The waiter is watching by now
+	time.Sleep(100 * time.Millisecond)
+	released := time.Now()
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+
+	if wait := (<-acquired).Sub(released); wait > releaseWait/2 {
+		t.Errorf("Expected waiter to be woken by the release, acquired after %v", wait)
+	}
+}
+
+func TestReleaseWakeup(t *testing.T) {
+	store := &notifyingStore{memoryStore: newMemoryStore()}
+	testReleaseWakeup(t, NewClient(nil, WithLogger(&NoopLogger{}), WithStore(store)))
+}
+
+func TestReleaseWakeupRedis(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-wakeup:"))
+	defer client.Close()
+	testReleaseWakeup(t, client)
+}
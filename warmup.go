@@ -0,0 +1,72 @@
+package arbiter
+
+import (
+	"context"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+// warmupScripts are the scripts on the acquisition path, loaded by Warmup
+var warmupScripts = []string{lua.TryLock, lua.Unlock, lua.Refresh, lua.NextAttempt}
+
+// WithWarmupSubscription makes Warmup also establish the pub/sub subscription shared by
+// latches, barriers, condition variables and the state cache
+func WithWarmupSubscription() ClientOption {
+	return func(c *Client) {
+		c.warmupSubscription = true
+	}
+}
+
+// Warmup prepares the client and its routes for their first acquisition, so a critical
+// acquisition right after a deploy does not pay cold-start latency. It runs the replica
+// check, loads the Lua scripts of the acquisition path into Redis' script cache, and the
+// function library with WithFunctions, and opens a connection, subscribes if
+// WithWarmupSubscription is set, and reads the state of the named locks, which also fills
+// the state cache.
+func (c *Client) Warmup(ctx context.Context, names ...string) error {
+	if c.executor == nil {
+		return ErrStoreUnsupported
+	}
+	for _, backend := range c.backends() {
+		if err := backend.warmup(ctx); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if _, err := c.IsLocked(ctx, name); err != nil {
+			c.logger.Warn(ctx, "Failed to warm up lock: %s, error: %v", name, err)
+			return err
+		}
+	}
+	c.logger.Debug(ctx, "Warmed up client with %d locks", len(names))
+	return nil
+}
+
+// warmup prepares the Redis of a single backend
+func (c *Client) warmup(ctx context.Context) error {
+	if err := c.checkRole(ctx); err != nil {
+		return err
+	}
+
+	// Scripts run by their digest once loaded instead of being sent on first use
+	for _, script := range warmupScripts {
+		if _, err := c.executor.ScriptLoad(ctx, script); err != nil {
+			c.logger.Warn(ctx, "Failed to load scripts, error: %v", err)
+			return err
+		}
+	}
+	if err := c.loadFunctions(ctx); err != nil {
+		c.logger.Warn(ctx, "Failed to load functions, error: %v", err)
+		return err
+	}
+
+	if c.warmupSubscription {
+		// The subscription outlives the listener
+		stop, err := c.notifier.listen(ctx, func(string) {})
+		if err != nil {
+			return err
+		}
+		stop()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package arbiter
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/huimingz/arbiter/internal/lua"
+)
+
+func TestWarmup(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-warmup:"), WithWarmupSubscription())
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := redisClient.ScriptFlush(ctx).Err(); err != nil {
+		t.Fatalf("Failed to flush scripts: %v", err)
+	}
+	if err := client.Warmup(ctx, "test-lock"); err != nil {
+		t.Fatalf("Warmup failed: %v", err)
+	}
+
+	digest := sha1.Sum([]byte(lua.TryLock))
+	loaded, err := redisClient.ScriptExists(ctx, hex.EncodeToString(digest[:])).Result()
+	if err != nil || !loaded[0] {
+		t.Errorf("TryLock script should be loaded, got: %v, %v", loaded, err)
+	}
+	if client.notifier.stop == nil {
+		t.Error("Warmup should subscribe to lock events")
+	}
+}
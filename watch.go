@@ -0,0 +1,141 @@
+package arbiter
+
+import (
+	"context"
+	"time"
+)
+
+// watchResyncInterval is how often Watch re-reads every watched lock, catching
+// transitions that are not published such as lease expiry
+const watchResyncInterval = 5 * time.Second
+
+// StateChange describes the state of a watched lock after a transition
+type StateChange struct {
+	Name  string
+	Held  bool
+	Owner string
+	Time  time.Time
+}
+
+// Watch streams the state transitions of the named locks until ctx is done.
+// The current state of every lock is sent first. Acquisitions and releases are
+// pushed as the store of each lock reports them, expirations are noticed by a
+// periodic resync. The channel is closed when ctx is done.
+func (c *Client) Watch(ctx context.Context, names ...string) (<-chan StateChange, error) {
+	byKey := make(map[string]string, len(names))
+	stores := make(map[Store]bool)
+	for _, name := range names {
+		routed := c.route(name)
+		byKey[routed.lockKey(name)] = name
+		stores[routed.store] = true
+	}
+
+	changed := make(chan string, len(names)+16)
+	var stops []func()
+	stop := func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+	for store := range stores {
+		stopWatching, err := store.Watch(ctx, func(key string) {
+			if _, ok := byKey[key]; !ok {
+				return
+			}
+			select {
+			case changed <- key:
+			default:
+				// A full queue already holds a pending re-read, the resync catches up
+			}
+		})
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		stops = append(stops, stopWatching)
+	}
+
+	// The current states are read before returning, so clients that cannot read them fail here
+	initial := make([]StateChange, 0, len(byKey))
+	for _, name := range byKey {
+		change, err := c.readState(ctx, name)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		initial = append(initial, change)
+	}
+
+	out := make(chan StateChange)
+	go func() {
+		defer close(out)
+		defer stop()
+
+		ticker := time.NewTicker(watchResyncInterval)
+		defer ticker.Stop()
+
+		last := make(map[string]StateChange, len(byKey))
+		emit := func(change StateChange) bool {
+			if prev, ok := last[change.Name]; ok && prev.Held == change.Held && prev.Owner == change.Owner {
+				return true
+			}
+			last[change.Name] = change
+			select {
+			case out <- change:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		send := func(key string) bool {
+			change, err := c.readState(ctx, byKey[key])
+			if err != nil {
+				c.logger.Warn(ctx, "Failed to read watched lock: %s, error: %v", key, err)
+				return ctx.Err() == nil
+			}
+			return emit(change)
+		}
+		resync := func() bool {
+			for key := range byKey {
+				if !send(key) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, change := range initial {
+			if !emit(change) {
+				return
+			}
+		}
+		for {
+			select {
+			case key := <-changed:
+				if !send(key) {
+					return
+				}
+			case <-ticker.C:
+				if !resync() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// readState reads the current state of a lock
+func (c *Client) readState(ctx context.Context, name string) (StateChange, error) {
+	c = c.route(name)
+	fields, err := replyFields(c.do(ctx, "HGETALL", c.lockKey(name)))
+	if err != nil {
+		return StateChange{}, err
+	}
+
+	owner, held := fields[ownerField]
+	return StateChange{Name: name, Held: held || readers(fields) > 0, Owner: owner, Time: time.Now()}, nil
+}
@@ -0,0 +1,94 @@
+package arbiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	redisClient := setupRedis(t)
+	defer redisClient.Close()
+
+	client := NewClient(redisClient, WithKeyPrefix("test-watch:"))
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := client.Watch(ctx, "test-watched")
+	if err != nil {
+		t.Fatalf("Failed to watch lock: %v", err)
+	}
+
+	next := func() StateChange {
+		t.Helper()
+		select {
+		case change := <-changes:
+			return change
+		case <-time.After(time.Second):
+			t.Fatal("No state change received")
+			return StateChange{}
+		}
+	}
+
+	if change := next(); change.Name != "test-watched" || change.Held {
+		t.Fatalf("Unexpected initial state: %+v", change)
+	}
+
+	// Changes of other locks are not reported
+	other := client.NewLock("test-unwatched")
+	if err := other.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer other.Unlock(ctx)
+
+	lock := client.NewLock("test-watched")
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	if change := next(); change.Name != "test-watched" || !change.Held || change.Owner == "" {
+		t.Fatalf("Expected acquisition, got: %+v", change)
+	}
+
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	if change := next(); change.Held {
+		t.Fatalf("Expected release, got: %+v", change)
+	}
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestWatchExecutor(t *testing.T) {
+	executor := &fakeExecutor{
+		commands: map[string]interface{}{"HGETALL": map[interface{}]interface{}{}},
+		calls:    make(map[string]int),
+	}
+	client := NewClient(nil, WithLogger(&NoopLogger{}), WithExecutor(executor))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := client.Watch(ctx, "test-watched")
+	if err != nil {
+		t.Fatalf("Failed to watch lock: %v", err)
+	}
+	if change := <-changes; change.Held {
+		t.Fatalf("Unexpected initial state: %+v", change)
+	}
+
+	// Transitions arrive through the subscription of the executor
+	executor.reply("HGETALL", map[interface{}]interface{}{ownerField: "owner"})
+	executor.subscriber(client.lockKey("test-watched"))
+	select {
+	case change := <-changes:
+		if !change.Held || change.Owner != "owner" {
+			t.Errorf("Expected acquisition, got: %+v", change)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("No state change received")
+	}
+}
@@ -0,0 +1,139 @@
+//go:build !arbiterminimal
+
+package arbiter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookSink is an EventSink that POSTs every event as JSON to an endpoint.
+// Events are queued and delivered in the background, failed deliveries are
+// retried with exponential backoff and dropped once the retries are exhausted.
+type WebhookSink struct {
+	url     string
+	client  *http.Client
+	headers http.Header
+	retries int
+	backoff time.Duration
+
+	queue eventQueue
+}
+
+// WebhookOption is a function type for setting webhook sink options
+type WebhookOption func(*WebhookSink)
+
+// WithWebhookHTTPClient sets the HTTP client used to deliver events
+func WithWebhookHTTPClient(client *http.Client) WebhookOption {
+	return func(s *WebhookSink) {
+		s.client = client
+	}
+}
+
+// WithWebhookHeader adds a header sent with every delivery, e.g. for authentication
+func WithWebhookHeader(key, value string) WebhookOption {
+	return func(s *WebhookSink) {
+		s.headers.Add(key, value)
+	}
+}
+
+// WithWebhookRetries sets how often a failed delivery is retried and the initial backoff
+func WithWebhookRetries(retries int, backoff time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.retries = retries
+		s.backoff = backoff
+	}
+}
+
+// WithWebhookQueueSize sets how many events may wait for delivery before new ones are dropped
+func WithWebhookQueueSize(size int) WebhookOption {
+	return func(s *WebhookSink) {
+		s.queue.queue = make(chan Event, size)
+	}
+}
+
+// WithWebhookLogger sets the logger reporting failed deliveries
+func WithWebhookLogger(logger Logger) WebhookOption {
+	return func(s *WebhookSink) {
+		s.queue.logger = logger
+	}
+}
+
+// NewWebhookSink creates a webhook sink delivering events to url until Close is called
+func NewWebhookSink(url string, opts ...WebhookOption) *WebhookSink {
+	s := &WebhookSink{
+		url:     url,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		headers: make(http.Header),
+		retries: 3,
+		backoff: 500 * time.Millisecond,
+		queue: eventQueue{
+			name:   "webhook",
+			logger: newDefaultLogger(),
+			queue:  make(chan Event, 256),
+		},
+	}
+	s.queue.deliver = s.deliver
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.queue.start()
+	return s
+}
+
+// Emit queues the event for delivery, dropping it if the queue is full
+func (s *WebhookSink) Emit(ctx context.Context, event Event) {
+	s.queue.push(ctx, event)
+}
+
+// Close stops accepting events and waits until the queued ones are delivered
+func (s *WebhookSink) Close() {
+	s.queue.close()
+}
+
+// deliver POSTs the event, retrying network errors, 429 and 5xx responses
+func (s *WebhookSink) deliver(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(ctx, body)
+		if !retry || attempt >= s.retries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends the body once and reports whether a failure is worth retrying
+func (s *WebhookSink) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header = s.headers.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return false, nil
+}
@@ -0,0 +1,74 @@
+//go:build !arbiterminimal
+
+package arbiter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink(t *testing.T) {
+	t.Run("retries failed deliveries", func(t *testing.T) {
+		var calls atomic.Int32
+		received := make(chan Event, 1)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				t.Errorf("Missing authorization header")
+			}
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			var event Event
+			if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+				t.Errorf("Failed to decode event: %v", err)
+			}
+			received <- event
+		}))
+		defer server.Close()
+
+		sink := NewWebhookSink(server.URL,
+			WithWebhookHeader("Authorization", "Bearer token"),
+			WithWebhookRetries(3, time.Millisecond),
+			WithWebhookLogger(&NoopLogger{}),
+		)
+		sink.Emit(context.Background(), Event{Type: EventForceUnlock, Name: "test-webhook", Time: time.Now()})
+		sink.Close()
+
+		select {
+		case event := <-received:
+			if event.Type != EventForceUnlock || event.Name != "test-webhook" {
+				t.Errorf("Unexpected event: %+v", event)
+			}
+		default:
+			t.Fatal("Event was not delivered")
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("Expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		sink := NewWebhookSink(server.URL, WithWebhookRetries(3, time.Millisecond), WithWebhookLogger(&NoopLogger{}))
+		sink.Emit(context.Background(), Event{Type: EventLockLost, Name: "test-webhook"})
+		sink.Close()
+
+		if got := calls.Load(); got != 1 {
+			t.Errorf("Expected 1 attempt, got %d", got)
+		}
+	})
+}